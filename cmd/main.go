@@ -2,20 +2,28 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/manifoldco/promptui"
 
+	"easilypanel/internal/audit"
 	"easilypanel/internal/config"
+	"easilypanel/internal/content"
 	"easilypanel/internal/download"
 	"easilypanel/internal/frp"
+	"easilypanel/internal/httpclient"
 	"easilypanel/internal/instance"
 	"easilypanel/internal/java"
 	"easilypanel/internal/menu"
@@ -80,6 +88,14 @@ func showHelpInfo() {
 	fmt.Println("    start NAME    启动指定实例")
 	fmt.Println("    stop NAME     停止指定实例")
 	fmt.Println("    status NAME   查看实例状态")
+	fmt.Println("    plugins ACTION NAME [...]  管理插件 (list/add/remove/enable/disable)")
+	fmt.Println("    mods ACTION NAME [...]     管理模组 (list/add/remove/enable/disable)")
+	fmt.Println("    content ACTION NAME [...]  从Modrinth/Hangar搜索、安装和更新 (search/install/installed/outdated/update)")
+	fmt.Println("    import-pack [-name NAME] FILE  从整合包 (.mrpack 或CurseForge服务端包) 创建实例")
+	fmt.Println("    upgrade [-build N | -latest] [-start] NAME  原地升级服务端核心，启动失败时恢复旧核心")
+	fmt.Println("    verify NAME   重新计算服务端核心的SHA1，检查文件是否损坏或被篡改")
+	fmt.Println("    create -core TYPE -mc VERSION [-build B] NAME  创建实例并安装核心 (vanilla/fabric/forge/neoforge 或FastMirror服务端名称)")
+	fmt.Println("    install-core [-core TYPE] [-build B] NAME      为已有实例安装或重装服务端核心")
 	fmt.Println()
 	fmt.Println("  frp             内网穿透管理")
 	fmt.Println("    status        查看frpc状态")
@@ -91,12 +107,16 @@ func showHelpInfo() {
 	fmt.Println("    detect        检测Java版本")
 	fmt.Println("    list          列出Java版本")
 	fmt.Println()
+	fmt.Println("  audit           查询审计日志")
+	fmt.Println("    -actor/-source/-action/-target/-result/-since/-until/-limit 过滤条件")
+	fmt.Println()
 	fmt.Println("示例:")
 	fmt.Println("  easilypanel                    # 启动交互式界面")
 	fmt.Println("  easilypanel -version           # 显示版本信息")
 	fmt.Println("  easilypanel instance list      # 列出所有实例")
 	fmt.Println("  easilypanel frp status         # 查看frpc状态")
 	fmt.Println("  easilypanel java detect        # 检测Java版本")
+	fmt.Println("  easilypanel audit -action instance.Stop -since 24h  # 查看最近24小时的停止操作")
 }
 
 // runInteractiveMenu 运行交互式菜单
@@ -127,10 +147,21 @@ func runInteractiveMenu(configFile, dataDir, logLevel string, daemon bool) {
 		config.Set("app.log_level", logLevel)
 	}
 
+	// 初始化审计日志、网络设置、内存上限和新实例的额外JVM参数
+	// 守护进程中的操作都由后台任务发起，记录为scheduler来源
+	source := audit.SourceMenu
+	if daemon {
+		source = audit.SourceScheduler
+	}
+	audit.Init(config.GetString("log.audit_file"), source)
+	initNetwork()
+	java.SetMaxMemoryPercent(config.GetInt("instance.max_memory_percent"))
+	instance.SetDefaultJavaArgs(config.GetStringSlice("instance.default_java_args"))
+
 	// 守护进程模式
 	if daemon {
 		fmt.Println("守护进程模式启动...")
-		// 这里可以添加守护进程逻辑
+		runDaemon(config.GetString("app.data_dir"))
 		return
 	}
 
@@ -145,6 +176,310 @@ func runInteractiveMenu(configFile, dataDir, logLevel string, daemon bool) {
 	menuSystem.Run()
 }
 
+// runDaemon 运行守护进程的后台任务，直到收到退出信号
+func runDaemon(dataDir string) {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	if config.GetBool("app.check_updates") {
+		interval := config.GetDuration("app.update_check_interval")
+		if interval <= 0 {
+			interval = 6 * time.Hour
+		}
+
+		// 每个下载源只检查从该下载源安装核心的实例
+		manager := instance.NewManager(filepath.Join(dataDir, "instances"))
+		for _, mirror := range newDownloadManager(dataDir).Mirrors() {
+			checker := instance.NewCoreUpdateChecker(manager, mirror)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				checker.Run(interval, stop, reportCoreUpdates)
+			}()
+		}
+		fmt.Printf("服务端核心更新检查已启用 (间隔 %s)\n", interval)
+	} else {
+		fmt.Println("服务端核心更新检查已禁用 (app.check_updates)")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	fmt.Println("正在停止守护进程...")
+	close(stop)
+	wg.Wait()
+}
+
+// reportCoreUpdates 输出后台核心更新检查的结果
+func reportCoreUpdates(outdated []*instance.Instance, errs []error) {
+	for _, err := range errs {
+		fmt.Printf("⚠️  检查核心更新失败: %v\n", err)
+	}
+	for _, inst := range outdated {
+		fmt.Printf("[%s] 实例 '%s' 的服务端核心有更新: %s %s -> %s\n",
+			time.Now().Format("2006-01-02 15:04:05"), inst.Name, inst.ServerType, inst.CoreVersion, inst.LatestCoreVersion)
+	}
+}
+
+// initNetwork 把配置中的代理、超时和User-Agent应用到所有HTTP客户端
+func initNetwork() {
+	err := httpclient.Init(httpclient.Options{
+		ProxyEnabled: config.GetBool("network.proxy_enabled"),
+		ProxyType:    config.GetString("network.proxy_type"),
+		ProxyHost:    config.GetString("network.proxy_host"),
+		ProxyPort:    config.GetInt("network.proxy_port"),
+		ProxyUser:    config.GetString("network.proxy_user"),
+		ProxyPass:    config.GetString("network.proxy_pass"),
+		Timeout:      time.Duration(config.GetInt("network.timeout")) * time.Second,
+		UserAgent:    config.GetString("network.user_agent"),
+	})
+	if err != nil {
+		fmt.Printf("⚠️  代理设置无效，将直接连接: %v\n", err)
+	}
+}
+
+// newDownloadManager 创建使用配置的下载源和并发数的下载管理器
+func newDownloadManager(dataDir string) *download.DownloadManager {
+	dm := download.NewDownloadManager(dataDir)
+	dm.SetSourceURLs(config.GetStringMapString("download.sources"))
+	if err := dm.SetMirrorOrder(config.GetStringSlice("download.mirror_order")); err != nil {
+		fmt.Printf("⚠️  download.mirror_order: %v\n", err)
+	}
+	dm.SetMaxConcurrent(config.GetInt("download.max_concurrent"))
+	dm.SetDefaultSource(config.GetString("download.default_source"))
+	dm.SetCacheCleanup(config.GetBool("download.auto_cleanup"), config.GetInt("download.cleanup_days"))
+	globalLimit, err := download.ParseRate(config.GetString("download.rate_limit"))
+	if err != nil {
+		fmt.Printf("⚠️  download.rate_limit: %v\n", err)
+	}
+	jobLimit, err := download.ParseRate(config.GetString("download.job_rate_limit"))
+	if err != nil {
+		fmt.Printf("⚠️  download.job_rate_limit: %v\n", err)
+	}
+	dm.SetRateLimit(globalLimit, jobLimit)
+	if err := dm.SetChecksumPolicy(config.GetBool("download.verify_checksum"), config.GetStringMapString("download.signing_keys")); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	return dm
+}
+
+// handleOfficialCoreInstall 交互式从官方源安装服务端核心并创建实例
+func handleOfficialCoreInstall() error {
+	fmt.Println("=== 官方核心安装 ===")
+	scanner := bufio.NewScanner(os.Stdin)
+
+	coreTypes := []string{"vanilla", "fabric", "forge", "neoforge"}
+	for i, coreType := range coreTypes {
+		fmt.Printf("%d. %s\n", i+1, coreType)
+	}
+	fmt.Print("请选择服务端类型 (输入序号): ")
+	if !scanner.Scan() {
+		return fmt.Errorf("读取输入失败")
+	}
+	index := -1
+	for i := range coreTypes {
+		if fmt.Sprintf("%d", i+1) == strings.TrimSpace(scanner.Text()) {
+			index = i
+		}
+	}
+	if index == -1 {
+		return fmt.Errorf("无效的选择")
+	}
+
+	dm := newDownloadManager("./data")
+	provider := dm.CoreProvider(coreTypes[index])
+
+	versions, err := provider.ListVersions()
+	if err != nil {
+		return err
+	}
+	if len(versions) > 10 {
+		versions = versions[:10]
+	}
+	fmt.Printf("最近的版本: %s\n", strings.Join(versions, ", "))
+	fmt.Print("请输入Minecraft版本: ")
+	if !scanner.Scan() {
+		return fmt.Errorf("读取输入失败")
+	}
+	mcVersion := strings.TrimSpace(scanner.Text())
+	if mcVersion == "" {
+		return fmt.Errorf("版本不能为空")
+	}
+
+	defaultName := fmt.Sprintf("%s-%s", provider.Name(), mcVersion)
+	fmt.Printf("请输入实例名称 (默认: %s): ", defaultName)
+	if !scanner.Scan() {
+		return fmt.Errorf("读取输入失败")
+	}
+	name := strings.TrimSpace(scanner.Text())
+	if name == "" {
+		name = defaultName
+	}
+
+	javaPath := ensureJavaForMinecraft(mcVersion, scanner)
+	handleInstanceCreateCommand([]string{"-core", provider.Name(), "-mc", mcVersion, "-java", javaPath, name},
+		instance.NewManager("./data/instances"), "./data")
+	return nil
+}
+
+// handleInstanceCreateCommand 创建实例并通过核心提供者安装服务端
+func handleInstanceCreateCommand(args []string, manager *instance.Manager, dataDir string) {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	coreType := flags.String("core", "vanilla", "服务端类型 (vanilla, fabric, forge, neoforge 或FastMirror服务端名称)")
+	mcVersion := flags.String("mc", "", "Minecraft版本")
+	build := flags.String("build", "", "核心构建版本，默认最新")
+	javaPath := flags.String("java", "", "Java路径或主版本选择器 (如 21、21:temurin)，默认根据Minecraft版本选择")
+	installJava := flags.Bool("install-java", false, "没有满足版本要求的Java时自动安装")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	if flags.NArg() < 1 || *mcVersion == "" {
+		fmt.Println("用法: instance create -core TYPE -mc VERSION [-build B] [-java PATH|MAJOR[:VENDOR]] [-install-java] NAME")
+		return
+	}
+	name := flags.Arg(0)
+
+	if *javaPath == "" {
+		*javaPath = resolveJavaForMinecraft(*mcVersion)
+	}
+	if *javaPath == "" {
+		required := java.RequiredJavaMajor(*mcVersion)
+		if *installJava {
+			if installed, err := installJavaRuntime(required, "jdk"); err == nil {
+				*javaPath = installed.Path
+			} else {
+				fmt.Printf("⚠️  安装Java %d失败: %v\n", required, err)
+			}
+		} else {
+			fmt.Printf("⚠️  未找到Java %d或更高版本 (Minecraft %s需要)，可使用 -install-java 或 'java install %d' 安装\n", required, *mcVersion, required)
+		}
+	}
+	if *javaPath == "" {
+		*javaPath = "java"
+	}
+
+	dm := newDownloadManager(dataDir)
+	provider := dm.CoreProvider(*coreType)
+
+	inst, err := manager.CreateMinecraftInstance(name, *mcVersion, provider.Name(), *javaPath)
+	if err != nil {
+		fmt.Printf("❌ 创建实例失败: %v\n", err)
+		return
+	}
+
+	if err := installInstanceCore(manager, dm, provider, inst, *build, filepath.Join(dataDir, "instances")); err != nil {
+		fmt.Printf("❌ 安装核心失败: %v\n", err)
+		manager.DeleteInstance(name, true)
+		return
+	}
+	fmt.Printf("✓ 实例 '%s' 创建成功\n", name)
+}
+
+// handleInstallCoreCommand 为已有实例安装服务端核心
+func handleInstallCoreCommand(args []string, manager *instance.Manager, dataDir string) {
+	flags := flag.NewFlagSet("install-core", flag.ContinueOnError)
+	coreType := flags.String("core", "", "服务端类型，默认使用实例的服务端类型")
+	build := flags.String("build", "", "核心构建版本，默认最新")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	if flags.NArg() < 1 {
+		fmt.Println("用法: instance install-core [-core TYPE] [-build B] NAME")
+		return
+	}
+
+	inst, err := manager.GetInstance(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("未找到实例: %s\n", flags.Arg(0))
+		return
+	}
+	if inst.IsRunning() {
+		fmt.Println("错误: 实例正在运行，请先停止")
+		return
+	}
+	if *coreType == "" {
+		*coreType = inst.ServerType
+	}
+
+	dm := newDownloadManager(dataDir)
+	provider := dm.CoreProvider(*coreType)
+	inst.ServerType = provider.Name()
+
+	if err := installInstanceCore(manager, dm, provider, inst, *build, filepath.Join(dataDir, "instances")); err != nil {
+		fmt.Printf("❌ 安装核心失败: %v\n", err)
+	}
+}
+
+// instanceJavaPath 返回实例当前使用的Java路径，设置了Java选择器时解析选择器，失败时返回 "java"
+func instanceJavaPath(inst *instance.Instance) string {
+	if inst.JavaSelector == nil {
+		return inst.JavaPath
+	}
+	resolved, err := newJavaManager().Resolve(inst.JavaSelector)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return "java"
+	}
+	return resolved.Path
+}
+
+// installInstanceCore 下载/安装核心到实例工作目录并保存启动方式和来源
+func installInstanceCore(manager *instance.Manager, dm *download.DownloadManager, provider download.CoreProvider, inst *instance.Instance, build, instancesDir string) error {
+	fmt.Printf("正在安装 %s %s %s...\n", provider.Name(), inst.MCVersion, build)
+
+	result, err := provider.Install(download.InstallRequest{
+		MCVersion:    inst.MCVersion,
+		Build:        build,
+		WorkDir:      inst.GetWorkDir(instancesDir),
+		JavaPath:     instanceJavaPath(inst),
+		ShowProgress: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	inst.ApplyCoreInstall(result)
+	inst.MCVersion = result.Core.MCVersion
+	if err := manager.UpdateInstance(inst); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ 已安装 %s %s (%s)\n", provider.Name(), result.Core.CoreVersion, result.Core.Source)
+	if result.ArgsFile != "" {
+		fmt.Printf("  启动参数文件: @%s\n", result.ArgsFile)
+	} else {
+		fmt.Printf("  服务端文件: %s\n", result.ServerJar)
+	}
+	return nil
+}
+
+// printCoreProvenance 打印服务端核心的版本和来源
+func printCoreProvenance(inst *instance.Instance) {
+	if inst.CoreVersion == "" {
+		return
+	}
+	fmt.Printf("核心版本: %s %s %s\n", inst.ServerType, inst.MCVersion, inst.CoreVersion)
+	if inst.CoreBuildTime != "" {
+		fmt.Printf("构建时间: %s\n", inst.CoreBuildTime)
+	}
+	if inst.CoreSource != "" {
+		fmt.Printf("下载来源: %s %s\n", inst.CoreSource, inst.CoreSourceURL)
+	}
+	if inst.CoreSHA1 != "" {
+		fmt.Printf("SHA1: %s\n", inst.CoreSHA1)
+	}
+}
+
+// coreUpdateMark 实例列表中核心可更新的标记
+func coreUpdateMark(inst *instance.Instance) string {
+	if !inst.CoreOutdated() {
+		return ""
+	}
+	return fmt.Sprintf(" [核心可更新: %s -> %s]", inst.CoreVersion, inst.LatestCoreVersion)
+}
+
 // createMainMenu 创建主菜单
 func createMainMenu() *menu.Menu {
 	mainMenu := menu.NewMenu("EasilyPanel5 v1.0.0", "跨平台通用游戏服务器管理工具")
@@ -161,6 +496,9 @@ func createMainMenu() *menu.Menu {
 				if err != nil {
 					return "错误"
 				}
+				if outdated := instance.CountOutdated(instances); outdated > 0 {
+					return fmt.Sprintf("%d个实例, %d个可更新", len(instances), outdated)
+				}
 				return fmt.Sprintf("%d个实例", len(instances))
 			}),
 	)
@@ -179,8 +517,7 @@ func createMainMenu() *menu.Menu {
 			WithSubMenu(javaMenu).
 			WithStatus(func() string {
 				// 显示Java状态
-				detector := java.NewDetector()
-				versions, _ := detector.DetectJava(false)
+				versions, _ := newJavaManager().Detect(context.Background(), false, nil)
 				if len(versions) == 0 {
 					return "未检测到"
 				}
@@ -254,6 +591,11 @@ func createDownloadMenu() *menu.Menu {
 				return handleFastMirrorDownload()
 			}),
 
+		menu.NewMenuItem("official", "官方核心", "从Mojang/Fabric/Forge/NeoForge官方源安装服务端并创建实例").
+			WithHandler(func() error {
+				return handleOfficialCoreInstall()
+			}),
+
 		menu.NewMenuItem("files", "已下载文件", "查看和管理已下载的文件").
 			WithHandler(func() error {
 				return handleDownloadedFiles()
@@ -290,10 +632,8 @@ func createJavaMenu() *menu.Menu {
 
 		menu.NewMenuItem("install", "安装Java", "下载并安装Java运行环境").
 			WithHandler(func() error {
-				fmt.Println("Java安装功能正在开发中...")
-				return nil
-			}).
-			WithEnabled(func() bool { return false }),
+				return handleJavaInstall()
+			}),
 	)
 	
 	return javaMenu
@@ -416,8 +756,7 @@ func handleCreateInstance() error {
 
 	if serverType == "minecraft" {
 		// 检测Java路径
-		detector := java.NewDetector()
-		javaVersions, _ := detector.DetectJava(false)
+		javaVersions, _ := newJavaManager().Detect(context.Background(), false, nil)
 		javaPath := "java"
 		if len(javaVersions) > 0 {
 			javaPath = javaVersions[0].Path
@@ -455,7 +794,7 @@ func handleInstanceList() error {
 	}
 
 	for _, inst := range instances {
-		fmt.Printf("- %s (%s)\n", inst.Name, inst.Type)
+		fmt.Printf("- %s (%s)%s\n", inst.Name, inst.Type, coreUpdateMark(inst))
 	}
 	return nil
 }
@@ -530,10 +869,12 @@ func handleManageInstance() error {
 
 	// 创建进程管理器
 	processManager := instance.NewProcessManager("./data/instances")
+	processManager.SetJavaManager(newJavaManager())
 
 	switch actionIndex {
 	case 0:
 		fmt.Printf("正在启动实例 '%s'...\n", selectedInstance.Name)
+		checkInstanceContent(selectedInstance, "./data/instances")
 		if err := processManager.StartInstance(selectedInstance.Name); err != nil {
 			return fmt.Errorf("启动实例失败: %w", err)
 		}
@@ -578,8 +919,9 @@ func handleManageInstance() error {
 		if selectedInstance.ServerJar != "" {
 			fmt.Printf("服务端文件: %s\n", selectedInstance.ServerJar)
 		}
-		if selectedInstance.JavaPath != "" {
-			fmt.Printf("Java路径: %s\n", selectedInstance.JavaPath)
+		printCoreProvenance(selectedInstance)
+		if binding := selectedInstance.JavaBinding(); binding != "" {
+			fmt.Printf("Java: %s\n", binding)
 		}
 		if selectedInstance.MaxMemory != "" {
 			fmt.Printf("最大内存: %s\n", selectedInstance.MaxMemory)
@@ -604,13 +946,14 @@ func handleManageInstance() error {
 
 func handleFastMirrorDownload() error {
 	fmt.Println("=== FastMirror下载 ===")
-	dm := download.NewDownloadManager("./data")
+	dm := newDownloadManager("./data")
 
 	// 获取服务端列表
 	servers, err := dm.ListAvailableServers()
 	if err != nil {
 		return fmt.Errorf("获取服务端列表失败: %w", err)
 	}
+	printCatalogStatus(dm)
 
 	if len(servers) == 0 {
 		fmt.Println("未找到可用的服务端")
@@ -651,6 +994,7 @@ func handleFastMirrorDownload() error {
 	if err != nil {
 		return fmt.Errorf("获取版本列表失败: %w", err)
 	}
+	printCatalogStatus(dm)
 
 	if len(versions) == 0 {
 		fmt.Println("未找到可用版本")
@@ -710,20 +1054,22 @@ func handleFastMirrorDownload() error {
 	// 开始下载
 	fmt.Printf("\n开始下载 %s %s...\n", selectedServer.Name, selectedVersion)
 
-	var filePath string
+	var core *download.CoreDownload
+	var downloadErr error
 	if downloadLatest {
 		// 获取最新构建
 		latestBuild, err := dm.GetLatestBuild(selectedServer.Name, selectedVersion)
 		if err != nil {
 			return fmt.Errorf("获取最新构建失败: %w", err)
 		}
-		filePath, err = dm.DownloadServer(selectedServer.Name, selectedVersion, latestBuild.CoreVersion, true)
+		core, downloadErr = dm.DownloadCore(selectedServer.Name, selectedVersion, latestBuild.CoreVersion, true)
 	} else {
 		// 获取构建列表
 		builds, err := dm.ListBuilds(selectedServer.Name, selectedVersion, 10)
 		if err != nil {
 			return fmt.Errorf("获取构建列表失败: %w", err)
 		}
+		printCatalogStatus(dm)
 
 		if len(builds) == 0 {
 			return fmt.Errorf("未找到可用构建")
@@ -754,15 +1100,15 @@ func handleFastMirrorDownload() error {
 		}
 
 		selectedBuild := builds[buildIndex]
-		filePath, err = dm.DownloadServer(selectedServer.Name, selectedVersion, selectedBuild.CoreVersion, true)
+		core, downloadErr = dm.DownloadCore(selectedServer.Name, selectedVersion, selectedBuild.CoreVersion, true)
 	}
 
-	if err != nil {
-		return fmt.Errorf("下载失败: %w", err)
+	if downloadErr != nil {
+		return fmt.Errorf("下载失败: %w", downloadErr)
 	}
 
 	fmt.Printf("\n✓ 下载完成!\n")
-	fmt.Printf("文件路径: %s\n", filePath)
+	fmt.Printf("文件路径: %s\n", core.Path)
 
 	// 询问是否创建实例
 	fmt.Print("\n是否使用此文件创建Minecraft实例? (y/N): ")
@@ -772,15 +1118,44 @@ func handleFastMirrorDownload() error {
 
 	createInstance := strings.ToLower(strings.TrimSpace(scanner.Text()))
 	if createInstance == "y" || createInstance == "yes" {
-		return handleCreateInstanceFromDownload(filePath, selectedServer.Name, selectedVersion)
+		return handleCreateInstanceFromDownload(core)
 	}
 
 	return nil
 }
 
+// printCatalogStatus 浏览时使用了缓存的目录则提示数据的新旧，离线时给出警告
+func printCatalogStatus(dm *download.DownloadManager) {
+	status := dm.CatalogStatus()
+	if status.FetchedAt.IsZero() {
+		return
+	}
+
+	fetchedAt := status.FetchedAt.Format("2006-01-02 15:04")
+	if status.Offline {
+		fmt.Printf("⚠️  无法连接下载源，显示的是 %s 前缓存的目录 (%s)，可能不是最新\n", formatAge(status.Age()), fetchedAt)
+	} else {
+		fmt.Printf("(目录缓存于 %s 前)\n", formatAge(status.Age()))
+	}
+}
+
+// formatAge 格式化时间间隔，如 "3天"、"5小时"、"10分钟"
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%d天", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%d小时", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%d分钟", int(d.Minutes()))
+	default:
+		return "不到1分钟"
+	}
+}
+
 func handleDownloadedFiles() error {
 	fmt.Println("=== 已下载文件 ===")
-	dm := download.NewDownloadManager("./data")
+	dm := newDownloadManager("./data")
 
 	// 获取下载目录
 	downloadDir := dm.GetDownloadDir()
@@ -840,7 +1215,7 @@ func handleDownloadedFiles() error {
 func handleCleanupDownloads() error {
 	fmt.Println("=== 清理下载 ===")
 
-	dm := download.NewDownloadManager("./data")
+	dm := newDownloadManager("./data")
 	downloadDir := dm.GetDownloadDir()
 
 	// 检查目录是否存在
@@ -985,31 +1360,103 @@ func cleanupFiles(dir string, days int) error {
 	}
 
 	fmt.Printf("\n✓ 清理完成: 删除了 %d 个文件，释放空间 %s\n", deletedCount, deletedSizeStr)
+	audit.Record(audit.DefaultActor(), "download.Cleanup", dir, map[string]interface{}{
+		"days":    days,
+		"deleted": deletedCount,
+	}, nil)
 	return nil
 }
 
 func handleJavaDetect() error {
 	fmt.Println("=== 检测Java ===")
-	detector := java.NewDetector()
-	versions, err := detector.DetectJava(true)
+	fmt.Println("按 Ctrl+C 停止扫描")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	versions, err := newJavaManager().DetectAndSaveContext(ctx, true, func(found *java.Java) {
+		fmt.Printf("- Java %s\n", found.Description())
+		fmt.Printf("    路径: %s\n", found.Path)
+	})
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("⚠️  扫描已取消，找到 %d 个Java，Java列表未更新\n", len(versions))
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
 	if len(versions) == 0 {
 		fmt.Println("未检测到Java环境")
-		return nil
 	}
+	return nil
+}
+
+// newJavaManager 创建Java管理器，应用配置的搜索目录和排除目录
+func newJavaManager() *java.Manager {
+	manager := java.NewManager("./data/configs")
+	// 自动安装的运行时位于 data/runtimes，重新检测时也要找到
+	searchPaths := append(config.GetStringSlice("java.search_paths"), filepath.Join("./data", "runtimes"))
+	manager.SetSearchPaths(searchPaths, config.GetStringSlice("java.exclude_paths"))
+	return manager
+}
+
+// handleJavaSelect 显示Minecraft版本的Java要求和选择结果
+func handleJavaSelect(mcVersion string) {
+	fmt.Println("Minecraft与Java兼容表:")
+	for _, rule := range java.CompatibilityTable {
+		maxJava := "不限"
+		if rule.MaxJava > 0 {
+			maxJava = strconv.Itoa(rule.MaxJava)
+		}
+		marker := " "
+		if rule.Matches(java.ParseMCVersion(mcVersion)) {
+			marker = "→"
+		}
+		fmt.Printf("%s %s: Java %d ~ %s\n", marker, rule.Range(), rule.MinJava, maxJava)
+	}
+	fmt.Println()
 
-	fmt.Printf("检测到 %d 个Java版本:\n", len(versions))
-	for _, version := range versions {
-		fmt.Printf("- Java %d (%s)\n", version.Version, version.Path)
+	sel := selectJavaForMinecraft(mcVersion)
+	if sel.Java == nil {
+		fmt.Printf("❌ %s\n", sel.Reason)
+		fmt.Printf("可使用 'java install %d' 安装\n", sel.Rule.MinJava)
+		return
 	}
-	return nil
+	fmt.Printf("✓ %s\n", sel.Reason)
+	fmt.Printf("路径: %s\n", sel.Java.Path)
+}
+
+// handleJavaInstall 交互式安装Java
+func handleJavaInstall() error {
+	fmt.Println("=== 安装Java ===")
+	fmt.Println("常用版本: 8 (Minecraft 1.16及以下), 17 (1.18-1.20.4), 21 (1.20.5及以上)")
+	fmt.Print("请输入Java主版本: ")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return fmt.Errorf("读取输入失败")
+	}
+	major, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || major <= 0 {
+		return fmt.Errorf("无效的版本: %s", scanner.Text())
+	}
+
+	_, err = installJavaRuntime(major, "jdk")
+	return err
 }
 
 func handleJavaList() error {
-	return handleJavaDetect()
+	manager := newJavaManager()
+	if _, err := manager.LoadJavaList(); err != nil {
+		return err
+	}
+	if len(manager.GetJavaList()) == 0 {
+		fmt.Println("Java列表为空，请先检测Java")
+		return nil
+	}
+	manager.PrintJavaList()
+	return nil
 }
 
 func handleJavaAdd() error {
@@ -1055,8 +1502,11 @@ func handleJavaAdd() error {
 	fmt.Printf("找到Java可执行文件: %s\n", javaPath)
 	fmt.Println("正在验证Java版本...")
 
-	// 创建Java管理器
-	manager := java.NewManager("./data/configs")
+	// 创建Java管理器并加载已保存的列表，以便检查重复并保留已有的Java
+	manager := newJavaManager()
+	if _, err := manager.LoadJavaList(); err != nil {
+		return err
+	}
 
 	// 尝试添加Java
 	addedJava, err := manager.AddJava(javaPath)
@@ -1119,8 +1569,7 @@ func handleFRPSetup() error {
 		}
 
 		// 保存到配置
-		config.Set("frp.openfrp.authorization", token)
-		if err := config.SaveConfig(); err != nil {
+		if err := config.SetAndSave("frp.openfrp.authorization", token); err != nil {
 			return fmt.Errorf("保存配置失败: %w", err)
 		}
 
@@ -1337,8 +1786,7 @@ func handleStartFRPCWithCommand(manager *frp.Manager, scanner *bufio.Scanner) er
 		}
 
 		// 保存到配置
-		config.Set("frp.openfrp.user_token", userToken)
-		if err := config.SaveConfig(); err != nil {
+		if err := config.SetAndSave("frp.openfrp.user_token", userToken); err != nil {
 			fmt.Printf("警告: 保存配置失败: %v\n", err)
 		}
 	}
@@ -1619,8 +2067,7 @@ func handleEditFRPConfig(scanner *bufio.Scanner) error {
 		}
 		newToken := strings.TrimSpace(scanner.Text())
 		if newToken != "" {
-			config.Set("frp.openfrp.authorization", newToken)
-			if err := config.SaveConfig(); err != nil {
+			if err := config.SetAndSave("frp.openfrp.authorization", newToken); err != nil {
 				return err
 			}
 			fmt.Println("✓ 认证令牌已更新")
@@ -1634,8 +2081,7 @@ func handleEditFRPConfig(scanner *bufio.Scanner) error {
 		}
 		newUserToken := strings.TrimSpace(scanner.Text())
 		if newUserToken != "" {
-			config.Set("frp.openfrp.user_token", newUserToken)
-			if err := config.SaveConfig(); err != nil {
+			if err := config.SetAndSave("frp.openfrp.user_token", newUserToken); err != nil {
 				return err
 			}
 			fmt.Println("✓ 用户访问密钥已更新")
@@ -1649,8 +2095,7 @@ func handleEditFRPConfig(scanner *bufio.Scanner) error {
 		}
 		newURL := strings.TrimSpace(scanner.Text())
 		if newURL != "" {
-			config.Set("frp.openfrp.api_url", newURL)
-			if err := config.SaveConfig(); err != nil {
+			if err := config.SetAndSave("frp.openfrp.api_url", newURL); err != nil {
 				return err
 			}
 			fmt.Println("✓ API地址已更新")
@@ -1664,8 +2109,7 @@ func handleEditFRPConfig(scanner *bufio.Scanner) error {
 		}
 		newNodeID := strings.TrimSpace(scanner.Text())
 		if newNodeID != "" {
-			config.Set("frp.openfrp.default_node_id", newNodeID)
-			if err := config.SaveConfig(); err != nil {
+			if err := config.SetAndSave("frp.openfrp.default_node_id", newNodeID); err != nil {
 				return err
 			}
 			fmt.Println("✓ 默认节点已更新")
@@ -1701,8 +2145,7 @@ func handleEditSystemConfig(scanner *bufio.Scanner) error {
 		}
 		newDir := strings.TrimSpace(scanner.Text())
 		if newDir != "" {
-			config.Set("app.data_dir", newDir)
-			if err := config.SaveConfig(); err != nil {
+			if err := config.SetAndSave("app.data_dir", newDir); err != nil {
 				return err
 			}
 			fmt.Println("✓ 数据目录已更新")
@@ -1717,8 +2160,7 @@ func handleEditSystemConfig(scanner *bufio.Scanner) error {
 		}
 		newLevel := strings.TrimSpace(scanner.Text())
 		if newLevel != "" {
-			config.Set("app.log_level", newLevel)
-			if err := config.SaveConfig(); err != nil {
+			if err := config.SetAndSave("app.log_level", newLevel); err != nil {
 				return err
 			}
 			fmt.Println("✓ 日志级别已更新")
@@ -1732,8 +2174,7 @@ func handleEditSystemConfig(scanner *bufio.Scanner) error {
 		}
 		newValue := strings.ToLower(strings.TrimSpace(scanner.Text()))
 		autoUpdate := (newValue == "y" || newValue == "yes")
-		config.Set("app.auto_update", autoUpdate)
-		if err := config.SaveConfig(); err != nil {
+		if err := config.SetAndSave("app.auto_update", autoUpdate); err != nil {
 			return err
 		}
 		fmt.Printf("✓ 自动更新已设置为: %t\n", autoUpdate)
@@ -1896,9 +2337,11 @@ func handleAbout() error {
 	return nil
 }
 
-func handleCreateInstanceFromDownload(filePath, serverType, version string) error {
+func handleCreateInstanceFromDownload(core *download.CoreDownload) error {
 	fmt.Println("\n=== 从下载创建实例 ===")
 
+	filePath, serverType, version := core.Path, core.ServerName, core.MCVersion
+
 	scanner := bufio.NewScanner(os.Stdin)
 
 	// 输入实例名称
@@ -1929,13 +2372,8 @@ func handleCreateInstanceFromDownload(filePath, serverType, version string) erro
 
 	fmt.Printf("正在创建实例 '%s'...\n", instanceName)
 
-	// 检测Java路径
-	detector := java.NewDetector()
-	javaVersions, _ := detector.DetectJava(false)
-	javaPath := "java"
-	if len(javaVersions) > 0 {
-		javaPath = javaVersions[0].Path
-	}
+	// 选择满足版本要求的Java，没有时询问是否自动安装
+	javaPath := ensureJavaForMinecraft(version, scanner)
 
 	inst, err := manager.CreateMinecraftInstance(instanceName, version, serverType, javaPath)
 	if err != nil {
@@ -1950,15 +2388,15 @@ func handleCreateInstanceFromDownload(filePath, serverType, version string) erro
 
 	// 获取原文件名
 	originalFileName := filepath.Base(filePath)
-	targetFilePath := filepath.Join(instanceDir, originalFileName)
 
 	fmt.Printf("正在复制服务端文件到实例目录...\n")
-	if err := copyFile(filePath, targetFilePath); err != nil {
+	if err := newDownloadManager("./data").LinkToWorkDir(filePath, core.SHA1, instanceDir, originalFileName); err != nil {
 		return fmt.Errorf("复制服务端文件失败: %w", err)
 	}
 
 	// 设置服务端文件路径为实例目录中的文件
 	inst.ServerJar = originalFileName // 只保存文件名，因为工作目录已经设置
+	inst.SetCore(core)
 	if err := manager.UpdateInstance(inst); err != nil {
 		return fmt.Errorf("保存实例配置失败: %w", err)
 	}
@@ -1994,6 +2432,12 @@ func handleCommandLine(args []string, configFile, dataDir, logLevel string) {
 		config.Set("app.log_level", logLevel)
 	}
 
+	// 初始化审计日志、网络设置、内存上限和新实例的额外JVM参数
+	audit.Init(config.GetString("log.audit_file"), audit.SourceCLI)
+	initNetwork()
+	java.SetMaxMemoryPercent(config.GetInt("instance.max_memory_percent"))
+	instance.SetDefaultJavaArgs(config.GetStringSlice("instance.default_java_args"))
+
 	command := args[0]
 	subArgs := args[1:]
 
@@ -2008,6 +2452,8 @@ func handleCommandLine(args []string, configFile, dataDir, logLevel string) {
 		handleDownloadCommand(subArgs, dataDir)
 	case "config":
 		handleConfigCommand(subArgs)
+	case "audit":
+		handleAuditCommand(subArgs)
 	default:
 		fmt.Printf("未知命令: %s\n", command)
 		fmt.Println("使用 'easilypanel -help' 查看可用命令")
@@ -2021,11 +2467,23 @@ func handleInstanceCommand(args []string, dataDir string) {
 		fmt.Println("  start NAME    启动指定实例")
 		fmt.Println("  stop NAME     停止指定实例")
 		fmt.Println("  status NAME   查看实例状态")
+		fmt.Println("  events NAME   查看实例的异常退出记录和诊断")
+		fmt.Println("  plugins ACTION NAME [...]  管理实例插件 (list/add/remove/enable/disable)")
+		fmt.Println("  mods ACTION NAME [...]     管理实例模组 (list/add/remove/enable/disable)")
+		fmt.Println("  content ACTION NAME [...]  从Modrinth/Hangar搜索、安装和更新 (search/install/installed/outdated/update)")
+		fmt.Println("  import-pack [-name NAME] FILE  从整合包 (.mrpack 或CurseForge服务端包) 创建实例")
+		fmt.Println("  upgrade [-build N | -latest] [-start] NAME  原地升级服务端核心，启动失败时恢复旧核心")
+		fmt.Println("  verify NAME   重新计算服务端核心的SHA1，检查文件是否损坏或被篡改")
+		fmt.Println("  java NAME [PATH | MAJOR[:VENDOR]]  查看或设置实例的Java，主版本选择器在启动时解析 (如 21、21:temurin)")
+		fmt.Println("  jvm NAME [PRESET]  查看或设置JVM参数预设 (aikar/zgc/minimal) 和生成的参数")
+		fmt.Println("  create -core TYPE -mc VERSION [-build B] NAME  创建实例并安装核心 (vanilla/fabric/forge/neoforge 或FastMirror服务端名称)")
+		fmt.Println("  install-core [-core TYPE] [-build B] NAME      为已有实例安装或重装服务端核心")
 		return
 	}
 
 	manager := instance.NewManager(filepath.Join(dataDir, "instances"))
 	processManager := instance.NewProcessManager(filepath.Join(dataDir, "instances"))
+	processManager.SetJavaManager(newJavaManager())
 
 	switch args[0] {
 	case "list":
@@ -2042,7 +2500,7 @@ func handleInstanceCommand(args []string, dataDir string) {
 
 		fmt.Printf("实例列表 (%d个):\n", len(instances))
 		for _, inst := range instances {
-			fmt.Printf("  %s (%s) - %s\n", inst.Name, inst.Type, inst.Status)
+			fmt.Printf("  %s (%s) - %s%s\n", inst.Name, inst.Type, inst.Status, coreUpdateMark(inst))
 		}
 
 	case "start":
@@ -2051,6 +2509,9 @@ func handleInstanceCommand(args []string, dataDir string) {
 			return
 		}
 		instanceName := args[1]
+		if inst, err := manager.GetInstance(instanceName); err == nil {
+			checkInstanceContent(inst, filepath.Join(dataDir, "instances"))
+		}
 		if err := processManager.StartInstance(instanceName); err != nil {
 			fmt.Printf("启动实例失败: %v\n", err)
 		} else {
@@ -2087,101 +2548,946 @@ func handleInstanceCommand(args []string, dataDir string) {
 				fmt.Printf("类型: %s\n", inst.Type)
 				fmt.Printf("端口: %d\n", inst.Port)
 				fmt.Printf("状态: %s\n", inst.Status)
+				if inst.Type == instance.TypeMinecraft {
+					fmt.Printf("Java: %s\n", inst.JavaBinding())
+				}
+				if event := inst.LastEvent(); event != nil && inst.Status == instance.StatusError {
+					fmt.Println()
+					printInstanceEvent(event)
+				}
 				return
 			}
 		}
 		fmt.Printf("未找到实例: %s\n", instanceName)
 
-	default:
-		fmt.Printf("未知子命令: %s\n", args[0])
-	}
-}
-
-func handleFRPCommand(args []string, dataDir string) {
-	if len(args) == 0 {
-		fmt.Println("FRP管理命令:")
-		fmt.Println("  status        查看frpc状态")
-		fmt.Println("  start         启动frpc")
-		fmt.Println("  stop          停止frpc")
-		fmt.Println("  restart       重启frpc")
-		return
-	}
+	case "plugins":
+		handleInstancePluginsCommand(args[1:], manager, filepath.Join(dataDir, "instances"))
 
-	manager := frp.NewManager(dataDir)
+	case "mods":
+		handleInstanceModsCommand(args[1:], manager, filepath.Join(dataDir, "instances"))
 
-	switch args[0] {
-	case "status":
-		status := manager.GetFRPCStatus()
-		fmt.Printf("frpc状态: %s\n", status)
+	case "content":
+		handleInstanceContentCommand(args[1:], manager, processManager, filepath.Join(dataDir, "instances"))
 
-	case "start":
-		if err := manager.StartFRPC(); err != nil {
-			fmt.Printf("启动frpc失败: %v\n", err)
-		} else {
-			fmt.Println("frpc启动成功")
-		}
+	case "import-pack":
+		handleImportPackCommand(args[1:], manager, dataDir)
 
-	case "stop":
-		if err := manager.StopFRPC(); err != nil {
-			fmt.Printf("停止frpc失败: %v\n", err)
-		} else {
-			fmt.Println("frpc停止成功")
-		}
+	case "upgrade":
+		handleInstanceUpgradeCommand(args[1:], manager, processManager, dataDir)
 
-	case "restart":
-		if err := manager.RestartFRPC(); err != nil {
-			fmt.Printf("重启frpc失败: %v\n", err)
-		} else {
-			fmt.Println("frpc重启成功")
-		}
+	case "create":
+		handleInstanceCreateCommand(args[1:], manager, dataDir)
 
-	default:
-		fmt.Printf("未知子命令: %s\n", args[0])
-	}
-}
+	case "install-core":
+		handleInstallCoreCommand(args[1:], manager, dataDir)
 
-func handleJavaCommand(args []string) {
-	if len(args) == 0 {
-		fmt.Println("Java管理命令:")
-		fmt.Println("  detect        检测Java版本")
-		fmt.Println("  list          列出Java版本")
-		return
-	}
+	case "java":
+		handleInstanceJavaCommand(args[1:], manager)
 
-	detector := java.NewDetector()
+	case "jvm":
+		handleInstanceJVMCommand(args[1:], manager)
 
-	switch args[0] {
-	case "detect", "list":
-		versions, err := detector.DetectJava(true)
+	case "events":
+		if len(args) < 2 {
+			fmt.Println("错误: 缺少实例名称")
+			return
+		}
+		inst, err := manager.GetInstance(args[1])
 		if err != nil {
-			fmt.Printf("检测Java失败: %v\n", err)
+			fmt.Printf("未找到实例: %s\n", args[1])
 			return
 		}
-
-		if len(versions) == 0 {
-			fmt.Println("未检测到Java环境")
+		if len(inst.Events) == 0 {
+			fmt.Println("没有异常退出记录")
 			return
 		}
-
-		fmt.Printf("检测到 %d 个Java版本:\n", len(versions))
-		for _, version := range versions {
-			fmt.Printf("  Java %d (%s)\n", version.Version, version.Path)
+		for i := len(inst.Events) - 1; i >= 0; i-- {
+			printInstanceEvent(&inst.Events[i])
+			fmt.Println()
 		}
 
-	default:
-		fmt.Printf("未知子命令: %s\n", args[0])
-	}
-}
-
-func handleDownloadCommand(args []string, dataDir string) {
-	if len(args) == 0 {
-		fmt.Println("下载管理命令:")
-		fmt.Println("  list          列出可用服务端")
+	case "verify":
+		if len(args) < 2 {
+			fmt.Println("错误: 缺少实例名称")
+			return
+		}
+		inst, err := manager.GetInstance(args[1])
+		if err != nil {
+			fmt.Printf("未找到实例: %s\n", args[1])
+			return
+		}
+		printCoreProvenance(inst)
+		result, err := manager.VerifyCore(args[1])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		if !result.OK {
+			fmt.Printf("❌ 核心文件校验失败: %s\n", result.Path)
+			fmt.Printf("  期望SHA1: %s\n", result.Expected)
+			fmt.Printf("  实际SHA1: %s\n", result.Actual)
+			return
+		}
+		fmt.Printf("✓ 核心文件校验通过: %s (%s)\n", result.Path, result.Actual)
+
+	default:
+		fmt.Printf("未知子命令: %s\n", args[0])
+	}
+}
+
+// handleInstanceJavaCommand 查看或设置实例的Java
+func handleInstanceJavaCommand(args []string, manager *instance.Manager) {
+	if len(args) < 1 {
+		fmt.Println("用法: instance java NAME [PATH | MAJOR[:VENDOR]]")
+		return
+	}
+	inst, err := manager.GetInstance(args[0])
+	if err != nil {
+		fmt.Printf("未找到实例: %s\n", args[0])
+		return
+	}
+
+	if len(args) > 1 {
+		inst.SetJava(args[1])
+		if err := manager.UpdateInstance(inst); err != nil {
+			fmt.Printf("❌ 保存实例失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 实例 '%s' 的Java已设置为: %s\n", inst.Name, inst.JavaBinding())
+	} else {
+		fmt.Printf("Java: %s\n", inst.JavaBinding())
+	}
+
+	if inst.JavaSelector == nil {
+		return
+	}
+	resolved, err := newJavaManager().Resolve(inst.JavaSelector)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("当前解析为: Java %s\n", resolved.Description())
+	fmt.Printf("  路径: %s\n", resolved.Path)
+}
+
+// printInstanceEvent 显示实例事件及其诊断
+func printInstanceEvent(event *instance.Event) {
+	fmt.Printf("❌ %s %s\n", event.Time.Format("2006-01-02 15:04:05"), event.Message)
+	if len(event.Diagnoses) == 0 {
+		fmt.Println("  未能识别原因，请查看实例工作目录中的 server.log")
+		return
+	}
+	for _, d := range event.Diagnoses {
+		fmt.Printf("  诊断: %s\n", d.Summary)
+		if d.Detail != "" {
+			fmt.Printf("    %s\n", strings.ReplaceAll(d.Detail, "\n", "\n    "))
+		}
+		if d.File != "" {
+			fmt.Printf("    崩溃日志: %s\n", d.File)
+		}
+		fmt.Printf("    建议: %s\n", d.Remedy)
+	}
+}
+
+// handleInstanceJVMCommand 查看或设置实例的JVM参数预设
+func handleInstanceJVMCommand(args []string, manager *instance.Manager) {
+	if len(args) < 1 {
+		fmt.Println("用法: instance jvm NAME [PRESET]")
+		printJVMPresets("")
+		return
+	}
+	inst, err := manager.GetInstance(args[0])
+	if err != nil {
+		fmt.Printf("未找到实例: %s\n", args[0])
+		return
+	}
+
+	if len(args) > 1 {
+		if err := setInstanceJVMPreset(inst, args[1]); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+	} else {
+		printJVMPresets(inst.JVMPreset)
+		if inst.JVMPreset == "" {
+			fmt.Println("该实例使用自定义的完整Java参数，可用 'instance jvm NAME PRESET' 改为使用预设")
+		}
+	}
+
+	// 按实例当前的Java检查参数
+	if inst.Type == instance.TypeMinecraft {
+		javaManager := newJavaManager()
+		if inst.JavaSelector != nil {
+			if resolved, err := javaManager.Resolve(inst.JavaSelector); err == nil {
+				inst.SetResolvedJava(resolved)
+			}
+		} else {
+			inst.SetResolvedJava(javaManager.Inspect(inst.JavaPath))
+		}
+	}
+	javaArgs, warnings, err := inst.BuildJavaArgs()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if len(args) > 1 {
+		if err := manager.UpdateInstance(inst); err != nil {
+			fmt.Printf("❌ 保存实例失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 实例 '%s' 的JVM参数预设已设置为: %s\n", inst.Name, inst.JVMPreset)
+	}
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	fmt.Printf("JVM参数: %s\n", strings.Join(javaArgs, " "))
+}
+
+// printJVMPresets 列出JVM参数预设，标记当前使用的预设
+func printJVMPresets(current string) {
+	fmt.Println("JVM参数预设:")
+	for _, preset := range java.FlagPresets {
+		marker := " "
+		if preset.Name == current {
+			marker = "→"
+		}
+		fmt.Printf("%s %-8s %-9s %s\n", marker, preset.Name, fmt.Sprintf("Java %d+", preset.MinJava), preset.Description)
+	}
+}
+
+// setInstanceJVMPreset 设置实例的JVM参数预设
+//
+// 旧实例从完整参数改为预设时，-Xmx/-Xms转为内存设置，JavaArgs中不属于Aikar参数的项保留为额外参数。
+func setInstanceJVMPreset(inst *instance.Instance, name string) error {
+	preset, err := java.FindFlagPreset(name)
+	if err != nil {
+		return err
+	}
+	if inst.JVMPreset == "" {
+		aikar, _ := java.FindFlagPreset(java.PresetAikar)
+		known := make(map[string]bool)
+		for _, flag := range aikar.Flags {
+			known[flag] = true
+		}
+		var extra []string
+		for _, arg := range inst.JavaArgs {
+			switch {
+			case strings.HasPrefix(arg, "-Xmx"):
+				if inst.MaxMemory == "" {
+					inst.MaxMemory = strings.TrimPrefix(arg, "-Xmx")
+				}
+			case strings.HasPrefix(arg, "-Xms"):
+				if inst.MinMemory == "" {
+					inst.MinMemory = strings.TrimPrefix(arg, "-Xms")
+				}
+			case !known[arg]:
+				extra = append(extra, arg)
+			}
+		}
+		inst.JavaArgs = extra
+	}
+	inst.JVMPreset = preset.Name
+	inst.UpdatedAt = time.Now()
+	return nil
+}
+
+// handleInstancePluginsCommand 处理实例插件管理命令
+func handleInstancePluginsCommand(args []string, manager *instance.Manager, instancesDir string) {
+	if len(args) < 2 {
+		fmt.Println("插件管理命令:")
+		fmt.Println("  plugins list NAME             列出插件并检查依赖")
+		fmt.Println("  plugins add NAME JAR          安装插件")
+		fmt.Println("  plugins remove NAME PLUGIN    删除插件")
+		fmt.Println("  plugins enable NAME PLUGIN    启用插件")
+		fmt.Println("  plugins disable NAME PLUGIN   禁用插件（移动到 plugins/.disabled）")
+		return
+	}
+
+	action, instanceName := args[0], args[1]
+	inst, err := manager.GetInstance(instanceName)
+	if err != nil {
+		fmt.Printf("未找到实例: %s\n", instanceName)
+		return
+	}
+
+	if !content.SupportsPlugins(inst) {
+		fmt.Printf("⚠️  实例服务端类型 '%s' 可能不支持Bukkit插件\n", inst.ServerType)
+	}
+
+	pluginManager := content.NewPluginManager(inst, instancesDir)
+
+	if action == "list" {
+		plugins, err := pluginManager.List()
+		if err != nil {
+			fmt.Printf("❌ 获取插件列表失败: %v\n", err)
+			return
+		}
+		content.PrintPluginList(plugins, pluginManager.Check(plugins))
+		return
+	}
+
+	if len(args) < 3 {
+		fmt.Println("错误: 缺少插件参数")
+		return
+	}
+	target := args[2]
+
+	switch action {
+	case "add":
+		info, err := pluginManager.Add(target)
+		if err != nil {
+			fmt.Printf("❌ 安装插件失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 插件 %s %s 安装成功\n", info.Name, info.Version)
+
+		// 安装后检查依赖
+		if plugins, err := pluginManager.List(); err == nil {
+			for _, problem := range pluginManager.Check(plugins) {
+				if problem.Subject == info.Name {
+					fmt.Printf("⚠️  %s\n", problem.Message)
+				}
+			}
+		}
+
+	case "remove":
+		if err := pluginManager.Remove(target); err != nil {
+			fmt.Printf("❌ 删除插件失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 插件 %s 已删除\n", target)
+
+	case "enable":
+		if err := pluginManager.Enable(target); err != nil {
+			fmt.Printf("❌ 启用插件失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 插件 %s 已启用\n", target)
+
+	case "disable":
+		if err := pluginManager.Disable(target); err != nil {
+			fmt.Printf("❌ 禁用插件失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 插件 %s 已禁用，可使用 enable 恢复\n", target)
+
+	default:
+		fmt.Printf("未知插件操作: %s\n", action)
+	}
+
+	if inst.IsRunning() {
+		fmt.Println("提示: 插件变更将在实例重启后生效")
+	}
+}
+
+// handleInstanceModsCommand 处理实例模组管理命令
+func handleInstanceModsCommand(args []string, manager *instance.Manager, instancesDir string) {
+	if len(args) < 2 {
+		fmt.Println("模组管理命令:")
+		fmt.Println("  mods list NAME             列出模组并检查兼容性和依赖")
+		fmt.Println("  mods add NAME JAR          安装模组")
+		fmt.Println("  mods remove NAME MOD       删除模组")
+		fmt.Println("  mods enable NAME MOD       启用模组")
+		fmt.Println("  mods disable NAME MOD      禁用模组（移动到 mods/.disabled）")
+		return
+	}
+
+	action, instanceName := args[0], args[1]
+	inst, err := manager.GetInstance(instanceName)
+	if err != nil {
+		fmt.Printf("未找到实例: %s\n", instanceName)
+		return
+	}
+
+	modManager := content.NewModManager(inst, instancesDir)
+	if modManager.Loader() == "" {
+		fmt.Printf("⚠️  实例服务端类型 '%s' 不是已知的模组服务端，将跳过加载器检查\n", inst.ServerType)
+	}
+
+	if action == "list" {
+		mods, err := modManager.List()
+		if err != nil {
+			fmt.Printf("❌ 获取模组列表失败: %v\n", err)
+			return
+		}
+		content.PrintModList(mods, modManager.Check(mods))
+		return
+	}
+
+	if len(args) < 3 {
+		fmt.Println("错误: 缺少模组参数")
+		return
+	}
+	target := args[2]
+
+	switch action {
+	case "add":
+		mods, err := modManager.Add(target)
+		if err != nil {
+			fmt.Printf("❌ 安装模组失败: %v\n", err)
+			return
+		}
+		for _, mod := range mods {
+			fmt.Printf("✓ 模组 %s %s 安装成功\n", mod.ID, mod.Version)
+		}
+
+		// 安装后检查兼容性和依赖
+		if all, err := modManager.List(); err == nil {
+			for _, problem := range modManager.Check(all) {
+				for _, mod := range mods {
+					if problem.Subject == mod.ID {
+						fmt.Printf("⚠️  %s\n", problem.Message)
+					}
+				}
+			}
+		}
+
+	case "remove":
+		if err := modManager.Remove(target); err != nil {
+			fmt.Printf("❌ 删除模组失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 模组 %s 已删除\n", target)
+
+	case "enable":
+		if err := modManager.Enable(target); err != nil {
+			fmt.Printf("❌ 启用模组失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 模组 %s 已启用\n", target)
+
+	case "disable":
+		if err := modManager.Disable(target); err != nil {
+			fmt.Printf("❌ 禁用模组失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 模组 %s 已禁用，可使用 enable 恢复\n", target)
+
+	default:
+		fmt.Printf("未知模组操作: %s\n", action)
+	}
+
+	if inst.IsRunning() {
+		fmt.Println("提示: 模组变更将在实例重启后生效")
+	}
+}
+
+// handleImportPackCommand 处理整合包导入命令
+func handleImportPackCommand(args []string, manager *instance.Manager, dataDir string) {
+	flags := flag.NewFlagSet("import-pack", flag.ContinueOnError)
+	name := flags.String("name", "", "实例名称，默认使用整合包名称")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	if flags.NArg() < 1 {
+		fmt.Println("用法: instance import-pack [-name NAME] FILE")
+		return
+	}
+	packPath := flags.Arg(0)
+
+	info, err := content.Inspect(packPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("整合包: %s %s (%s)\n", info.Name, info.Version, info.Format)
+	fmt.Printf("Minecraft %s, 加载器: %s %s\n", info.MCVersion, info.Loader, info.LoaderVersion)
+
+	dm := newDownloadManager(dataDir)
+	defer dm.Queue().Close()
+
+	instancesDir := filepath.Join(dataDir, "instances")
+	importer := content.NewPackImporter(manager, instancesDir, dm)
+	inst, _, err := importer.Import(packPath, content.ImportOptions{
+		Name:         *name,
+		JavaResolver: resolveJavaForMinecraft,
+		Progress: func(current, total int, file string) {
+			fmt.Printf("[%d/%d] 下载 %s\n", current, total, file)
+		},
+	})
+	if err != nil {
+		fmt.Printf("❌ 导入失败: %v\n", err)
+		return
+	}
+
+	fmt.Printf("✓ 实例 '%s' 创建成功\n", inst.Name)
+	fmt.Printf("  工作目录: %s\n", inst.GetWorkDir(instancesDir))
+	fmt.Printf("  Java: %s\n", inst.JavaBinding())
+	printCoreProvenance(inst)
+}
+
+// handleInstanceUpgradeCommand 处理服务端核心升级命令
+func handleInstanceUpgradeCommand(args []string, manager *instance.Manager, processManager *instance.ProcessManager, dataDir string) {
+	flags := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	build := flags.String("build", "", "目标构建版本")
+	latest := flags.Bool("latest", false, "升级到最新构建（默认）")
+	start := flags.Bool("start", false, "升级后启动实例并等待就绪，失败则恢复旧核心")
+	timeout := flags.Int("timeout", 180, "等待实例启动完成的秒数")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	if flags.NArg() < 1 {
+		fmt.Println("用法: instance upgrade [-build N | -latest] [-start] [-timeout 180] NAME")
+		return
+	}
+	if *build != "" && *latest {
+		fmt.Println("错误: -build 和 -latest 不能同时使用")
+		return
+	}
+	name := flags.Arg(0)
+
+	inst, err := manager.GetInstance(name)
+	if err != nil {
+		fmt.Printf("未找到实例: %s\n", name)
+		return
+	}
+
+	dm := newDownloadManager(dataDir)
+	upgrader := instance.NewCoreUpgrader(processManager, dm)
+
+	target, err := upgrader.ResolveBuild(inst, *build)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	current := inst.CoreVersion
+	if current == "" {
+		current = "未知"
+	}
+	fmt.Printf("%s %s: %s -> %s\n", inst.ServerType, inst.MCVersion, current, target.CoreVersion)
+
+	if changes, err := dm.GetChangelog(inst.ServerType, inst.MCVersion, inst.CoreVersion, target.CoreVersion); err == nil {
+		if len(changes) > 0 {
+			fmt.Println("\n更新日志:")
+			for _, change := range changes {
+				fmt.Printf("  #%d %s\n", change.Build, change.Summary)
+			}
+			fmt.Println()
+		}
+	} else if !errors.Is(err, download.ErrNoChangelog) {
+		fmt.Printf("⚠️  获取更新日志失败: %v\n", err)
+	}
+
+	result, err := upgrader.Upgrade(name, instance.UpgradeOptions{
+		Build:   target.CoreVersion,
+		Start:   *start,
+		Timeout: time.Duration(*timeout) * time.Second,
+	})
+	if err != nil {
+		fmt.Printf("❌ 升级失败: %v\n", err)
+		return
+	}
+
+	fmt.Printf("✓ 实例 '%s' 已升级到 %s (%s)\n", name, result.NewBuild, result.NewJar)
+	if result.BackupFile != "" {
+		fmt.Printf("  旧核心备份: %s\n", result.BackupFile)
+	}
+	if *start {
+		fmt.Printf("✓ 实例 '%s' 已就绪\n", name)
+	}
+}
+
+// resolveJavaForMinecraft 从已登记和检测到的Java中选择满足该Minecraft版本要求的Java，没有时返回空字符串
+func resolveJavaForMinecraft(mcVersion string) string {
+	sel := selectJavaForMinecraft(mcVersion)
+	if sel.Java == nil {
+		return ""
+	}
+	fmt.Printf("Java: %s\n", sel.Reason)
+	return sel.Java.Path
+}
+
+// selectJavaForMinecraft 按兼容表选择Java，已登记的Java中没有满足要求的时再检测系统中的Java
+func selectJavaForMinecraft(mcVersion string) *java.Selection {
+	javaManager := newJavaManager()
+	if _, err := javaManager.LoadJavaList(); err == nil {
+		if sel := javaManager.SelectForMinecraft(mcVersion); sel.Java != nil {
+			return sel
+		}
+	}
+
+	detected, _ := javaManager.Detect(context.Background(), false, nil)
+	return java.SelectJavaForMinecraft(detected, mcVersion)
+}
+
+// ensureJavaForMinecraft 选择满足Minecraft版本要求的Java，没有时询问是否从JDK索引安装
+func ensureJavaForMinecraft(mcVersion string, scanner *bufio.Scanner) string {
+	if javaPath := resolveJavaForMinecraft(mcVersion); javaPath != "" {
+		return javaPath
+	}
+
+	required := java.RequiredJavaMajor(mcVersion)
+	fmt.Printf("⚠️  未找到Java %d或更高版本 (Minecraft %s需要)\n", required, mcVersion)
+	fmt.Printf("是否自动安装Java %d? (Y/n): ", required)
+	if scanner.Scan() && strings.ToLower(strings.TrimSpace(scanner.Text())) != "n" {
+		installed, err := installJavaRuntime(required, "jdk")
+		if err == nil {
+			return installed.Path
+		}
+		fmt.Printf("❌ 安装Java失败: %v\n", err)
+	}
+
+	fmt.Println("将使用系统PATH中的java，可稍后在实例设置中修改Java路径")
+	return "java"
+}
+
+// installJavaRuntime 从配置的JDK索引安装指定主版本的Java到 data/runtimes
+func installJavaRuntime(major int, imageType string) (*java.Java, error) {
+	dm := newDownloadManager("./data")
+	defer dm.Queue().Close()
+
+	installer := java.NewRuntimeInstaller(newJavaManager(), dm,
+		filepath.Join("./data", "runtimes"), config.GetString("java.runtime_index"))
+	installed, err := installer.Install(major, imageType)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("✓ 已安装Java %s: %s\n", installed.Version, installed.Path)
+	return installed, nil
+}
+
+// newContentSources 根据配置创建内容源
+func newContentSources() []content.Source {
+	return []content.Source{
+		content.NewModrinthSource(config.GetString("content.sources.modrinth")),
+		content.NewHangarSource(config.GetString("content.sources.hangar")),
+	}
+}
+
+// handleInstanceContentCommand 处理从内容源搜索和安装插件/模组的命令
+func handleInstanceContentCommand(args []string, manager *instance.Manager, processManager *instance.ProcessManager, instancesDir string) {
+	if len(args) < 2 {
+		fmt.Println("内容源命令:")
+		fmt.Println("  content search [-source S] [-kind K] NAME QUERY            搜索兼容的插件/模组")
+		fmt.Println("  content install [-source S] [-kind K] [-version V] NAME PROJECT  安装插件/模组及其依赖")
+		fmt.Println("  content installed NAME                                     列出通过内容源安装的内容")
+		fmt.Println("  content outdated NAME                                      检查可用更新")
+		fmt.Println("  content update [-all] [-no-check] [-timeout 180] NAME [PROJECT...]  更新内容，启动失败时自动回滚")
+		fmt.Println()
+		fmt.Println("内容源: modrinth (默认), hangar")
+		return
+	}
+
+	action := args[0]
+	flags := flag.NewFlagSet("content "+action, flag.ContinueOnError)
+	sourceName := flags.String("source", "modrinth", "内容源 (modrinth, hangar)")
+	kind := flags.String("kind", "", "内容类型 (plugin, mod)，默认根据服务端类型判断")
+	versionRef := flags.String("version", "", "指定版本（版本ID或版本号），默认最新兼容版本")
+	limit := flags.Int("limit", 10, "搜索结果数量")
+	all := flags.Bool("all", false, "更新所有有可用更新的内容")
+	timeout := flags.Int("timeout", 180, "更新后等待实例启动完成的秒数")
+	noCheck := flags.Bool("no-check", false, "更新后不启动实例检查（实例未运行时）")
+	if err := flags.Parse(args[1:]); err != nil {
+		return
+	}
+	rest := flags.Args()
+
+	if len(rest) < 1 {
+		fmt.Println("错误: 缺少实例名称")
+		return
+	}
+
+	inst, err := manager.GetInstance(rest[0])
+	if err != nil {
+		fmt.Printf("未找到实例: %s\n", rest[0])
+		return
+	}
+
+	// 通过下载管理器的队列下载，遵守全局和单个任务的限速
+	dm := newDownloadManager(filepath.Dir(instancesDir))
+	defer dm.Queue().Close()
+
+	installer := content.NewInstaller(inst, instancesDir, *kind, dm.Queue(), newContentSources()...)
+
+	switch action {
+	case "search":
+		if len(rest) < 2 {
+			fmt.Println("错误: 缺少搜索关键词")
+			return
+		}
+		fmt.Printf("正在 %s 中搜索 '%s' (Minecraft %s, %s)...\n",
+			*sourceName, rest[1], inst.MCVersion, strings.Join(installer.Loaders(), "/"))
+		projects, err := installer.Search(*sourceName, strings.Join(rest[1:], " "), *limit)
+		if err != nil {
+			fmt.Printf("❌ 搜索失败: %v\n", err)
+			return
+		}
+		content.PrintProjects(projects)
+
+	case "install":
+		if len(rest) < 2 {
+			fmt.Println("错误: 缺少项目ID或slug")
+			return
+		}
+		fmt.Printf("正在从 %s 安装 %s...\n", *sourceName, rest[1])
+		installed, err := installer.Install(*sourceName, rest[1], *versionRef)
+		if err != nil {
+			fmt.Printf("❌ 安装失败: %v\n", err)
+			return
+		}
+		for _, entry := range installed {
+			suffix := ""
+			if entry.Dependency {
+				suffix = " (依赖)"
+			}
+			fmt.Printf("✓ 已安装 %s %s -> %s%s\n", entry.Name, entry.VersionNumber, entry.File, suffix)
+		}
+		if inst.IsRunning() {
+			fmt.Println("提示: 新内容将在实例重启后生效")
+		}
+
+	case "installed":
+		entries, err := installer.Installed()
+		if err != nil {
+			fmt.Printf("❌ 读取安装记录失败: %v\n", err)
+			return
+		}
+		content.PrintInstalled(entries)
+
+	case "outdated":
+		fmt.Println("正在检查更新...")
+		updates, errs := installer.Outdated()
+		for _, err := range errs {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		content.PrintUpdates(updates)
+
+	case "update":
+		updates, errs := installer.Outdated()
+		for _, err := range errs {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		if !*all {
+			if len(rest) < 2 {
+				fmt.Println("错误: 请指定要更新的内容，或使用 -all 更新全部")
+				return
+			}
+			if updates, err = content.SelectUpdates(updates, rest[1:]); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return
+			}
+		}
+		if len(updates) == 0 {
+			fmt.Println("✓ 所有内容均为最新版本")
+			return
+		}
+
+		// 重启（未运行时临时启动）实例并检查是否能正常启动，失败则回滚
+		var check func() error
+		wasRunning := inst.IsRunning() && processManager.IsProcessRunning(inst.PID)
+		switch {
+		case wasRunning:
+			check = func() error {
+				return restartAndWaitReady(manager, processManager, inst.Name, time.Duration(*timeout)*time.Second)
+			}
+		case *noCheck:
+			fmt.Println("提示: 已指定 -no-check，跳过启动就绪检查")
+		default:
+			check = func() error {
+				return startCheckAndStop(manager, processManager, inst.Name, time.Duration(*timeout)*time.Second)
+			}
+		}
+
+		batch, err := installer.ApplyUpdates(updates, check)
+		if err != nil {
+			fmt.Printf("❌ 更新失败: %v\n", err)
+			if batch != nil && wasRunning {
+				fmt.Println("正在使用旧版本重新启动实例...")
+				if err := restartAndWaitReady(manager, processManager, inst.Name, time.Duration(*timeout)*time.Second); err != nil {
+					fmt.Printf("❌ 重新启动失败: %v\n", err)
+				}
+			}
+			return
+		}
+
+		for _, item := range batch.Items {
+			fmt.Printf("✓ %s: %s -> %s\n", item.New.Name, item.Old.VersionNumber, item.New.VersionNumber)
+		}
+		fmt.Printf("旧版本已备份到: %s\n", batch.BackupDir)
+
+	default:
+		fmt.Printf("未知内容源操作: %s\n", action)
+	}
+}
+
+// restartAndWaitReady 重启实例并等待启动完成，未能就绪时停止实例
+func restartAndWaitReady(manager *instance.Manager, processManager *instance.ProcessManager, name string, timeout time.Duration) error {
+	offset := processManager.LogOffset(name)
+	if err := processManager.RestartInstance(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("等待实例 '%s' 启动完成...\n", name)
+	if err := processManager.WaitForReady(name, offset, timeout); err != nil {
+		if inst, getErr := manager.GetInstance(name); getErr == nil && processManager.IsProcessRunning(inst.PID) {
+			processManager.StopInstance(name)
+		}
+		return err
+	}
+
+	fmt.Printf("✓ 实例 '%s' 已就绪\n", name)
+	return nil
+}
+
+// startCheckAndStop 启动未运行的实例，等待启动完成后再停止，用于检查更新后能否正常启动
+func startCheckAndStop(manager *instance.Manager, processManager *instance.ProcessManager, name string, timeout time.Duration) error {
+	offset := processManager.LogOffset(name)
+	if err := processManager.StartInstance(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("等待实例 '%s' 启动完成...\n", name)
+	err := processManager.WaitForReady(name, offset, timeout)
+	if inst, getErr := manager.GetInstance(name); getErr == nil && processManager.IsProcessRunning(inst.PID) {
+		if stopErr := processManager.StopInstance(name); stopErr != nil {
+			fmt.Printf("⚠️  停止实例失败: %v\n", stopErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ 实例 '%s' 可以正常启动，已停止\n", name)
+	return nil
+}
+
+// checkInstanceContent 启动前检查模组兼容性和依赖，只提示不阻止启动
+func checkInstanceContent(inst *instance.Instance, instancesDir string) {
+	modManager := content.NewModManager(inst, instancesDir)
+	if modManager.Loader() == "" {
+		return
+	}
+
+	mods, err := modManager.List()
+	if err != nil {
+		return
+	}
+
+	for _, problem := range modManager.Check(mods) {
+		if problem.Severity == content.SeverityError {
+			fmt.Printf("⚠️  模组 %s: %s\n", problem.Subject, problem.Message)
+		}
+	}
+}
+
+func handleFRPCommand(args []string, dataDir string) {
+	if len(args) == 0 {
+		fmt.Println("FRP管理命令:")
+		fmt.Println("  status        查看frpc状态")
+		fmt.Println("  start         启动frpc")
+		fmt.Println("  stop          停止frpc")
+		fmt.Println("  restart       重启frpc")
+		return
+	}
+
+	manager := frp.NewManager(dataDir)
+
+	switch args[0] {
+	case "status":
+		status := manager.GetFRPCStatus()
+		fmt.Printf("frpc状态: %s\n", status)
+
+	case "start":
+		if err := manager.StartFRPC(); err != nil {
+			fmt.Printf("启动frpc失败: %v\n", err)
+		} else {
+			fmt.Println("frpc启动成功")
+		}
+
+	case "stop":
+		if err := manager.StopFRPC(); err != nil {
+			fmt.Printf("停止frpc失败: %v\n", err)
+		} else {
+			fmt.Println("frpc停止成功")
+		}
+
+	case "restart":
+		if err := manager.RestartFRPC(); err != nil {
+			fmt.Printf("重启frpc失败: %v\n", err)
+		} else {
+			fmt.Println("frpc重启成功")
+		}
+
+	default:
+		fmt.Printf("未知子命令: %s\n", args[0])
+	}
+}
+
+func handleJavaCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Java管理命令:")
+		fmt.Println("  detect        扫描并保存Java列表 (java.search_paths / java.exclude_paths)")
+		fmt.Println("  list          列出已保存的Java")
+		fmt.Println("  install [-jre] N  从JDK索引安装Java N到 data/runtimes")
+		fmt.Println("  select MC_VERSION 按兼容表为Minecraft版本选择Java")
+		return
+	}
+
+	if args[0] == "select" {
+		if len(args) < 2 {
+			fmt.Println("用法: java select MC_VERSION")
+			return
+		}
+		handleJavaSelect(args[1])
+		return
+	}
+
+	if args[0] == "install" {
+		flags := flag.NewFlagSet("java install", flag.ContinueOnError)
+		jre := flags.Bool("jre", false, "安装JRE而不是JDK")
+		if err := flags.Parse(args[1:]); err != nil {
+			return
+		}
+		major, err := strconv.Atoi(flags.Arg(0))
+		if flags.NArg() < 1 || err != nil || major <= 0 {
+			fmt.Println("用法: java install [-jre] N")
+			return
+		}
+		imageType := "jdk"
+		if *jre {
+			imageType = "jre"
+		}
+		if _, err := installJavaRuntime(major, imageType); err != nil {
+			fmt.Printf("❌ 安装Java失败: %v\n", err)
+		}
+		return
+	}
+
+	switch args[0] {
+	case "detect":
+		if err := handleJavaDetect(); err != nil {
+			fmt.Printf("检测Java失败: %v\n", err)
+		}
+
+	case "list":
+		if err := handleJavaList(); err != nil {
+			fmt.Printf("读取Java列表失败: %v\n", err)
+		}
+
+	default:
+		fmt.Printf("未知子命令: %s\n", args[0])
+	}
+}
+
+func handleDownloadCommand(args []string, dataDir string) {
+	if len(args) == 0 {
+		fmt.Println("下载管理命令:")
+		fmt.Println("  list          列出可用服务端")
 		fmt.Println("  files         查看已下载文件")
+		fmt.Println("  mirrors       测试镜像下载源延迟")
+		fmt.Println("  catalog status             查看离线目录缓存")
+		fmt.Println("  catalog export [-files A,B] FILE  导出目录缓存和已下载文件")
+		fmt.Println("  catalog import FILE        导入其他机器导出的目录和文件")
+		fmt.Println("  cache stats        查看下载缓存统计")
+		fmt.Println("  cache clean [-days N]  清理没有实例引用的缓存文件")
+		fmt.Println("  queue list [-all]  查看下载队列")
+		fmt.Println("  queue cancel ID    取消下载任务")
+		fmt.Println("  queue limit [-job ID] RATE  调整正在进行的下载的限速 (如 2MB，0为不限速)")
 		return
 	}
 
-	dm := download.NewDownloadManager(dataDir)
+	dm := newDownloadManager(dataDir)
 
 	switch args[0] {
 	case "list":
@@ -2199,11 +3505,247 @@ func handleDownloadCommand(args []string, dataDir string) {
 	case "files":
 		dm.PrintDownloadedFiles()
 
+	case "mirrors":
+		fmt.Println("正在测试镜像下载源...")
+		for i, probe := range dm.ProbeMirrors() {
+			if probe.Reachable() {
+				fmt.Printf("%d. ✓ %-12s %v\n", i+1, probe.Name, probe.Latency.Round(time.Millisecond))
+			} else {
+				fmt.Printf("%d. ❌ %-12s %s\n", i+1, probe.Name, probe.Error)
+			}
+		}
+
+	case "queue":
+		handleDownloadQueueCommand(args[1:], dm)
+
+	case "cache":
+		handleDownloadCacheCommand(args[1:], dm)
+
+	case "catalog":
+		handleDownloadCatalogCommand(args[1:], dm)
+
+	default:
+		fmt.Printf("未知子命令: %s\n", args[0])
+	}
+}
+
+// handleDownloadQueueCommand 查看和取消所有进程中的下载任务
+func handleDownloadQueueCommand(args []string, dm *download.DownloadManager) {
+	if len(args) == 0 {
+		fmt.Println("用法: download queue list [-all] | download queue cancel ID | download queue limit [-job ID] RATE")
+		return
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("queue list", flag.ContinueOnError)
+		all := flags.Bool("all", false, "同时显示已结束的任务")
+		if err := flags.Parse(args[1:]); err != nil {
+			return
+		}
+
+		jobs, err := download.ListQueueJobs(dm.GetQueueDir())
+		if err != nil {
+			fmt.Printf("读取下载队列失败: %v\n", err)
+			return
+		}
+
+		count := 0
+		for _, job := range jobs {
+			active := job.Status == download.JobQueued || job.Status == download.JobRunning
+			if !active && !*all {
+				continue
+			}
+			count++
+
+			progress := formatJobProgress(job)
+			fmt.Printf("%-10s %-9s %-4d %s  %s", job.ID, job.Status, job.Priority, progress, job.Label)
+			if job.Source != "" {
+				fmt.Printf(" [%s]", job.Source)
+			}
+			if job.RateLimit > 0 && active {
+				fmt.Printf(" (限速 %s)", download.FormatRate(job.RateLimit))
+			}
+			fmt.Println()
+			if job.Error != "" {
+				fmt.Printf("           错误: %s\n", job.Error)
+			}
+		}
+		if count == 0 {
+			fmt.Println("下载队列为空")
+		}
+
+	case "cancel":
+		if len(args) < 2 {
+			fmt.Println("用法: download queue cancel ID")
+			return
+		}
+		if err := download.RequestCancel(dm.GetQueueDir(), args[1]); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 已请求取消下载任务 %s\n", args[1])
+
+	case "limit":
+		flags := flag.NewFlagSet("queue limit", flag.ContinueOnError)
+		jobID := flags.String("job", "", "只调整指定任务的限速")
+		if err := flags.Parse(args[1:]); err != nil {
+			return
+		}
+		if flags.NArg() < 1 {
+			fmt.Println("用法: download queue limit [-job ID] RATE")
+			return
+		}
+		rate, err := download.ParseRate(flags.Arg(0))
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		if err := download.RequestRateLimit(dm.GetQueueDir(), *jobID, rate); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		target := "正在进行的下载"
+		if *jobID != "" {
+			target = "下载任务 " + *jobID
+		}
+		fmt.Printf("✓ 已请求调整%s的限速: %s\n", target, download.FormatRate(rate))
+		if *jobID == "" {
+			fmt.Println("  仅对当前运行的下载生效，默认限速请修改 download.rate_limit")
+		}
+
+	default:
+		fmt.Printf("未知子命令: %s\n", args[0])
+	}
+}
+
+// handleDownloadCacheCommand 查看和清理下载缓存
+func handleDownloadCacheCommand(args []string, dm *download.DownloadManager) {
+	if len(args) == 0 {
+		fmt.Println("用法: download cache stats | download cache clean [-days N]")
+		return
+	}
+
+	cache := dm.Cache()
+	switch args[0] {
+	case "stats":
+		stats, err := cache.Stats()
+		if err != nil {
+			fmt.Printf("读取下载缓存失败: %v\n", err)
+			return
+		}
+
+		fmt.Printf("下载缓存: %s\n", cache.Dir())
+		fmt.Printf("  文件数: %d (被实例引用: %d)\n", stats.Entries, stats.Referenced)
+		fmt.Printf("  磁盘占用: %s\n", download.FormatBytes(stats.Size))
+		fmt.Printf("  引用总大小: %s (硬链接共享 %d 个)\n", download.FormatBytes(stats.LogicalSize), stats.HardLinked)
+		lookups := stats.Hits + stats.Misses
+		if lookups > 0 {
+			fmt.Printf("  命中: %d / %d (%.1f%%)\n", stats.Hits, lookups, float64(stats.Hits)/float64(lookups)*100)
+		} else {
+			fmt.Println("  命中: 0 / 0")
+		}
+
+	case "clean":
+		flags := flag.NewFlagSet("cache clean", flag.ContinueOnError)
+		days := flags.Int("days", config.GetInt("download.cleanup_days"), "清理超过N天未使用的文件，0表示所有未引用的文件")
+		if err := flags.Parse(args[1:]); err != nil {
+			return
+		}
+
+		removed, freed, err := cache.Evict(time.Duration(*days) * 24 * time.Hour)
+		if err != nil {
+			fmt.Printf("❌ 清理下载缓存失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 已清理 %d 个缓存文件，释放 %s\n", removed, download.FormatBytes(freed))
+
+	default:
+		fmt.Printf("未知子命令: %s\n", args[0])
+	}
+}
+
+// handleDownloadCatalogCommand 管理离线目录缓存
+func handleDownloadCatalogCommand(args []string, dm *download.DownloadManager) {
+	if len(args) == 0 {
+		fmt.Println("用法: download catalog status | export [-files A,B] FILE | import FILE")
+		return
+	}
+
+	switch args[0] {
+	case "status":
+		info, err := dm.Catalog().Info()
+		if err != nil {
+			fmt.Printf("读取目录缓存失败: %v\n", err)
+			return
+		}
+		fmt.Printf("目录缓存: %s\n", dm.Catalog().Dir())
+		if info.Entries == 0 {
+			fmt.Println("  目录缓存为空，浏览一次下载菜单或导入其他机器的目录后即可离线使用")
+			return
+		}
+		fmt.Printf("  条目: %d (已过期: %d)\n", info.Entries, info.Stale)
+		fmt.Printf("  最旧: %s (%s前)\n", info.Oldest.Format("2006-01-02 15:04"), formatAge(time.Since(info.Oldest)))
+		fmt.Printf("  最新: %s (%s前)\n", info.Newest.Format("2006-01-02 15:04"), formatAge(time.Since(info.Newest)))
+
+	case "export":
+		flags := flag.NewFlagSet("catalog export", flag.ContinueOnError)
+		files := flags.String("files", "", "同时导出的已下载文件，逗号分隔 (见 'download files')")
+		if err := flags.Parse(args[1:]); err != nil {
+			return
+		}
+		if flags.NArg() < 1 {
+			fmt.Println("用法: download catalog export [-files A,B] FILE")
+			return
+		}
+
+		var names []string
+		for _, name := range strings.Split(*files, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+
+		count, err := dm.ExportCatalog(flags.Arg(0), names)
+		if err != nil {
+			fmt.Printf("❌ 导出失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 已导出 %d 条目录缓存和 %d 个文件到 %s\n", count, len(names), flags.Arg(0))
+
+	case "import":
+		if len(args) < 2 {
+			fmt.Println("用法: download catalog import FILE")
+			return
+		}
+		count, files, err := dm.ImportCatalog(args[1])
+		if err != nil {
+			fmt.Printf("❌ 导入失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 已导入 %d 条目录缓存\n", count)
+		for _, file := range files {
+			fmt.Printf("  文件: %s\n", file)
+		}
+
 	default:
 		fmt.Printf("未知子命令: %s\n", args[0])
 	}
 }
 
+// formatJobProgress 格式化下载任务进度
+func formatJobProgress(job download.JobInfo) string {
+	progress := download.FormatBytes(job.Downloaded)
+	if job.Total > 0 {
+		progress = fmt.Sprintf("%5.1f%% (%s/%s)", float64(job.Downloaded)/float64(job.Total)*100,
+			download.FormatBytes(job.Downloaded), download.FormatBytes(job.Total))
+	}
+	if job.Status == download.JobRunning && job.Speed > 0 {
+		progress += " " + download.FormatSpeed(job.Speed)
+	}
+	return progress
+}
+
 func handleConfigCommand(args []string) {
 	if len(args) == 0 {
 		fmt.Println("配置管理命令:")
@@ -2227,8 +3769,7 @@ func handleConfigCommand(args []string) {
 			return
 		}
 		key, value := args[1], args[2]
-		config.Set(key, value)
-		if err := config.SaveConfig(); err != nil {
+		if err := config.SetAndSave(key, value); err != nil {
 			fmt.Printf("保存配置失败: %v\n", err)
 		} else {
 			fmt.Printf("配置已设置: %s = %s\n", key, value)
@@ -2249,6 +3790,83 @@ func handleConfigCommand(args []string) {
 	}
 }
 
+// handleAuditCommand 处理审计日志查询命令
+func handleAuditCommand(args []string) {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	actor := flags.String("actor", "", "按操作者过滤")
+	source := flags.String("source", "", "按来源过滤 (cli, menu, api, scheduler)")
+	action := flags.String("action", "", "按操作过滤 (如 instance.Start，instance.* 匹配所有实例操作)")
+	target := flags.String("target", "", "按操作对象过滤 (如实例名称)")
+	result := flags.String("result", "", "按结果过滤 (success, failure)")
+	since := flags.String("since", "", "起始时间 (如 2006-01-02、2006-01-02 15:04:05 或 24h)")
+	until := flags.String("until", "", "结束时间 (格式同 -since)")
+	limit := flags.Int("limit", 50, "最多显示最近的N条，0表示不限制")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+
+	filter := &audit.Filter{
+		Actor:  *actor,
+		Source: audit.Source(*source),
+		Action: *action,
+		Target: *target,
+		Result: *result,
+		Limit:  *limit,
+	}
+
+	var err error
+	if filter.Since, err = parseAuditTime(*since); err != nil {
+		fmt.Printf("无效的起始时间: %v\n", err)
+		return
+	}
+	if filter.Until, err = parseAuditTime(*until); err != nil {
+		fmt.Printf("无效的结束时间: %v\n", err)
+		return
+	}
+
+	logger := audit.Default()
+	if logger == nil {
+		fmt.Println("审计日志未启用")
+		return
+	}
+
+	entries, err := logger.Query(filter)
+	if err != nil {
+		fmt.Printf("查询审计日志失败: %v\n", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("未找到匹配的审计记录")
+		return
+	}
+
+	fmt.Printf("审计记录 (%d条, 文件: %s):\n", len(entries), logger.Path())
+	for _, entry := range entries {
+		fmt.Println(entry.String())
+	}
+}
+
+// parseAuditTime 解析审计查询时间，支持日期、日期时间和相对时长
+func parseAuditTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("无法识别的时间格式: %s", value)
+}
+
 func handleEditInstanceConfig(manager *instance.Manager, inst *instance.Instance, scanner *bufio.Scanner) error {
 	fmt.Printf("\n=== 编辑实例配置: %s ===\n", inst.Name)
 
@@ -2256,13 +3874,18 @@ func handleEditInstanceConfig(manager *instance.Manager, inst *instance.Instance
 		fmt.Println("\n可编辑的配置项:")
 		fmt.Println("1. 最大内存")
 		fmt.Println("2. 最小内存")
-		fmt.Println("3. Java参数")
+		if inst.JVMPreset != "" {
+			fmt.Println("3. 额外Java参数")
+		} else {
+			fmt.Println("3. Java参数")
+		}
 		fmt.Println("4. 服务器参数")
 		fmt.Println("5. 启动命令")
 		fmt.Println("6. 自动启动")
 		fmt.Println("7. 自动重启")
+		fmt.Println("8. JVM参数预设")
 		fmt.Println("0. 保存并返回")
-		fmt.Print("请选择要编辑的配置 (0-7): ")
+		fmt.Print("请选择要编辑的配置 (0-8): ")
 
 		if !scanner.Scan() {
 			return fmt.Errorf("读取输入失败")
@@ -2279,7 +3902,11 @@ func handleEditInstanceConfig(manager *instance.Manager, inst *instance.Instance
 			}
 			newValue := strings.TrimSpace(scanner.Text())
 			if newValue != "" {
-				inst.MaxMemory = newValue
+				if _, err := java.ParseMemory(newValue); err != nil {
+					fmt.Printf("❌ %v\n", err)
+					continue
+				}
+				inst.UpdateMemorySettings(inst.MinMemory, newValue)
 				fmt.Println("✓ 最大内存已更新")
 			}
 
@@ -2291,7 +3918,11 @@ func handleEditInstanceConfig(manager *instance.Manager, inst *instance.Instance
 			}
 			newValue := strings.TrimSpace(scanner.Text())
 			if newValue != "" {
-				inst.MinMemory = newValue
+				if _, err := java.ParseMemory(newValue); err != nil {
+					fmt.Printf("❌ %v\n", err)
+					continue
+				}
+				inst.UpdateMemorySettings(newValue, inst.MaxMemory)
 				fmt.Println("✓ 最小内存已更新")
 			}
 
@@ -2322,6 +3953,18 @@ func handleEditInstanceConfig(manager *instance.Manager, inst *instance.Instance
 		case "5":
 			return handleEditStartCommand(inst, scanner)
 
+		case "8":
+			printJVMPresets(inst.JVMPreset)
+			fmt.Print("请输入预设名称: ")
+			if !scanner.Scan() {
+				return fmt.Errorf("读取输入失败")
+			}
+			if err := setInstanceJVMPreset(inst, strings.TrimSpace(scanner.Text())); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				continue
+			}
+			fmt.Printf("✓ JVM参数预设已设置为: %s\n", inst.JVMPreset)
+
 		case "6":
 			fmt.Printf("当前自动启动: %t\n", inst.AutoStart)
 			fmt.Print("是否启用自动启动? (y/N): ")
@@ -2423,15 +4066,23 @@ func handleEditStartCommand(inst *instance.Instance, scanner *bufio.Scanner) err
 			fmt.Printf("自定义命令: %s\n", inst.StartCmd)
 		} else {
 			// 显示默认命令（需要模拟生成）
-			defaultCmd := fmt.Sprintf("java -Xmx%s -Xms%s",
-				getMemoryOrDefault(inst.MaxMemory, "1G"),
-				getMemoryOrDefault(inst.MinMemory, "512M"))
-
-			if len(inst.JavaArgs) > 0 {
-				defaultCmd += " " + strings.Join(inst.JavaArgs, " ")
+			defaultCmd := "java"
+			javaArgs, warnings, err := inst.BuildJavaArgs()
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+			for _, warning := range warnings {
+				fmt.Printf("⚠️  %s\n", warning)
+			}
+			if len(javaArgs) > 0 {
+				defaultCmd += " " + strings.Join(javaArgs, " ")
 			}
 
-			defaultCmd += " -jar " + inst.ServerJar
+			if inst.ArgsFile != "" {
+				defaultCmd += " @" + inst.ArgsFile
+			} else {
+				defaultCmd += " -jar " + inst.ServerJar
+			}
 
 			if len(inst.ServerArgs) > 0 {
 				defaultCmd += " " + strings.Join(inst.ServerArgs, " ")
@@ -2450,42 +4101,6 @@ func handleEditStartCommand(inst *instance.Instance, scanner *bufio.Scanner) err
 	return nil
 }
 
-// getMemoryOrDefault 获取内存设置或默认值
-func getMemoryOrDefault(memory, defaultValue string) string {
-	if memory != "" {
-		return memory
-	}
-	return defaultValue
-}
-
-// copyFile 复制文件
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("打开源文件失败: %w", err)
-	}
-	defer sourceFile.Close()
-
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("创建目标文件失败: %w", err)
-	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, sourceFile)
-	if err != nil {
-		return fmt.Errorf("复制文件内容失败: %w", err)
-	}
-
-	// 确保数据写入磁盘
-	err = destFile.Sync()
-	if err != nil {
-		return fmt.Errorf("同步文件失败: %w", err)
-	}
-
-	return nil
-}
-
 func handleViewInstanceLogs(inst *instance.Instance) error {
 	fmt.Printf("\n=== 查看实例日志: %s ===\n", inst.Name)
 
@@ -0,0 +1,162 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxEntrySize 单个文件解压后的最大大小，防止压缩炸弹
+const maxEntrySize = 2 * 1024 * 1024 * 1024
+
+// SafeJoin 将压缩包内的相对路径拼接到目标目录，拒绝绝对路径和 ".." 等越界路径
+func SafeJoin(dest, name string) (string, error) {
+	// 压缩包内统一使用 "/"，同时拒绝Windows风格的路径分隔符和盘符
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, ":") {
+		return "", fmt.Errorf("非法路径: %s", name)
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("路径越界: %s", name)
+	}
+
+	base, err := filepath.Abs(dest)
+	if err != nil {
+		return "", fmt.Errorf("解析目标目录失败: %w", err)
+	}
+
+	target := filepath.Join(base, filepath.FromSlash(cleaned))
+	rel, err := filepath.Rel(base, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("路径越界: %s", name)
+	}
+
+	return target, nil
+}
+
+// ExtractZip 解压zip文件到目标目录
+func ExtractZip(src, dest string) (int, error) {
+	reader, err := zip.OpenReader(src)
+	if err != nil {
+		return 0, fmt.Errorf("打开压缩包失败: %w", err)
+	}
+	defer reader.Close()
+
+	return Extract(&reader.Reader, "", dest)
+}
+
+// Extract 解压zip中prefix目录下的文件到目标目录（去掉prefix），返回解压的文件数
+//
+// 符号链接会被跳过，任何越界路径都会导致整个解压失败。
+func Extract(reader *zip.Reader, prefix, dest string) (int, error) {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	// 先检查所有路径，避免解压到一半才发现非法条目
+	type entry struct {
+		file   *zip.File
+		target string
+	}
+	var entries []entry
+	for _, file := range reader.File {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		name = strings.TrimPrefix(name, prefix)
+		if name == "" {
+			continue
+		}
+
+		if file.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		target, err := SafeJoin(dest, name)
+		if err != nil {
+			return 0, err
+		}
+		entries = append(entries, entry{file: file, target: target})
+	}
+
+	count := 0
+	for _, e := range entries {
+		if e.file.FileInfo().IsDir() {
+			if err := os.MkdirAll(e.target, 0755); err != nil {
+				return count, fmt.Errorf("创建目录失败: %w", err)
+			}
+			continue
+		}
+
+		if err := extractFile(e.file, e.target); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// extractFile 解压单个文件
+func extractFile(file *zip.File, target string) error {
+	if file.UncompressedSize64 > maxEntrySize {
+		return fmt.Errorf("文件过大: %s", file.Name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("打开 %s 失败: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	mode := file.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer out.Close()
+
+	written, err := io.Copy(out, io.LimitReader(rc, maxEntrySize+1))
+	if err != nil {
+		return fmt.Errorf("解压 %s 失败: %w", file.Name, err)
+	}
+	if written > maxEntrySize {
+		return fmt.Errorf("文件过大: %s", file.Name)
+	}
+
+	return nil
+}
+
+// CommonRoot 如果压缩包内所有文件都位于同一个顶层目录下，返回该目录（带 "/"），否则返回空字符串
+func CommonRoot(reader *zip.Reader) string {
+	root := ""
+	for _, file := range reader.File {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		idx := strings.Index(name, "/")
+		if idx < 0 {
+			return ""
+		}
+		top := name[:idx+1]
+		if root == "" {
+			root = top
+		} else if root != top {
+			return ""
+		}
+	}
+	return root
+}
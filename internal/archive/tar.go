@@ -0,0 +1,178 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractTarGz 解压tar.gz文件到目标目录，返回解压的文件数
+//
+// JDK等压缩包依赖符号链接，因此保留指向目标目录内部的相对符号链接，其他符号链接会被跳过；
+// 任何越界路径都会导致解压失败。符号链接在所有文件写入后才创建，并按磁盘上的实际路径检查，
+// 避免通过链接链写到目标目录之外。
+func ExtractTarGz(src, dest string) (int, error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("打开压缩包失败: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, fmt.Errorf("打开压缩包失败: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return 0, fmt.Errorf("创建目录失败: %w", err)
+	}
+	root, err := filepath.Abs(dest)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("解析目标目录失败: %w", err)
+	}
+
+	reader := tar.NewReader(gz)
+	count := 0
+	var links []tarLink
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("读取压缩包失败: %w", err)
+		}
+
+		name := strings.TrimPrefix(strings.ReplaceAll(header.Name, "\\", "/"), "./")
+		if name == "" || name == "." {
+			continue
+		}
+		target, err := SafeJoin(root, name)
+		if err != nil {
+			return count, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := prepareTarget(root, target); err != nil {
+				return count, err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return count, fmt.Errorf("创建目录失败: %w", err)
+			}
+
+		case tar.TypeReg:
+			if err := prepareTarget(root, target); err != nil {
+				return count, err
+			}
+			if err := extractTarFile(reader, header, target); err != nil {
+				return count, err
+			}
+			count++
+
+		case tar.TypeSymlink:
+			if filepath.IsAbs(header.Linkname) || strings.Contains(header.Linkname, "\\") {
+				continue
+			}
+			links = append(links, tarLink{target: target, linkname: header.Linkname})
+		}
+	}
+
+	// 链接可能指向其他链接，按顺序多轮创建，直到没有可以创建的为止
+	for len(links) > 0 {
+		var pending []tarLink
+		for _, link := range links {
+			if !linkInside(root, link) {
+				pending = append(pending, link)
+				continue
+			}
+			if err := prepareTarget(root, link.target); err != nil {
+				return count, err
+			}
+			os.Remove(link.target)
+			if err := os.Symlink(link.linkname, link.target); err != nil {
+				return count, fmt.Errorf("创建符号链接失败: %w", err)
+			}
+		}
+		if len(pending) == len(links) {
+			break // 剩下的链接指向目标目录之外或不存在的路径，跳过
+		}
+		links = pending
+	}
+	return count, nil
+}
+
+// tarLink 延后创建的符号链接
+type tarLink struct {
+	target   string
+	linkname string
+}
+
+// linkInside 判断链接在磁盘上实际指向的路径是否存在且位于root内
+//
+// 不能先用filepath.Join清理路径：链接目标中的 ".." 要在解析前面的符号链接之后才能确定含义。
+func linkInside(root string, link tarLink) bool {
+	parent, err := filepath.EvalSymlinks(filepath.Dir(link.target))
+	if err != nil || !within(root, parent) {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(parent + string(filepath.Separator) + filepath.FromSlash(link.linkname))
+	return err == nil && within(root, resolved)
+}
+
+// prepareTarget 创建目标的父目录并确认其实际位置仍在root内；目标本身是符号链接时先删除，不通过它写入
+func prepareTarget(root, target string) error {
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil || !within(root, realDir) {
+		return fmt.Errorf("路径越界: %s", target)
+	}
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return fmt.Errorf("删除符号链接失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// within 判断path是否为root或位于root之内，两者都应是已解析符号链接的绝对路径
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// extractTarFile 解压tar中的单个文件
+func extractTarFile(reader io.Reader, header *tar.Header, target string) error {
+	if header.Size > maxEntrySize {
+		return fmt.Errorf("文件过大: %s", header.Name)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	mode := os.FileMode(header.Mode).Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, io.LimitReader(reader, maxEntrySize)); err != nil {
+		return fmt.Errorf("解压 %s 失败: %w", header.Name, err)
+	}
+	return nil
+}
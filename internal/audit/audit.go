@@ -0,0 +1,356 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source 操作来源
+type Source string
+
+const (
+	SourceCLI       Source = "cli"
+	SourceMenu      Source = "menu"
+	SourceAPI       Source = "api"
+	SourceScheduler Source = "scheduler"
+)
+
+// 操作结果
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// redactedValue 脱敏后的占位值
+const redactedValue = "******"
+
+// sensitiveKeywords 参数名中出现这些关键词时值会被脱敏
+var sensitiveKeywords = []string{
+	"password", "passwd", "pass", "secret", "token", "authorization",
+	"api_key", "apikey", "access_key", "private_key", "credential",
+}
+
+// sensitiveSegments 参数名中以 _、-、. 分隔出的这些片段会被脱敏（整段匹配，避免误伤 author 等字段）
+var sensitiveSegments = []string{"auth"}
+
+// Actor 操作者（谁 + 从哪里发起）
+type Actor struct {
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+}
+
+// Entry 审计日志条目
+type Entry struct {
+	Time   time.Time              `json:"time"`
+	Actor  string                 `json:"actor"`
+	Source Source                 `json:"source"`
+	Action string                 `json:"action"`
+	Target string                 `json:"target,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	Result string                 `json:"result"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// Filter 审计日志查询条件，空字段表示不过滤
+type Filter struct {
+	Actor  string
+	Source Source
+	Action string
+	Target string
+	Result string
+	Since  time.Time
+	Until  time.Time
+	Limit  int // 只返回最近的N条，0表示不限制
+}
+
+// Logger 审计日志记录器（只追加写入JSON Lines文件）
+type Logger struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewLogger 创建新的审计日志记录器
+func NewLogger(path string) *Logger {
+	return &Logger{path: path}
+}
+
+// Path 获取审计日志文件路径
+func (l *Logger) Path() string {
+	return l.path
+}
+
+// Write 追加一条审计日志
+func (l *Logger) Write(entry *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("创建审计日志目录失败: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化审计日志失败: %w", err)
+	}
+
+	// 只追加打开，避免覆盖已有记录
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+
+	return nil
+}
+
+// Query 按条件查询审计日志，结果按时间先后排列
+func (l *Logger) Query(filter *Filter) ([]Entry, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			// 跳过损坏的行，继续读取
+			continue
+		}
+
+		if filter == nil || filter.Match(&entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取审计日志失败: %w", err)
+	}
+
+	if filter != nil && filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+
+	return entries, nil
+}
+
+// matchAction 比较操作名（不区分大小写），以 "*" 结尾时按前缀匹配，如 "instance.*"
+func matchAction(action, pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return len(action) >= len(prefix) && strings.EqualFold(action[:len(prefix)], prefix)
+	}
+	return strings.EqualFold(action, pattern)
+}
+
+// Match 检查条目是否满足过滤条件
+func (f *Filter) Match(entry *Entry) bool {
+	if f.Actor != "" && !strings.EqualFold(entry.Actor, f.Actor) {
+		return false
+	}
+	if f.Source != "" && entry.Source != f.Source {
+		return false
+	}
+	if f.Action != "" && !matchAction(entry.Action, f.Action) {
+		return false
+	}
+	if f.Target != "" && entry.Target != f.Target {
+		return false
+	}
+	if f.Result != "" && entry.Result != f.Result {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// String 返回条目的单行文本表示
+func (e *Entry) String() string {
+	line := fmt.Sprintf("%s | %-9s | %-10s | %-24s | %-20s | %s",
+		e.Time.Format("2006-01-02 15:04:05"), e.Source, e.Actor, e.Action, e.Target, e.Result)
+
+	if len(e.Params) > 0 {
+		if data, err := json.Marshal(e.Params); err == nil {
+			line += " " + string(data)
+		}
+	}
+	if e.Error != "" {
+		line += " 错误: " + e.Error
+	}
+	return line
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *Logger
+	defaultActor  = Actor{Name: currentUserName(), Source: SourceCLI}
+)
+
+// Init 初始化全局审计日志，source为当前进程的默认操作来源
+func Init(path string, source Source) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if path != "" {
+		defaultLogger = NewLogger(path)
+	}
+	defaultActor = Actor{Name: currentUserName(), Source: source}
+}
+
+// Default 获取全局审计日志记录器，未初始化时返回nil
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// DefaultActor 获取当前进程的默认操作者
+func DefaultActor() Actor {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultActor
+}
+
+// SchedulerActor 返回由后台任务发起操作时使用的操作者
+func SchedulerActor(task string) Actor {
+	return Actor{Name: task, Source: SourceScheduler}
+}
+
+// Record 记录一次变更操作，err为nil表示成功
+func Record(actor Actor, action, target string, params map[string]interface{}, err error) {
+	logger := Default()
+	if logger == nil {
+		return
+	}
+
+	if actor.Name == "" {
+		actor = DefaultActor()
+	}
+
+	entry := &Entry{
+		Time:   time.Now(),
+		Actor:  actor.Name,
+		Source: actor.Source,
+		Action: action,
+		Target: target,
+		Params: Redact(params),
+		Result: ResultSuccess,
+	}
+	if err != nil {
+		entry.Result = ResultFailure
+		entry.Error = err.Error()
+	}
+
+	if writeErr := logger.Write(entry); writeErr != nil {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", writeErr)
+	}
+}
+
+// Redact 返回脱敏后的参数副本
+func Redact(params map[string]interface{}) map[string]interface{} {
+	if len(params) == 0 {
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		if isSensitiveKey(key) {
+			result[key] = redactedValue
+			continue
+		}
+		result[key] = redactValue(value)
+	}
+	return result
+}
+
+// redactValue 递归处理嵌套结构
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return Redact(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = redactValue(item)
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+// isSensitiveKey 判断参数名是否为敏感字段
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, keyword := range sensitiveKeywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+	segments := strings.FieldsFunc(key, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+	for _, segment := range segments {
+		for _, sensitive := range sensitiveSegments {
+			if segment == sensitive {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StructParams 将结构体转换为参数映射（使用JSON字段名）
+func StructParams(v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var params map[string]interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil
+	}
+	return params
+}
+
+// currentUserName 获取当前系统用户名
+func currentUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if name := os.Getenv("USERNAME"); name != "" {
+		return name
+	}
+	return "unknown"
+}
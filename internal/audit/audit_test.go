@@ -0,0 +1,48 @@
+package audit
+
+import "testing"
+
+func TestIsSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"password", true},
+		{"Authorization", true},
+		{"auth", true},
+		{"proxy_auth", true},
+		{"auth-token", true},
+		{"http.auth", true},
+		{"author", false},
+		{"authors", false},
+		{"mod_author", false},
+		{"name", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSensitiveKey(tt.key); got != tt.want {
+			t.Errorf("isSensitiveKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestFilterMatchAction(t *testing.T) {
+	entry := &Entry{Action: "instance.Stop"}
+	tests := []struct {
+		action string
+		want   bool
+	}{
+		{"instance.Stop", true},
+		{"INSTANCE.stop", true},
+		{"instance.*", true},
+		{"instance.Start", false},
+		{"frp.*", false},
+	}
+
+	for _, tt := range tests {
+		f := &Filter{Action: tt.action}
+		if got := f.Match(entry); got != tt.want {
+			t.Errorf("Filter{Action: %q}.Match = %v, want %v", tt.action, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+
+	"easilypanel/internal/audit"
 )
 
 // Config 应用配置结构
@@ -24,6 +26,9 @@ type Config struct {
 	// 下载设置
 	Download DownloadConfig `mapstructure:"download"`
 
+	// 内容源设置
+	Content ContentConfig `mapstructure:"content"`
+
 	// 实例设置
 	Instance InstanceConfig `mapstructure:"instance"`
 
@@ -45,21 +50,23 @@ type Config struct {
 
 // AppConfig 应用配置
 type AppConfig struct {
-	DataDir       string `mapstructure:"data_dir"`
-	ConfigDir     string `mapstructure:"config_dir"`
-	Language      string `mapstructure:"language"`
-	AutoBackup    bool   `mapstructure:"auto_backup"`
-	BackupCount   int    `mapstructure:"backup_count"`
-	CheckUpdates  bool   `mapstructure:"check_updates"`
-	FirstRun      bool   `mapstructure:"first_run"`
-	Theme         string `mapstructure:"theme"`
-	MaxInstances  int    `mapstructure:"max_instances"`
+	DataDir             string `mapstructure:"data_dir"`
+	ConfigDir           string `mapstructure:"config_dir"`
+	Language            string `mapstructure:"language"`
+	AutoBackup          bool   `mapstructure:"auto_backup"`
+	BackupCount         int    `mapstructure:"backup_count"`
+	CheckUpdates        bool   `mapstructure:"check_updates"`
+	UpdateCheckInterval string `mapstructure:"update_check_interval"` // 守护进程检查服务端核心更新的间隔
+	FirstRun            bool   `mapstructure:"first_run"`
+	Theme               string `mapstructure:"theme"`
+	MaxInstances        int    `mapstructure:"max_instances"`
 }
 
 // LogConfig 日志配置
 type LogConfig struct {
 	Level      string `mapstructure:"level"`
 	File       string `mapstructure:"file"`
+	AuditFile  string `mapstructure:"audit_file"`
 	MaxSize    int    `mapstructure:"max_size"`
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age"`
@@ -72,6 +79,7 @@ type JavaConfig struct {
 	DefaultPath  string   `mapstructure:"default_path"`
 	SearchPaths  []string `mapstructure:"search_paths"`
 	ExcludePaths []string `mapstructure:"exclude_paths"`
+	RuntimeIndex string   `mapstructure:"runtime_index"` // JDK发行版索引（Adoptium API v3格式），用于 java install
 }
 
 // DownloadConfig 下载配置
@@ -80,15 +88,25 @@ type DownloadConfig struct {
 	Timeout         int               `mapstructure:"timeout"`
 	Retry           int               `mapstructure:"retry"`
 	MaxConcurrent   int               `mapstructure:"max_concurrent"`
-	VerifyChecksum  bool              `mapstructure:"verify_checksum"`
+	RateLimit       string            `mapstructure:"rate_limit"`     // 所有下载共享的速率上限，如 "2MB"，0为不限速
+	JobRateLimit    string            `mapstructure:"job_rate_limit"` // 单个下载任务的速率上限
+	VerifyChecksum  bool              `mapstructure:"verify_checksum"` // 下载源和地址旁都没有校验和时拒绝下载
 	AutoCleanup     bool              `mapstructure:"auto_cleanup"`
 	CleanupDays     int               `mapstructure:"cleanup_days"`
-	Sources         map[string]string `mapstructure:"sources"`
+	Sources         map[string]string `mapstructure:"sources"`      // 下载源地址，镜像下载源删除或留空即禁用
+	MirrorOrder     []string          `mapstructure:"mirror_order"` // 镜像下载源的优先顺序
+	SigningKeys     map[string]string `mapstructure:"signing_keys"` // 下载源 -> base64编码的Ed25519公钥
+}
+
+// ContentConfig 插件/模组内容源配置
+type ContentConfig struct {
+	Sources map[string]string `mapstructure:"sources"` // 内容源 API 地址 (modrinth, hangar)
 }
 
 // InstanceConfig 实例配置
 type InstanceConfig struct {
 	DefaultJavaArgs   []string          `mapstructure:"default_java_args"`
+	MaxMemoryPercent  int               `mapstructure:"max_memory_percent"`
 	DefaultServerArgs []string          `mapstructure:"default_server_args"`
 	DefaultMemory     MemoryConfig      `mapstructure:"default_memory"`
 	AutoEULA          bool              `mapstructure:"auto_eula"`
@@ -254,6 +272,7 @@ func setDefaults() {
 	viper.SetDefault("app.auto_backup", true)
 	viper.SetDefault("app.backup_count", 5)
 	viper.SetDefault("app.check_updates", true)
+	viper.SetDefault("app.update_check_interval", "6h")
 	viper.SetDefault("app.first_run", true)
 	viper.SetDefault("app.theme", "default")
 	viper.SetDefault("app.max_instances", 10)
@@ -261,6 +280,7 @@ func setDefaults() {
 	// 日志默认设置
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.file", "./logs/easilypanel.log")
+	viper.SetDefault("log.audit_file", "./logs/audit.log")
 	viper.SetDefault("log.max_size", 100)
 	viper.SetDefault("log.max_backups", 3)
 	viper.SetDefault("log.max_age", 28)
@@ -270,42 +290,39 @@ func setDefaults() {
 	viper.SetDefault("java.auto_detect", true)
 	viper.SetDefault("java.search_paths", []string{})
 	viper.SetDefault("java.exclude_paths", []string{})
+	viper.SetDefault("java.runtime_index", "https://api.adoptium.net/v3")
 
 	// 下载默认设置
 	viper.SetDefault("download.default_source", "fastmirror")
 	viper.SetDefault("download.timeout", 300)
 	viper.SetDefault("download.retry", 3)
 	viper.SetDefault("download.max_concurrent", 3)
-	viper.SetDefault("download.verify_checksum", true)
+	viper.SetDefault("download.rate_limit", "0")
+	viper.SetDefault("download.job_rate_limit", "0")
+	// Fabric meta和MCSL-Sync不提供校验和，默认不强制要求
+	viper.SetDefault("download.verify_checksum", false)
 	viper.SetDefault("download.auto_cleanup", false)
 	viper.SetDefault("download.cleanup_days", 30)
 	viper.SetDefault("download.sources", map[string]string{
 		"fastmirror": "https://download.fastmirror.net/api/v3",
 		"mcsl":       "https://sync.mcsl.com.cn/api",
+		"papermc":    "https://api.papermc.io/v2",
+		"mojang":     "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json",
+		"fabric":     "https://meta.fabricmc.net/v2",
+		"forge":      "https://maven.minecraftforge.net",
+		"neoforge":   "https://maven.neoforged.net/releases",
 	})
+	viper.SetDefault("download.mirror_order", []string{"fastmirror", "mcsl"})
 
-	// 实例默认设置
-	viper.SetDefault("instance.default_java_args", []string{
-		"-Xmx2G", "-Xms1G",
-		"-XX:+UseG1GC",
-		"-XX:+ParallelRefProcEnabled",
-		"-XX:MaxGCPauseMillis=200",
-		"-XX:+UnlockExperimentalVMOptions",
-		"-XX:+DisableExplicitGC",
-		"-XX:+AlwaysPreTouch",
-		"-XX:G1NewSizePercent=30",
-		"-XX:G1MaxNewSizePercent=40",
-		"-XX:G1HeapRegionSize=8M",
-		"-XX:G1ReservePercent=20",
-		"-XX:G1HeapWastePercent=5",
-		"-XX:G1MixedGCCountTarget=4",
-		"-XX:InitiatingHeapOccupancyPercent=15",
-		"-XX:G1MixedGCLiveThresholdPercent=90",
-		"-XX:G1RSetUpdatingPauseTimePercent=5",
-		"-XX:SurvivorRatio=32",
-		"-XX:+PerfDisableSharedMem",
-		"-XX:MaxTenuringThreshold=1",
+	// 内容源默认设置
+	viper.SetDefault("content.sources", map[string]string{
+		"modrinth": "https://api.modrinth.com/v2",
+		"hangar":   "https://hangar.papermc.io/api/v1",
 	})
+
+	// 实例默认设置
+	viper.SetDefault("instance.default_java_args", []string{}) // 追加在JVM参数预设之后的额外参数
+	viper.SetDefault("instance.max_memory_percent", 80)       // -Xmx占本机内存的上限百分比，0表示不限制
 	viper.SetDefault("instance.default_server_args", []string{"nogui"})
 	viper.SetDefault("instance.default_memory.min", "1G")
 	viper.SetDefault("instance.default_memory.max", "2G")
@@ -430,6 +447,14 @@ func SaveConfig() error {
 	return viper.WriteConfig()
 }
 
+// SetAndSave 设置配置值并立即保存，同时写入审计日志
+func SetAndSave(key string, value interface{}) error {
+	viper.Set(key, value)
+	err := viper.WriteConfig()
+	audit.Record(audit.DefaultActor(), "config.Set", key, map[string]interface{}{key: value}, err)
+	return err
+}
+
 // GetString 获取字符串配置
 func GetString(key string) string {
 	return viper.GetString(key)
@@ -455,6 +480,11 @@ func GetStringMap(key string) map[string]interface{} {
 	return viper.GetStringMap(key)
 }
 
+// GetStringMapString 获取字符串到字符串的映射配置
+func GetStringMapString(key string) map[string]string {
+	return viper.GetStringMapString(key)
+}
+
 // GetDuration 获取时间间隔配置
 func GetDuration(key string) time.Duration {
 	return viper.GetDuration(key)
@@ -535,6 +565,13 @@ func ValidateConfig(config *Config) error {
 		}
 	}
 
+	// 验证更新检查间隔
+	if config.App.UpdateCheckInterval != "" {
+		if _, err := time.ParseDuration(config.App.UpdateCheckInterval); err != nil {
+			return fmt.Errorf("无效的更新检查间隔: %s", config.App.UpdateCheckInterval)
+		}
+	}
+
 	// 验证网络超时
 	if config.Network.Timeout <= 0 {
 		return fmt.Errorf("网络超时必须大于0")
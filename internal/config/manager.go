@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+
+	"easilypanel/internal/audit"
 )
 
 // Manager 配置管理器
@@ -124,6 +126,13 @@ func (m *Manager) ensureDirectories(config *Config) error {
 
 // UpdateConfig 更新配置
 func (m *Manager) UpdateConfig(key string, value interface{}) error {
+	err := m.updateConfig(key, value)
+	audit.Record(audit.DefaultActor(), "config.Set", key, map[string]interface{}{key: value}, err)
+	return err
+}
+
+// updateConfig 设置配置值并保存
+func (m *Manager) updateConfig(key string, value interface{}) error {
 	viper.Set(key, value)
 	
 	// 重新解析配置
@@ -0,0 +1,235 @@
+package content
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// HangarBaseURL Hangar API默认地址
+const HangarBaseURL = "https://hangar.papermc.io/api/v1"
+
+// hangarPlatform Hangar中服务端插件使用的平台
+const hangarPlatform = "PAPER"
+
+// HangarSource Hangar内容源（PaperMC官方插件仓库）
+type HangarSource struct {
+	*apiClient
+}
+
+// hangarProject 项目详情
+type hangarProject struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Namespace struct {
+		Owner string `json:"owner"`
+		Slug  string `json:"slug"`
+	} `json:"namespace"`
+	Description string `json:"description"`
+	Stats       struct {
+		Downloads int64 `json:"downloads"`
+	} `json:"stats"`
+}
+
+// hangarVersion 版本详情
+type hangarVersion struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	Downloads map[string]struct {
+		FileInfo *struct {
+			Name       string `json:"name"`
+			SizeBytes  int64  `json:"sizeBytes"`
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"fileInfo"`
+		ExternalURL string `json:"externalUrl"`
+		DownloadURL string `json:"downloadUrl"`
+	} `json:"downloads"`
+	PluginDependencies map[string][]struct {
+		Name        string `json:"name"`
+		Required    bool   `json:"required"`
+		ExternalURL string `json:"externalUrl"`
+	} `json:"pluginDependencies"`
+	PlatformDependencies map[string][]string `json:"platformDependencies"`
+}
+
+// hangarProjectPage 项目分页响应
+type hangarProjectPage struct {
+	Result []hangarProject `json:"result"`
+}
+
+// hangarVersionPage 版本分页响应
+type hangarVersionPage struct {
+	Result []hangarVersion `json:"result"`
+}
+
+// NewHangarSource 创建Hangar内容源，baseURL为空时使用官方地址
+func NewHangarSource(baseURL string) *HangarSource {
+	if baseURL == "" {
+		baseURL = HangarBaseURL
+	}
+	return &HangarSource{apiClient: newAPIClient(baseURL)}
+}
+
+// Name 内容源名称
+func (s *HangarSource) Name() string {
+	return "hangar"
+}
+
+// Search 搜索项目（Hangar只提供插件）
+func (s *HangarSource) Search(query SearchQuery) ([]Project, error) {
+	if query.Kind == KindMod {
+		return nil, fmt.Errorf("Hangar不提供模组")
+	}
+
+	params := url.Values{}
+	params.Set("q", query.Query)
+	params.Set("platform", hangarPlatform)
+	if query.MCVersion != "" {
+		params.Set("version", query.MCVersion)
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var page hangarProjectPage
+	if err := s.getJSON("/projects?"+params.Encode(), &page); err != nil {
+		return nil, fmt.Errorf("搜索Hangar失败: %w", err)
+	}
+
+	projects := make([]Project, 0, len(page.Result))
+	for i := range page.Result {
+		projects = append(projects, page.Result[i].toProject(s.Name()))
+	}
+	return projects, nil
+}
+
+// GetProject 按ID或slug获取项目
+func (s *HangarSource) GetProject(idOrSlug string) (*Project, error) {
+	var project hangarProject
+	if err := s.getJSON("/projects/"+url.PathEscape(idOrSlug), &project); err != nil {
+		return nil, fmt.Errorf("获取Hangar项目失败: %w", err)
+	}
+
+	result := project.toProject(s.Name())
+	return &result, nil
+}
+
+// ListVersions 列出兼容的版本，loaders仅用于判断实例是否为Paper系服务端
+func (s *HangarSource) ListVersions(projectID, mcVersion string, loaders []string) ([]ProjectVersion, error) {
+	if err := checkHangarLoaders(loaders); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("platform", hangarPlatform)
+	if mcVersion != "" {
+		params.Set("platformVersion", mcVersion)
+	}
+	params.Set("limit", "25")
+
+	var page hangarVersionPage
+	endpoint := "/projects/" + url.PathEscape(projectID) + "/versions?" + params.Encode()
+	if err := s.getJSON(endpoint, &page); err != nil {
+		return nil, fmt.Errorf("获取Hangar版本列表失败: %w", err)
+	}
+
+	var result []ProjectVersion
+	for i := range page.Result {
+		version, ok := page.Result[i].toProjectVersion(projectID)
+		if ok {
+			result = append(result, version)
+		}
+	}
+	sortVersionsByDate(result)
+
+	return result, nil
+}
+
+// GetVersion 获取指定版本（Hangar使用版本名）
+func (s *HangarSource) GetVersion(projectID, version string) (*ProjectVersion, error) {
+	var v hangarVersion
+	endpoint := "/projects/" + url.PathEscape(projectID) + "/versions/" + url.PathEscape(version)
+	if err := s.getJSON(endpoint, &v); err != nil {
+		return nil, fmt.Errorf("获取Hangar版本失败: %w", err)
+	}
+
+	result, ok := v.toProjectVersion(projectID)
+	if !ok {
+		return nil, fmt.Errorf("版本 %s 没有 %s 平台的下载", version, hangarPlatform)
+	}
+	return &result, nil
+}
+
+// checkHangarLoaders 判断实例是否可以使用Hangar插件
+func checkHangarLoaders(loaders []string) error {
+	if len(loaders) == 0 {
+		return nil
+	}
+	for _, loader := range loaders {
+		switch loader {
+		case "paper", "purpur", "folia":
+			return nil
+		}
+	}
+	return fmt.Errorf("Hangar仅支持Paper系服务端")
+}
+
+// toProject 转换为通用项目结构
+func (p *hangarProject) toProject(source string) Project {
+	return Project{
+		ID:        strconv.FormatInt(p.ID, 10),
+		Slug:      p.Namespace.Slug,
+		Name:      p.Name,
+		Summary:   p.Description,
+		Author:    p.Namespace.Owner,
+		Downloads: p.Stats.Downloads,
+		Kind:      KindPlugin,
+		Source:    source,
+	}
+}
+
+// toProjectVersion 转换为通用版本结构，没有PAPER平台下载时返回false
+func (v *hangarVersion) toProjectVersion(projectID string) (ProjectVersion, bool) {
+	download, ok := v.Downloads[hangarPlatform]
+	if !ok {
+		return ProjectVersion{}, false
+	}
+
+	result := ProjectVersion{
+		ID:            strconv.FormatInt(v.ID, 10),
+		ProjectID:     projectID,
+		Name:          v.Name,
+		VersionNumber: v.Name,
+		GameVersions:  v.PlatformDependencies[hangarPlatform],
+		Loaders:       []string{"paper"},
+		Published:     v.CreatedAt,
+	}
+
+	// 外部托管的文件没有fileInfo，无法校验，不提供下载
+	if download.FileInfo != nil && download.DownloadURL != "" {
+		result.Files = append(result.Files, VersionFile{
+			URL:      download.DownloadURL,
+			Filename: download.FileInfo.Name,
+			Size:     download.FileInfo.SizeBytes,
+			Hashes:   map[string]string{"sha256": download.FileInfo.SHA256Hash},
+			Primary:  true,
+		})
+	}
+
+	for _, dep := range v.PluginDependencies[hangarPlatform] {
+		depType := DependencyOptional
+		if dep.Required {
+			depType = DependencyRequired
+		}
+		result.Dependencies = append(result.Dependencies, VersionDependency{
+			ProjectID: dep.Name,
+			Name:      dep.Name,
+			Type:      depType,
+			URL:       dep.ExternalURL,
+		})
+	}
+
+	return result, true
+}
@@ -0,0 +1,405 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"easilypanel/internal/audit"
+	"easilypanel/internal/download"
+	"easilypanel/internal/instance"
+)
+
+// maxDependencyDepth 依赖解析的最大深度，防止循环依赖
+const maxDependencyDepth = 8
+
+// Installer 从内容源安装插件/模组到实例
+type Installer struct {
+	instance *instance.Instance
+	dataDir  string
+	workDir  string
+	kind     string
+	sources  map[string]Source
+	queue    *download.Queue
+	actor    audit.Actor
+}
+
+// plannedInstall 待安装的项目版本
+type plannedInstall struct {
+	source     Source
+	project    *Project
+	version    *ProjectVersion
+	dependency bool
+}
+
+// NewInstaller 创建内容安装器，kind为空时根据实例服务端类型推断
+//
+// 文件通过queue下载（通常是下载管理器的队列，以遵守 download.max_concurrent 和限速设置），由调用方负责关闭。
+func NewInstaller(inst *instance.Instance, dataDir, kind string, queue *download.Queue, sources ...Source) *Installer {
+	if kind == "" {
+		kind = KindForInstance(inst.ServerType)
+	}
+
+	installer := &Installer{
+		instance: inst,
+		dataDir:  dataDir,
+		workDir:  inst.GetWorkDir(dataDir),
+		kind:     kind,
+		sources:  make(map[string]Source),
+		queue:    queue,
+		actor:    audit.DefaultActor(),
+	}
+	for _, source := range sources {
+		installer.sources[source.Name()] = source
+	}
+	return installer
+}
+
+// SetActor 设置审计日志中记录的操作者
+func (in *Installer) SetActor(actor audit.Actor) {
+	in.actor = actor
+}
+
+// Kind 获取安装的内容类型
+func (in *Installer) Kind() string {
+	return in.kind
+}
+
+// Loaders 获取实例可使用的加载器
+func (in *Installer) Loaders() []string {
+	return LoadersForInstance(in.instance.ServerType, in.kind)
+}
+
+// Source 按名称获取内容源
+func (in *Installer) Source(name string) (Source, error) {
+	source, ok := in.sources[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的内容源: %s", name)
+	}
+	return source, nil
+}
+
+// targetDir 获取内容安装目录（相对于工作目录）
+func (in *Installer) targetDir() string {
+	if in.kind == KindMod {
+		return ModsDirName
+	}
+	return PluginsDirName
+}
+
+// Search 在内容源中搜索与实例兼容的项目
+func (in *Installer) Search(sourceName, query string, limit int) ([]Project, error) {
+	source, err := in.Source(sourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return source.Search(SearchQuery{
+		Query:     query,
+		Kind:      in.kind,
+		MCVersion: in.instance.MCVersion,
+		Loaders:   in.Loaders(),
+		Limit:     limit,
+	})
+}
+
+// ResolveVersion 查找项目与实例兼容的版本，versionRef为空时选择最新版本
+func (in *Installer) ResolveVersion(source Source, projectID, versionRef string) (*ProjectVersion, error) {
+	if versionRef != "" {
+		return source.GetVersion(projectID, versionRef)
+	}
+
+	versions, err := source.ListVersions(projectID, in.instance.MCVersion, in.Loaders())
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("没有与 Minecraft %s (%s) 兼容的版本",
+			in.instance.MCVersion, strings.Join(in.Loaders(), "/"))
+	}
+	return &versions[0], nil
+}
+
+// Install 安装项目及其必需依赖，返回实际安装的记录
+func (in *Installer) Install(sourceName, projectRef, versionRef string) ([]InstalledContent, error) {
+	installed, err := in.install(sourceName, projectRef, versionRef)
+
+	var files []string
+	for _, entry := range installed {
+		files = append(files, entry.File)
+	}
+	audit.Record(in.actor, "content.Install", in.instance.Name, map[string]interface{}{
+		"source":  sourceName,
+		"project": projectRef,
+		"version": versionRef,
+		"files":   files,
+	}, err)
+
+	return installed, err
+}
+
+// install 解析依赖、下载并校验文件，任何一步失败都会回滚本次安装的文件
+func (in *Installer) install(sourceName, projectRef, versionRef string) ([]InstalledContent, error) {
+	source, err := in.Source(sourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	provenance, err := LoadProvenance(in.workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := source.GetProject(projectRef)
+	if err != nil {
+		return nil, err
+	}
+	if existing := provenance.Find(source.Name(), project.ID); existing != nil {
+		return nil, fmt.Errorf("%s 已安装 (%s %s)", project.Name, existing.File, existing.VersionNumber)
+	}
+
+	version, err := in.ResolveVersion(source, project.ID, versionRef)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := []plannedInstall{{source: source, project: project, version: version}}
+	if err := in.resolveDependencies(source, version, provenance, &plan, 0); err != nil {
+		return nil, err
+	}
+
+	var installed []InstalledContent
+	for _, item := range plan {
+		entry, err := in.installVersion(item)
+		if err != nil {
+			in.rollback(installed)
+			return nil, fmt.Errorf("安装 %s 失败: %w", item.project.Name, err)
+		}
+		installed = append(installed, *entry)
+	}
+
+	for _, entry := range installed {
+		provenance.Put(entry)
+	}
+	if err := provenance.Save(); err != nil {
+		return installed, err
+	}
+
+	return installed, nil
+}
+
+// resolveDependencies 递归解析必需依赖，已安装或已计划的项目会被跳过
+func (in *Installer) resolveDependencies(source Source, version *ProjectVersion, provenance *Provenance, plan *[]plannedInstall, depth int) error {
+	if depth >= maxDependencyDepth {
+		return fmt.Errorf("依赖层级过深，可能存在循环依赖")
+	}
+
+	for _, dep := range version.Dependencies {
+		if dep.Type != DependencyRequired {
+			continue
+		}
+		if dep.ProjectID == "" && dep.VersionID == "" {
+			continue
+		}
+
+		projectID := dep.ProjectID
+		var depVersion *ProjectVersion
+		if projectID == "" {
+			v, err := source.GetVersion("", dep.VersionID)
+			if err != nil {
+				return fmt.Errorf("解析依赖失败: %w", err)
+			}
+			depVersion, projectID = v, v.ProjectID
+		}
+
+		project, err := source.GetProject(projectID)
+		if err != nil {
+			if dep.URL != "" {
+				return fmt.Errorf("依赖 %s 需要手动安装: %s", dep.Name, dep.URL)
+			}
+			return fmt.Errorf("解析依赖 %s 失败: %w", projectID, err)
+		}
+
+		if in.alreadyAvailable(source, project, provenance, *plan) {
+			continue
+		}
+
+		if depVersion == nil {
+			depVersion, err = in.ResolveVersion(source, project.ID, dep.VersionID)
+			if err != nil {
+				return fmt.Errorf("依赖 %s: %w", project.Name, err)
+			}
+		}
+
+		*plan = append(*plan, plannedInstall{source: source, project: project, version: depVersion, dependency: true})
+		if err := in.resolveDependencies(source, depVersion, provenance, plan, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// alreadyAvailable 判断依赖是否已安装（来源记录、本次计划或手动放入的同名插件/模组）
+func (in *Installer) alreadyAvailable(source Source, project *Project, provenance *Provenance, plan []plannedInstall) bool {
+	if provenance.Find(source.Name(), project.ID) != nil {
+		return true
+	}
+	for _, item := range plan {
+		if item.source.Name() == source.Name() && item.project.ID == project.ID {
+			return true
+		}
+	}
+
+	names := map[string]bool{
+		normalizeName(project.Slug): true,
+		normalizeName(project.Name): true,
+	}
+
+	if in.kind == KindMod {
+		mods, err := NewModManager(in.instance, in.dataDir).List()
+		if err != nil {
+			return false
+		}
+		for _, mod := range mods {
+			if mod.Enabled && names[normalizeName(mod.ID)] {
+				return true
+			}
+		}
+		return false
+	}
+
+	plugins, err := NewPluginManager(in.instance, in.dataDir).List()
+	if err != nil {
+		return false
+	}
+	for _, plugin := range plugins {
+		if plugin.Enabled && names[normalizeName(plugin.Name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// installVersion 下载并校验单个版本的主文件到插件/模组目录
+func (in *Installer) installVersion(item plannedInstall) (*InstalledContent, error) {
+	return in.installVersionTo(item, in.targetDir())
+}
+
+// installVersionTo 下载并校验单个版本的主文件到指定目录（相对于工作目录）
+func (in *Installer) installVersionTo(item plannedInstall, relDir string) (*InstalledContent, error) {
+	file, err := item.version.PrimaryFile()
+	if err != nil {
+		return nil, err
+	}
+
+	filename := filepath.Base(file.Filename)
+	if filename == "." || filename == string(filepath.Separator) || !strings.HasSuffix(strings.ToLower(filename), ".jar") {
+		return nil, fmt.Errorf("无效的文件名: %s", file.Filename)
+	}
+
+	relPath := filepath.Join(relDir, filename)
+	target := filepath.Join(in.workDir, relPath)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("文件已存在: %s", target)
+	}
+
+	sums, err := fileChecksums(file.Hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先下载到临时文件，下载的同时校验，通过后再放入目标目录
+	tempPath := target + ".download"
+	job := in.queue.Submit(download.JobRequest{
+		URLs:      []string{file.URL},
+		Dest:      tempPath,
+		Label:     filename,
+		Source:    item.source.Name(),
+		Priority:  download.PriorityHigh,
+		Retries:   3,
+		Checksums: sums,
+	})
+	if err := job.Wait(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tempPath, target); err != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("移动文件失败: %w", err)
+	}
+
+	return &InstalledContent{
+		Source:        item.source.Name(),
+		ProjectID:     item.project.ID,
+		Slug:          item.project.Slug,
+		Name:          item.project.Name,
+		Kind:          in.kind,
+		VersionID:     item.version.ID,
+		VersionNumber: item.version.VersionNumber,
+		File:          filepath.ToSlash(relPath),
+		URL:           file.URL,
+		Hashes:        file.Hashes,
+		MCVersion:     in.instance.MCVersion,
+		Loaders:       in.Loaders(),
+		Dependency:    item.dependency,
+		InstalledAt:   time.Now(),
+	}, nil
+}
+
+// rollback 删除本次已安装的文件
+func (in *Installer) rollback(installed []InstalledContent) {
+	for _, entry := range installed {
+		os.Remove(filepath.Join(in.workDir, filepath.FromSlash(entry.File)))
+	}
+}
+
+// Installed 列出通过内容源安装的记录
+func (in *Installer) Installed() ([]InstalledContent, error) {
+	provenance, err := LoadProvenance(in.workDir)
+	if err != nil {
+		return nil, err
+	}
+	return provenance.Entries, nil
+}
+
+// PrintProjects 打印搜索结果
+func PrintProjects(projects []Project) {
+	if len(projects) == 0 {
+		fmt.Println("未找到匹配的项目")
+		return
+	}
+
+	fmt.Printf("找到 %d 个项目:\n\n", len(projects))
+	fmt.Println("名称                     | ID/Slug              | 作者             | 下载量")
+	fmt.Println("-------------------------|----------------------|------------------|--------")
+	for _, project := range projects {
+		ref := project.Slug
+		if ref == "" {
+			ref = project.ID
+		}
+		fmt.Printf("%-24s | %-20s | %-16s | %d\n", project.Name, ref, project.Author, project.Downloads)
+		if project.Summary != "" {
+			fmt.Printf("  %s\n", project.Summary)
+		}
+	}
+}
+
+// PrintInstalled 打印通过内容源安装的记录
+func PrintInstalled(entries []InstalledContent) {
+	if len(entries) == 0 {
+		fmt.Println("没有通过内容源安装的插件或模组")
+		return
+	}
+
+	fmt.Printf("共 %d 项:\n\n", len(entries))
+	for _, entry := range entries {
+		dependency := ""
+		if entry.Dependency {
+			dependency = " (依赖)"
+		}
+		fmt.Printf("  %s %s [%s:%s]%s\n", entry.Name, entry.VersionNumber, entry.Source, entry.ProjectID, dependency)
+		fmt.Printf("    文件: %s  安装时间: %s\n", entry.File, entry.InstalledAt.Format("2006-01-02 15:04:05"))
+	}
+}
@@ -0,0 +1,116 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"easilypanel/internal/download"
+	"easilypanel/internal/instance"
+)
+
+// newTestInstaller 创建使用测试内容源的Fabric实例安装器
+func newTestInstaller(t *testing.T, fake *fakeModrinth) (*Installer, string) {
+	t.Helper()
+	workDir := t.TempDir()
+	inst := instance.NewMinecraftInstance("test", "1.20.1", "fabric", "java")
+	inst.WorkDir = workDir
+	queue := download.NewQueue(download.NewDownloader(), 1, "")
+	t.Cleanup(queue.Close)
+	return NewInstaller(inst, t.TempDir(), KindMod, queue, NewModrinthSource(fake.server.URL)), workDir
+}
+
+func TestInstallResolvesRequiredDependencies(t *testing.T) {
+	fake := newFakeModrinth(t)
+	fake.addProject("P-API", "fabric-api", "v-api", []byte("api"))
+	fake.addProject("P-LIB", "cloth", "v-lib", []byte("lib"))
+	fake.addProject("P-OPT", "extras", "v-opt", []byte("opt"))
+	fake.addProject("P-MOD", "sodium", "v-mod", []byte("mod"),
+		[3]string{DependencyRequired, "P-API", ""},
+		[3]string{DependencyRequired, "", "v-lib"}, // 只提供版本ID的依赖
+		[3]string{DependencyOptional, "P-OPT", ""},
+		[3]string{DependencyRequired, "P-API", ""}, // 重复的依赖只安装一次
+	)
+
+	installer, workDir := newTestInstaller(t, fake)
+	installed, err := installer.Install("modrinth", "sodium", "")
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	var files []string
+	for _, entry := range installed {
+		files = append(files, entry.File)
+		if entry.Dependency != (entry.ProjectID != "P-MOD") {
+			t.Errorf("%s: Dependency = %v", entry.Slug, entry.Dependency)
+		}
+	}
+	sort.Strings(files)
+	want := []string{"mods/cloth-1.0.jar", "mods/fabric-api-1.0.jar", "mods/sodium-1.0.jar"}
+	if len(files) != len(want) {
+		t.Fatalf("installed = %v, 期望 %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Fatalf("installed = %v, 期望 %v", files, want)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(workDir, "mods", "extras-1.0.jar")); !os.IsNotExist(err) {
+		t.Error("可选依赖不应被安装")
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, "mods", "sodium-1.0.jar"))
+	if err != nil || string(data) != "mod" {
+		t.Errorf("sodium-1.0.jar = %q, %v", data, err)
+	}
+
+	provenance, err := LoadProvenance(workDir)
+	if err != nil {
+		t.Fatalf("LoadProvenance: %v", err)
+	}
+	if len(provenance.Entries) != 3 || provenance.Find("modrinth", "P-LIB") == nil {
+		t.Errorf("provenance = %+v", provenance.Entries)
+	}
+
+	// 已安装的依赖不再重复安装
+	fake.addProject("P-OTHER", "iris", "v-other", []byte("other"), [3]string{DependencyRequired, "P-API", ""})
+	installed, err = installer.Install("modrinth", "iris", "")
+	if err != nil {
+		t.Fatalf("Install iris: %v", err)
+	}
+	if len(installed) != 1 || installed[0].ProjectID != "P-OTHER" {
+		t.Errorf("installed = %+v, 期望只安装iris", installed)
+	}
+}
+
+func TestInstallRejectsHashMismatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("下载失败会重试，耗时数秒")
+	}
+
+	fake := newFakeModrinth(t)
+	fake.addProject("P-API", "fabric-api", "v-api", []byte("api"))
+	fake.addProject("P-MOD", "sodium", "v-mod", []byte("mod"), [3]string{DependencyRequired, "P-API", ""})
+	fake.corruptFile("fabric-api")
+
+	installer, workDir := newTestInstaller(t, fake)
+	_, err := installer.Install("modrinth", "sodium", "")
+	if err == nil || !strings.Contains(err.Error(), "文件校验失败") {
+		t.Fatalf("哈希不一致时安装应当失败, err = %v", err)
+	}
+
+	// 已下载的sodium被回滚，校验失败的文件和临时文件都不保留
+	entries, _ := os.ReadDir(filepath.Join(workDir, "mods"))
+	for _, entry := range entries {
+		t.Errorf("安装失败后不应留下文件: %s", entry.Name())
+	}
+	provenance, err := LoadProvenance(workDir)
+	if err != nil {
+		t.Fatalf("LoadProvenance: %v", err)
+	}
+	if len(provenance.Entries) != 0 {
+		t.Errorf("安装失败后不应记录来源: %+v", provenance.Entries)
+	}
+}
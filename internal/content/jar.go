@@ -0,0 +1,106 @@
+package content
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxDescriptorSize 描述文件的最大读取大小，防止异常jar占用过多内存
+const maxDescriptorSize = 4 * 1024 * 1024
+
+// readJarEntry 读取jar中指定文件的内容，文件不存在时返回 os.ErrNotExist
+func readJarEntry(reader *zip.Reader, name string) ([]byte, error) {
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("打开 %s 失败: %w", name, err)
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(io.LimitReader(rc, maxDescriptorSize))
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", name, err)
+		}
+		return data, nil
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// listJars 列出目录中的jar文件（不递归），目录不存在时返回空列表
+func listJars(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("读取目录失败: %w", err)
+	}
+
+	var jars []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(entry.Name()), ".jar") {
+			jars = append(jars, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	return jars, nil
+}
+
+// moveFile 移动文件，目标已存在时报错
+func moveFile(src, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("目标文件已存在: %s", dst)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("移动文件失败: %w", err)
+	}
+
+	return nil
+}
+
+// copyFile 复制文件
+func copyFile(src, dst string) error {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("打开源文件失败: %w", err)
+	}
+	defer sourceFile.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	destFile, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("创建目标文件失败: %w", err)
+	}
+	defer destFile.Close()
+
+	if _, err := io.Copy(destFile, sourceFile); err != nil {
+		return fmt.Errorf("复制文件内容失败: %w", err)
+	}
+
+	return destFile.Sync()
+}
+
+// normalizeName 统一名称比较（忽略大小写和空白）
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
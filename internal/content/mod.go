@@ -0,0 +1,896 @@
+package content
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"easilypanel/internal/audit"
+	"easilypanel/internal/instance"
+	"easilypanel/internal/version"
+)
+
+const (
+	// ModsDirName 模组目录名
+	ModsDirName = "mods"
+
+	fabricDescriptor   = "fabric.mod.json"
+	forgeDescriptor    = "META-INF/mods.toml"
+	neoforgeDescriptor = "META-INF/neoforge.mods.toml"
+	jarJarMetadata     = "META-INF/jarjar/metadata.json"
+	manifestFile       = "META-INF/MANIFEST.MF"
+
+	// maxNestedJarSize 内嵌jar的最大读取大小
+	maxNestedJarSize = 64 * 1024 * 1024
+)
+
+// 模组加载器
+const (
+	LoaderFabric   = "fabric"
+	LoaderQuilt    = "quilt"
+	LoaderForge    = "forge"
+	LoaderNeoForge = "neoforge"
+)
+
+// 依赖类型
+const (
+	DependencyRequired     = "required"
+	DependencyOptional     = "optional"
+	DependencyIncompatible = "incompatible"
+)
+
+// builtinModIDs 由加载器或游戏本身提供的模组ID
+var builtinModIDs = map[string]bool{
+	"minecraft":     true,
+	"java":          true,
+	"fabricloader":  true,
+	"fabric-loader": true,
+	"quilt_loader":  true,
+	"forge":         true,
+	"neoforge":      true,
+}
+
+// ModDependency 模组依赖
+type ModDependency struct {
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`                    // required, optional, incompatible
+	VersionRange []string `json:"version_range,omitempty"` // 满足任一即可
+	Side         string   `json:"side,omitempty"`
+	rangeLoader  string
+}
+
+// Matches 判断版本是否满足依赖的版本范围，无法判断时返回错误
+func (d *ModDependency) Matches(ver string) (bool, error) {
+	if d.rangeLoader == LoaderFabric {
+		return version.MatchFabricPredicates(d.VersionRange, ver)
+	}
+
+	for _, spec := range d.VersionRange {
+		ok, err := version.MatchMavenRange(spec, ver)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return len(d.VersionRange) == 0, nil
+}
+
+// ModInfo 模组信息
+type ModInfo struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Version      string          `json:"version"`
+	Loader       string          `json:"loader"`
+	Environment  string          `json:"environment,omitempty"` // client, server, *
+	Dependencies []ModDependency `json:"dependencies,omitempty"`
+	Provides     []string        `json:"provides,omitempty"` // 包括内嵌jar中的模组
+	Descriptor   string          `json:"descriptor,omitempty"`
+	File         string          `json:"file"`
+	Enabled      bool            `json:"enabled"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// MinecraftRange 获取模组要求的Minecraft版本范围
+func (m *ModInfo) MinecraftRange() string {
+	for _, dep := range m.Dependencies {
+		if dep.ID == "minecraft" {
+			return strings.Join(dep.VersionRange, " || ")
+		}
+	}
+	return ""
+}
+
+// fabricModJSON fabric.mod.json 中使用的字段
+type fabricModJSON struct {
+	ID          string                      `json:"id"`
+	Name        string                      `json:"name"`
+	Version     string                      `json:"version"`
+	Environment string                      `json:"environment"`
+	Provides    []string                    `json:"provides"`
+	Depends     map[string]fabricPredicates `json:"depends"`
+	Recommends  map[string]fabricPredicates `json:"recommends"`
+	Breaks      map[string]fabricPredicates `json:"breaks"`
+	Jars        []struct {
+		File string `json:"file"`
+	} `json:"jars"`
+}
+
+// fabricPredicates 版本谓词，可以是字符串或字符串数组
+type fabricPredicates []string
+
+// UnmarshalJSON 兼容字符串和数组两种写法
+func (p *fabricPredicates) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*p = []string{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*p = list
+	return nil
+}
+
+// modsTOML mods.toml / neoforge.mods.toml 中使用的字段
+type modsTOML struct {
+	ModLoader string `toml:"modLoader"`
+	Mods      []struct {
+		ModID       string `toml:"modId"`
+		Version     string `toml:"version"`
+		DisplayName string `toml:"displayName"`
+	} `toml:"mods"`
+	Dependencies map[string][]struct {
+		ModID        string `toml:"modId"`
+		Mandatory    *bool  `toml:"mandatory"` // Forge
+		Type         string `toml:"type"`      // NeoForge
+		VersionRange string `toml:"versionRange"`
+		Side         string `toml:"side"`
+	} `toml:"dependencies"`
+}
+
+// ParseModJar 解析模组jar，loader用于在多加载器jar中选择描述文件（可为空）
+//
+// 一个jar可能包含多个模组（mods.toml中的多个[[mods]]），因此返回列表。
+func ParseModJar(path, loader string) ([]*ModInfo, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开jar失败: %w", err)
+	}
+	defer reader.Close()
+
+	mods, err := parseModZip(&reader.Reader, loader, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mod := range mods {
+		mod.File = path
+	}
+	return mods, nil
+}
+
+// parseModZip 从zip中解析模组，depth用于限制内嵌jar的递归层数
+func parseModZip(reader *zip.Reader, loader string, depth int) ([]*ModInfo, error) {
+	for _, descriptor := range descriptorOrder(loader) {
+		data, err := readJarEntry(reader, descriptor)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var mods []*ModInfo
+		var nested []string
+		if descriptor == fabricDescriptor {
+			var mod *ModInfo
+			mod, nested, err = parseFabricDescriptor(data)
+			if mod != nil {
+				mods = []*ModInfo{mod}
+			}
+		} else {
+			mods, err = parseModsTOML(data, descriptor, reader)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 收集内嵌jar提供的模组ID
+		if depth < 2 {
+			nested = append(nested, jarJarPaths(reader)...)
+			provided := nestedModIDs(reader, nested, loader, depth+1)
+			for _, mod := range mods {
+				mod.Provides = append(mod.Provides, provided...)
+			}
+		}
+
+		return mods, nil
+	}
+
+	return nil, fmt.Errorf("未找到 %s、%s 或 %s", fabricDescriptor, forgeDescriptor, neoforgeDescriptor)
+}
+
+// descriptorOrder 根据加载器决定描述文件的查找顺序
+func descriptorOrder(loader string) []string {
+	switch loader {
+	case LoaderNeoForge:
+		return []string{neoforgeDescriptor, forgeDescriptor, fabricDescriptor}
+	case LoaderForge:
+		return []string{forgeDescriptor, neoforgeDescriptor, fabricDescriptor}
+	default:
+		return []string{fabricDescriptor, neoforgeDescriptor, forgeDescriptor}
+	}
+}
+
+// parseFabricDescriptor 解析fabric.mod.json，返回模组信息和内嵌jar路径
+func parseFabricDescriptor(data []byte) (*ModInfo, []string, error) {
+	var desc fabricModJSON
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return nil, nil, fmt.Errorf("解析 %s 失败: %w", fabricDescriptor, err)
+	}
+	if desc.ID == "" {
+		return nil, nil, fmt.Errorf("%s 缺少id字段", fabricDescriptor)
+	}
+
+	mod := &ModInfo{
+		ID:          desc.ID,
+		Name:        desc.Name,
+		Version:     desc.Version,
+		Loader:      LoaderFabric,
+		Environment: desc.Environment,
+		Provides:    desc.Provides,
+		Descriptor:  fabricDescriptor,
+	}
+	if mod.Name == "" {
+		mod.Name = desc.ID
+	}
+
+	for _, group := range []struct {
+		deps    map[string]fabricPredicates
+		depType string
+	}{
+		{desc.Depends, DependencyRequired},
+		{desc.Recommends, DependencyOptional},
+		{desc.Breaks, DependencyIncompatible},
+	} {
+		for _, id := range sortedKeys(group.deps) {
+			mod.Dependencies = append(mod.Dependencies, ModDependency{
+				ID:           id,
+				Type:         group.depType,
+				VersionRange: group.deps[id],
+				rangeLoader:  LoaderFabric,
+			})
+		}
+	}
+
+	var nested []string
+	for _, jar := range desc.Jars {
+		nested = append(nested, jar.File)
+	}
+
+	return mod, nested, nil
+}
+
+// parseModsTOML 解析mods.toml或neoforge.mods.toml
+func parseModsTOML(data []byte, descriptor string, reader *zip.Reader) ([]*ModInfo, error) {
+	var desc modsTOML
+	if err := toml.Unmarshal(data, &desc); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", descriptor, err)
+	}
+	if len(desc.Mods) == 0 {
+		return nil, fmt.Errorf("%s 中没有声明任何模组", descriptor)
+	}
+
+	var mods []*ModInfo
+	for _, entry := range desc.Mods {
+		if entry.ModID == "" {
+			continue
+		}
+
+		mod := &ModInfo{
+			ID:         entry.ModID,
+			Name:       entry.DisplayName,
+			Version:    entry.Version,
+			Loader:     LoaderForge,
+			Descriptor: descriptor,
+		}
+		if descriptor == neoforgeDescriptor {
+			mod.Loader = LoaderNeoForge
+		}
+		if mod.Name == "" {
+			mod.Name = entry.ModID
+		}
+		// 版本号由构建工具替换为jar的Implementation-Version
+		if strings.Contains(mod.Version, "${file.jarVersion}") {
+			mod.Version = manifestAttribute(reader, "Implementation-Version")
+		}
+
+		for _, dep := range desc.Dependencies[entry.ModID] {
+			depType := DependencyOptional
+			switch strings.ToLower(dep.Type) {
+			case "required":
+				depType = DependencyRequired
+			case "incompatible":
+				depType = DependencyIncompatible
+			case "":
+				if dep.Mandatory != nil && *dep.Mandatory {
+					depType = DependencyRequired
+				}
+			}
+
+			// 早期NeoForge仍使用mods.toml，通过依赖识别
+			if dep.ModID == "neoforge" {
+				mod.Loader = LoaderNeoForge
+			}
+
+			modDep := ModDependency{
+				ID:          dep.ModID,
+				Type:        depType,
+				Side:        dep.Side,
+				rangeLoader: LoaderForge,
+			}
+			if dep.VersionRange != "" {
+				modDep.VersionRange = []string{dep.VersionRange}
+			}
+			mod.Dependencies = append(mod.Dependencies, modDep)
+		}
+
+		mods = append(mods, mod)
+	}
+
+	if len(mods) == 0 {
+		return nil, fmt.Errorf("%s 中没有有效的模组", descriptor)
+	}
+	return mods, nil
+}
+
+// jarJarPaths 读取Forge/NeoForge Jar-in-Jar元数据中的内嵌jar路径
+func jarJarPaths(reader *zip.Reader) []string {
+	data, err := readJarEntry(reader, jarJarMetadata)
+	if err != nil {
+		return nil
+	}
+
+	var metadata struct {
+		Jars []struct {
+			Path string `json:"path"`
+		} `json:"jars"`
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, jar := range metadata.Jars {
+		paths = append(paths, jar.Path)
+	}
+	return paths
+}
+
+// nestedModIDs 解析内嵌jar，返回其提供的模组ID
+func nestedModIDs(reader *zip.Reader, paths []string, loader string, depth int) []string {
+	var ids []string
+	for _, path := range paths {
+		for _, file := range reader.File {
+			if file.Name != path {
+				continue
+			}
+
+			rc, err := file.Open()
+			if err != nil {
+				break
+			}
+			data, err := io.ReadAll(io.LimitReader(rc, maxNestedJarSize))
+			rc.Close()
+			if err != nil {
+				break
+			}
+
+			nested, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				break
+			}
+			mods, err := parseModZip(nested, loader, depth)
+			if err != nil {
+				break
+			}
+			for _, mod := range mods {
+				ids = append(ids, mod.ID)
+				ids = append(ids, mod.Provides...)
+			}
+			break
+		}
+	}
+	return ids
+}
+
+// manifestAttribute 读取MANIFEST.MF中的属性
+func manifestAttribute(reader *zip.Reader, key string) string {
+	data, err := readJarEntry(reader, manifestFile)
+	if err != nil {
+		return ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, key+":") {
+			return strings.TrimSpace(strings.TrimPrefix(line, key+":"))
+		}
+	}
+	return ""
+}
+
+// sortedKeys 返回排序后的键
+func sortedKeys(m map[string]fabricPredicates) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LoaderForServerType 根据服务端类型推断模组加载器，非模组服务端返回空字符串
+func LoaderForServerType(serverType string) string {
+	serverType = normalizeName(serverType)
+	switch {
+	case strings.Contains(serverType, "neoforge"):
+		return LoaderNeoForge
+	case strings.Contains(serverType, "quilt"):
+		return LoaderQuilt
+	case strings.Contains(serverType, "fabric"):
+		return LoaderFabric
+	case strings.Contains(serverType, "forge"),
+		strings.Contains(serverType, "mohist"),
+		strings.Contains(serverType, "arclight"),
+		strings.Contains(serverType, "catserver"):
+		return LoaderForge
+	default:
+		return ""
+	}
+}
+
+// ModManager 实例模组管理器（Fabric/Forge/NeoForge等）
+type ModManager struct {
+	instance *instance.Instance
+	workDir  string
+	loader   string
+	actor    audit.Actor
+}
+
+// NewModManager 创建模组管理器
+func NewModManager(inst *instance.Instance, dataDir string) *ModManager {
+	return &ModManager{
+		instance: inst,
+		workDir:  inst.GetWorkDir(dataDir),
+		loader:   LoaderForServerType(inst.ServerType),
+		actor:    audit.DefaultActor(),
+	}
+}
+
+// SetActor 设置审计日志中记录的操作者
+func (mm *ModManager) SetActor(actor audit.Actor) {
+	mm.actor = actor
+}
+
+// Loader 获取实例使用的模组加载器
+func (mm *ModManager) Loader() string {
+	return mm.loader
+}
+
+// ModsDir 获取模组目录
+func (mm *ModManager) ModsDir() string {
+	return filepath.Join(mm.workDir, ModsDirName)
+}
+
+// DisabledDir 获取已禁用模组目录
+func (mm *ModManager) DisabledDir() string {
+	return filepath.Join(mm.ModsDir(), DisabledDirName)
+}
+
+// List 列出所有模组（包括已禁用的），解析失败的jar也会列出并带有错误信息
+func (mm *ModManager) List() ([]*ModInfo, error) {
+	var mods []*ModInfo
+
+	for _, dir := range []struct {
+		path    string
+		enabled bool
+	}{
+		{mm.ModsDir(), true},
+		{mm.DisabledDir(), false},
+	} {
+		jars, err := listJars(dir.path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, jar := range jars {
+			parsed, err := ParseModJar(jar, mm.loader)
+			if err != nil {
+				parsed = []*ModInfo{{
+					ID:    strings.TrimSuffix(filepath.Base(jar), filepath.Ext(jar)),
+					File:  jar,
+					Error: err.Error(),
+				}}
+			}
+			for _, mod := range parsed {
+				mod.Enabled = dir.enabled
+				mods = append(mods, mod)
+			}
+		}
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		return normalizeName(mods[i].ID) < normalizeName(mods[j].ID)
+	})
+
+	return mods, nil
+}
+
+// Find 按模组ID或文件名查找模组所在的jar
+func (mm *ModManager) Find(name string) (*ModInfo, error) {
+	mods, err := mm.List()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string]*ModInfo)
+	for _, mod := range mods {
+		if normalizeName(mod.ID) == normalizeName(name) ||
+			normalizeName(filepath.Base(mod.File)) == normalizeName(name) {
+			matches[mod.File] = mod
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("未找到模组: %s", name)
+	case 1:
+		for _, mod := range matches {
+			return mod, nil
+		}
+	}
+	return nil, fmt.Errorf("找到多个ID为 %s 的模组，请使用文件名指定", name)
+}
+
+// Add 安装模组jar到模组目录
+func (mm *ModManager) Add(jarPath string) ([]*ModInfo, error) {
+	mods, err := mm.add(jarPath)
+	audit.Record(mm.actor, "mods.Add", mm.instance.Name, map[string]interface{}{
+		"file": filepath.Base(jarPath),
+	}, err)
+	return mods, err
+}
+
+// add 校验并复制模组jar
+func (mm *ModManager) add(jarPath string) ([]*ModInfo, error) {
+	mods, err := ParseModJar(jarPath, mm.loader)
+	if err != nil {
+		return nil, fmt.Errorf("无效的模组: %w", err)
+	}
+
+	// 同ID模组已启用时拒绝安装，避免重复加载
+	for _, mod := range mods {
+		if existing, err := mm.Find(mod.ID); err == nil && existing.Enabled {
+			return nil, fmt.Errorf("模组 %s 已安装 (%s)，请先移除旧版本", mod.ID, filepath.Base(existing.File))
+		}
+	}
+
+	target := filepath.Join(mm.ModsDir(), filepath.Base(jarPath))
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("文件已存在: %s", target)
+	}
+
+	if err := copyFile(jarPath, target); err != nil {
+		return nil, err
+	}
+
+	for _, mod := range mods {
+		mod.File = target
+		mod.Enabled = true
+	}
+	return mods, nil
+}
+
+// Remove 删除模组文件
+func (mm *ModManager) Remove(name string) error {
+	file, err := mm.remove(name)
+	audit.Record(mm.actor, "mods.Remove", mm.instance.Name, map[string]interface{}{
+		"mod":  name,
+		"file": file,
+	}, err)
+	return err
+}
+
+// remove 删除模组文件，返回被删除的文件名
+func (mm *ModManager) remove(name string) (string, error) {
+	mod, err := mm.Find(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Remove(mod.File); err != nil {
+		return "", fmt.Errorf("删除模组失败: %w", err)
+	}
+	forgetFile(mm.workDir, mod.File)
+
+	return filepath.Base(mod.File), nil
+}
+
+// Enable 启用模组（从 mods/.disabled 移回模组目录）
+func (mm *ModManager) Enable(name string) error {
+	err := mm.setEnabled(name, true)
+	audit.Record(mm.actor, "mods.Enable", mm.instance.Name, map[string]interface{}{
+		"mod": name,
+	}, err)
+	return err
+}
+
+// Disable 禁用模组（移动到 mods/.disabled，可随时恢复）
+func (mm *ModManager) Disable(name string) error {
+	err := mm.setEnabled(name, false)
+	audit.Record(mm.actor, "mods.Disable", mm.instance.Name, map[string]interface{}{
+		"mod": name,
+	}, err)
+	return err
+}
+
+// setEnabled 在模组目录和禁用目录之间移动模组
+func (mm *ModManager) setEnabled(name string, enabled bool) error {
+	mod, err := mm.Find(name)
+	if err != nil {
+		return err
+	}
+
+	if mod.Enabled == enabled {
+		if enabled {
+			return fmt.Errorf("模组 %s 已处于启用状态", mod.ID)
+		}
+		return fmt.Errorf("模组 %s 已处于禁用状态", mod.ID)
+	}
+
+	targetDir := mm.DisabledDir()
+	if enabled {
+		targetDir = mm.ModsDir()
+	}
+
+	target := filepath.Join(targetDir, filepath.Base(mod.File))
+	if err := moveFile(mod.File, target); err != nil {
+		return err
+	}
+
+	trackMove(mm.workDir, mod.File, target)
+	return nil
+}
+
+// Check 检查已启用模组的问题：加载器或MC版本不匹配、缺失必需依赖、不兼容模组、重复模组
+func (mm *ModManager) Check(mods []*ModInfo) []Problem {
+	var problems []Problem
+
+	// 收集已启用模组提供的ID和版本
+	available := make(map[string]string)
+	byID := make(map[string][]*ModInfo)
+	for _, mod := range mods {
+		if !mod.Enabled || mod.Error != "" {
+			continue
+		}
+		key := normalizeName(mod.ID)
+		available[key] = mod.Version
+		byID[key] = append(byID[key], mod)
+		for _, alias := range mod.Provides {
+			if _, ok := available[normalizeName(alias)]; !ok {
+				available[normalizeName(alias)] = ""
+			}
+		}
+	}
+
+	// 重复模组（同一jar中的多个模组不算重复）
+	var ids []string
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		files := make(map[string]bool)
+		var names []string
+		for _, mod := range byID[id] {
+			if !files[mod.File] {
+				files[mod.File] = true
+				names = append(names, fmt.Sprintf("%s (%s)", filepath.Base(mod.File), mod.Version))
+			}
+		}
+		if len(names) > 1 {
+			problems = append(problems, Problem{
+				Severity: SeverityError,
+				Subject:  byID[id][0].ID,
+				Message:  fmt.Sprintf("检测到重复模组: %s", strings.Join(names, ", ")),
+			})
+		}
+	}
+
+	for _, mod := range mods {
+		if !mod.Enabled {
+			continue
+		}
+
+		if mod.Error != "" {
+			problems = append(problems, Problem{
+				Severity: SeverityWarning,
+				Subject:  filepath.Base(mod.File),
+				Message:  fmt.Sprintf("无法解析模组: %s", mod.Error),
+			})
+			continue
+		}
+
+		problems = append(problems, mm.checkLoader(mod)...)
+
+		if mod.Environment == "client" {
+			problems = append(problems, Problem{
+				Severity: SeverityWarning,
+				Subject:  mod.ID,
+				Message:  "仅客户端模组，服务端无需安装",
+			})
+		}
+
+		var missing []string
+		for i := range mod.Dependencies {
+			dep := &mod.Dependencies[i]
+			key := normalizeName(dep.ID)
+
+			if dep.ID == "minecraft" {
+				problems = append(problems, mm.checkMinecraft(mod, dep)...)
+				continue
+			}
+			if builtinModIDs[key] {
+				continue
+			}
+			// Forge/NeoForge中side为CLIENT的依赖只在客户端需要
+			if strings.EqualFold(dep.Side, "CLIENT") {
+				continue
+			}
+
+			installedVersion, installed := available[key]
+			switch dep.Type {
+			case DependencyRequired:
+				if !installed {
+					missing = append(missing, dep.ID)
+					continue
+				}
+				if installedVersion == "" {
+					continue
+				}
+				if ok, err := dep.Matches(installedVersion); err == nil && !ok {
+					problems = append(problems, Problem{
+						Severity: SeverityWarning,
+						Subject:  mod.ID,
+						Message: fmt.Sprintf("依赖 %s 版本不满足: 需要 %s，已安装 %s",
+							dep.ID, strings.Join(dep.VersionRange, " || "), installedVersion),
+					})
+				}
+			case DependencyIncompatible:
+				if installed {
+					problems = append(problems, Problem{
+						Severity: SeverityError,
+						Subject:  mod.ID,
+						Message:  fmt.Sprintf("与已安装的模组 %s 不兼容", dep.ID),
+					})
+				}
+			}
+		}
+
+		if len(missing) > 0 {
+			problems = append(problems, Problem{
+				Severity: SeverityError,
+				Subject:  mod.ID,
+				Message:  fmt.Sprintf("缺少必需的依赖: %s", strings.Join(missing, ", ")),
+			})
+		}
+	}
+
+	return problems
+}
+
+// checkLoader 检查模组加载器是否与实例服务端匹配
+func (mm *ModManager) checkLoader(mod *ModInfo) []Problem {
+	if mm.loader == "" || mod.Loader == mm.loader {
+		return nil
+	}
+
+	// Quilt可以加载Fabric模组
+	if mm.loader == LoaderQuilt && mod.Loader == LoaderFabric {
+		return nil
+	}
+
+	// NeoForge 1.20.1 兼容Forge模组
+	if mm.loader == LoaderNeoForge && mod.Loader == LoaderForge {
+		return []Problem{{
+			Severity: SeverityWarning,
+			Subject:  mod.ID,
+			Message:  "Forge模组在NeoForge上可能无法加载",
+		}}
+	}
+
+	return []Problem{{
+		Severity: SeverityError,
+		Subject:  mod.ID,
+		Message:  fmt.Sprintf("模组加载器不匹配: 模组为 %s，实例为 %s", mod.Loader, mm.loader),
+	}}
+}
+
+// checkMinecraft 检查模组要求的Minecraft版本
+func (mm *ModManager) checkMinecraft(mod *ModInfo, dep *ModDependency) []Problem {
+	if mm.instance.MCVersion == "" || dep.Type != DependencyRequired {
+		return nil
+	}
+
+	ok, err := dep.Matches(mm.instance.MCVersion)
+	if err != nil || ok {
+		// 无法解析的版本（如快照）不做判断
+		return nil
+	}
+
+	return []Problem{{
+		Severity: SeverityError,
+		Subject:  mod.ID,
+		Message: fmt.Sprintf("Minecraft版本不匹配: 需要 %s，实例为 %s",
+			strings.Join(dep.VersionRange, " || "), mm.instance.MCVersion),
+	}}
+}
+
+// PrintModList 打印模组列表及检查结果
+func PrintModList(mods []*ModInfo, problems []Problem) {
+	if len(mods) == 0 {
+		fmt.Println("未找到任何模组")
+		return
+	}
+
+	fmt.Printf("找到 %d 个模组:\n\n", len(mods))
+	fmt.Println("状态 | 模组ID               | 版本         | 加载器   | Minecraft")
+	fmt.Println("-----|----------------------|--------------|----------|----------")
+
+	for _, mod := range mods {
+		status := "✓"
+		if !mod.Enabled {
+			status = "✗"
+		}
+		if mod.Error != "" {
+			fmt.Printf("%-4s | %-20s | %-12s | %-8s | 解析失败: %s\n", status, mod.ID, "-", "-", mod.Error)
+			continue
+		}
+
+		mcRange := mod.MinecraftRange()
+		if mcRange == "" {
+			mcRange = "-"
+		}
+		fmt.Printf("%-4s | %-20s | %-12s | %-8s | %s\n", status, mod.ID, mod.Version, mod.Loader, mcRange)
+
+		var required []string
+		for _, dep := range mod.Dependencies {
+			if dep.Type == DependencyRequired && !builtinModIDs[normalizeName(dep.ID)] {
+				required = append(required, dep.ID)
+			}
+		}
+		if len(required) > 0 {
+			fmt.Printf("     |   依赖: %s\n", strings.Join(required, ", "))
+		}
+	}
+
+	fmt.Println("\n✓ = 已启用, ✗ = 已禁用")
+	printProblems(problems)
+}
@@ -0,0 +1,35 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"easilypanel/internal/instance"
+)
+
+func TestCheckSkipsClientSideDependencies(t *testing.T) {
+	inst := instance.NewMinecraftInstance("test", "1.20.1", "forge", "java")
+	mm := NewModManager(inst, t.TempDir())
+
+	mods := []*ModInfo{{
+		ID:      "create",
+		Version: "0.5.1",
+		Loader:  LoaderForge,
+		File:    "mods/create.jar",
+		Enabled: true,
+		Dependencies: []ModDependency{
+			{ID: "jei", Type: DependencyRequired, Side: "CLIENT"},
+			{ID: "flywheel", Type: DependencyRequired, Side: "BOTH"},
+		},
+	}}
+
+	var missing []string
+	for _, problem := range mm.Check(mods) {
+		if strings.Contains(problem.Message, "缺少必需的依赖") {
+			missing = append(missing, problem.Message)
+		}
+	}
+	if len(missing) != 1 || strings.Contains(missing[0], "jei") || !strings.Contains(missing[0], "flywheel") {
+		t.Errorf("缺少依赖 = %v, 期望只报告flywheel", missing)
+	}
+}
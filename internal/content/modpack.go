@@ -0,0 +1,545 @@
+package content
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"easilypanel/internal/archive"
+	"easilypanel/internal/audit"
+	"easilypanel/internal/download"
+	"easilypanel/internal/instance"
+)
+
+// 整合包格式
+const (
+	PackFormatModrinth   = "mrpack"
+	PackFormatCurseForge = "curseforge"
+)
+
+const (
+	mrpackIndex        = "modrinth.index.json"
+	curseForgeManifest = "manifest.json"
+	serverPackVars     = "variables.txt" // ServerPackCreator生成的服务端包
+)
+
+// modrinthCDNPattern 从Modrinth CDN地址中提取项目ID和版本ID
+var modrinthCDNPattern = regexp.MustCompile(`^https://cdn\.modrinth\.com/data/([^/]+)/versions/([^/]+)/`)
+
+// PackInfo 整合包信息
+type PackInfo struct {
+	Format        string `json:"format"`
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	MCVersion     string `json:"mc_version"`
+	Loader        string `json:"loader"` // fabric, quilt, forge, neoforge
+	LoaderVersion string `json:"loader_version"`
+	ServerJar     string `json:"server_jar,omitempty"` // 服务端包中已包含的启动jar
+	FileCount     int    `json:"file_count"`           // 需要下载的文件数
+}
+
+// mrpackIndexJSON modrinth.index.json
+type mrpackIndexJSON struct {
+	FormatVersion int    `json:"formatVersion"`
+	Game          string `json:"game"`
+	VersionID     string `json:"versionId"`
+	Name          string `json:"name"`
+	Files         []struct {
+		Path   string            `json:"path"`
+		Hashes map[string]string `json:"hashes"`
+		Env    *struct {
+			Server string `json:"server"`
+		} `json:"env"`
+		Downloads []string `json:"downloads"`
+		FileSize  int64    `json:"fileSize"`
+	} `json:"files"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// curseForgeManifestJSON CurseForge整合包的manifest.json
+type curseForgeManifestJSON struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Minecraft struct {
+		Version    string `json:"version"`
+		ModLoaders []struct {
+			ID      string `json:"id"`
+			Primary bool   `json:"primary"`
+		} `json:"modLoaders"`
+	} `json:"minecraft"`
+	Files []struct {
+		ProjectID int  `json:"projectID"`
+		FileID    int  `json:"fileID"`
+		Required  bool `json:"required"`
+	} `json:"files"`
+	Overrides string `json:"overrides"`
+}
+
+// ImportOptions 整合包导入选项
+type ImportOptions struct {
+	Name         string                                // 实例名称，为空时使用整合包名称
+	JavaResolver func(mcVersion string) string         // 根据MC版本选择Java路径
+	Progress     func(current, total int, file string) // 下载进度
+}
+
+// PackImporter 整合包导入器
+type PackImporter struct {
+	manager *instance.Manager
+	dataDir string
+	dm      *download.DownloadManager
+	actor   audit.Actor
+}
+
+// NewPackImporter 创建整合包导入器
+//
+// 文件通过下载管理器的队列下载（遵守 download.max_concurrent 和限速设置），
+// 整合包不含服务端核心时通过它安装对应的加载器；队列由调用方负责关闭。
+func NewPackImporter(manager *instance.Manager, dataDir string, dm *download.DownloadManager) *PackImporter {
+	return &PackImporter{
+		manager: manager,
+		dataDir: dataDir,
+		dm:      dm,
+		actor:   audit.DefaultActor(),
+	}
+}
+
+// SetActor 设置审计日志中记录的操作者
+func (pi *PackImporter) SetActor(actor audit.Actor) {
+	pi.actor = actor
+	pi.manager.SetActor(actor)
+}
+
+// Inspect 读取整合包信息
+func Inspect(packPath string) (*PackInfo, error) {
+	reader, err := zip.OpenReader(packPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开整合包失败: %w", err)
+	}
+	defer reader.Close()
+
+	info, _, err := inspectZip(&reader.Reader)
+	return info, err
+}
+
+// inspectZip 识别整合包格式，返回信息和服务端包的根目录
+func inspectZip(reader *zip.Reader) (*PackInfo, string, error) {
+	if data, err := readJarEntry(reader, mrpackIndex); err == nil {
+		info, _, err := parseMrpackIndex(data)
+		return info, "", err
+	}
+
+	root := archive.CommonRoot(reader)
+	if data, err := readJarEntry(reader, root+curseForgeManifest); err == nil {
+		var manifest curseForgeManifestJSON
+		if err := json.Unmarshal(data, &manifest); err == nil && manifest.Minecraft.Version != "" {
+			info := &PackInfo{
+				Format:    PackFormatCurseForge,
+				Name:      manifest.Name,
+				Version:   manifest.Version,
+				MCVersion: manifest.Minecraft.Version,
+				FileCount: len(manifest.Files),
+			}
+			for _, loader := range manifest.Minecraft.ModLoaders {
+				if loader.Primary || info.Loader == "" {
+					info.Loader, info.LoaderVersion = splitLoaderID(loader.ID)
+				}
+			}
+			info.ServerJar = findServerJar(reader, root)
+			return info, root, nil
+		}
+	}
+
+	// 不含manifest的CurseForge服务端包，根据文件特征推断
+	info := &PackInfo{Format: PackFormatCurseForge}
+	detectServerPack(reader, root, info)
+	if info.Loader == "" && info.MCVersion == "" {
+		return nil, "", fmt.Errorf("无法识别的整合包格式（需要 %s 或CurseForge服务端包）", mrpackIndex)
+	}
+	info.ServerJar = findServerJar(reader, root)
+	return info, root, nil
+}
+
+// parseMrpackIndex 解析modrinth.index.json
+func parseMrpackIndex(data []byte) (*PackInfo, *mrpackIndexJSON, error) {
+	var index mrpackIndexJSON
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, nil, fmt.Errorf("解析 %s 失败: %w", mrpackIndex, err)
+	}
+	if index.Game != "" && index.Game != "minecraft" {
+		return nil, nil, fmt.Errorf("不支持的游戏: %s", index.Game)
+	}
+
+	info := &PackInfo{
+		Format:    PackFormatModrinth,
+		Name:      index.Name,
+		Version:   index.VersionID,
+		MCVersion: index.Dependencies["minecraft"],
+	}
+	for key, loader := range map[string]string{
+		"fabric-loader": LoaderFabric,
+		"quilt-loader":  LoaderQuilt,
+		"forge":         LoaderForge,
+		"neoforge":      LoaderNeoForge,
+	} {
+		if v, ok := index.Dependencies[key]; ok {
+			info.Loader, info.LoaderVersion = loader, v
+		}
+	}
+	if info.MCVersion == "" {
+		return nil, nil, fmt.Errorf("%s 中缺少Minecraft版本", mrpackIndex)
+	}
+
+	for _, file := range index.Files {
+		if file.Env == nil || file.Env.Server != "unsupported" {
+			info.FileCount++
+		}
+	}
+
+	return info, &index, nil
+}
+
+// splitLoaderID 拆分CurseForge的加载器ID，如 "forge-47.2.0" -> ("forge", "47.2.0")
+func splitLoaderID(id string) (string, string) {
+	name, ver, _ := strings.Cut(id, "-")
+	return strings.ToLower(name), ver
+}
+
+// forgeLibraryPattern 从libraries目录推断Forge/NeoForge版本
+var forgeLibraryPattern = regexp.MustCompile(`libraries/net/(minecraftforge/forge|neoforged/neoforge|neoforged/forge)/([^/]+)/`)
+
+// detectServerPack 根据服务端包中的文件推断MC版本和加载器
+func detectServerPack(reader *zip.Reader, root string, info *PackInfo) {
+	// ServerPackCreator的variables.txt
+	if data, err := readJarEntry(reader, root+serverPackVars); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(value, `"' `)
+			switch strings.ToUpper(key) {
+			case "MINECRAFT_VERSION":
+				info.MCVersion = value
+			case "MODLOADER":
+				info.Loader = strings.ToLower(value)
+			case "MODLOADER_VERSION":
+				info.LoaderVersion = value
+			}
+		}
+		if info.MCVersion != "" && info.Loader != "" {
+			return
+		}
+	}
+
+	for _, file := range reader.File {
+		name := strings.TrimPrefix(file.Name, root)
+
+		if m := forgeLibraryPattern.FindStringSubmatch(name); m != nil {
+			if strings.Contains(m[1], "neoforge") {
+				info.Loader = LoaderNeoForge
+				info.LoaderVersion = m[2]
+			} else {
+				// Forge的版本目录为 "1.20.1-47.2.0"
+				info.Loader = LoaderForge
+				if mc, ver, ok := strings.Cut(m[2], "-"); ok {
+					info.MCVersion, info.LoaderVersion = mc, ver
+				}
+			}
+			continue
+		}
+
+		base := path.Base(name)
+		switch {
+		case base == "fabric-server-launch.jar" || strings.HasPrefix(name, ".fabric/"):
+			info.Loader = LoaderFabric
+		case strings.HasPrefix(base, "forge-") && strings.HasSuffix(base, ".jar") && info.MCVersion == "":
+			// forge-1.20.1-47.2.0.jar / forge-1.12.2-14.23.5.2860-universal.jar
+			parts := strings.Split(strings.TrimSuffix(base, ".jar"), "-")
+			if len(parts) >= 3 {
+				info.Loader = LoaderForge
+				info.MCVersion, info.LoaderVersion = parts[1], parts[2]
+			}
+		case strings.HasPrefix(name, "versions/") && strings.HasSuffix(base, ".jar") && info.MCVersion == "":
+			// versions/1.20.1/server-1.20.1.jar（新版Fabric服务端启动器）
+			info.MCVersion = path.Base(path.Dir(name))
+		}
+	}
+}
+
+// findServerJar 在服务端包中查找可直接启动的jar
+func findServerJar(reader *zip.Reader, root string) string {
+	candidates := []string{"fabric-server-launch.jar", "quilt-server-launch.jar"}
+	var forgeJar string
+
+	for _, file := range reader.File {
+		name := strings.TrimPrefix(file.Name, root)
+		if strings.Contains(name, "/") {
+			continue
+		}
+		for _, candidate := range candidates {
+			if name == candidate {
+				return name
+			}
+		}
+		if strings.HasPrefix(name, "forge-") && strings.HasSuffix(name, ".jar") && !strings.Contains(name, "installer") {
+			forgeJar = name
+		}
+	}
+
+	return forgeJar
+}
+
+// Import 导入整合包并创建新实例
+func (pi *PackImporter) Import(packPath string, opts ImportOptions) (*instance.Instance, *PackInfo, error) {
+	inst, info, err := pi.importPack(packPath, opts)
+
+	params := map[string]interface{}{
+		"file": filepath.Base(packPath),
+	}
+	if info != nil {
+		params["format"] = info.Format
+		params["mc_version"] = info.MCVersion
+		params["loader"] = info.Loader
+	}
+	target := opts.Name
+	if inst != nil {
+		target = inst.Name
+	}
+	audit.Record(pi.actor, "content.ImportPack", target, params, err)
+
+	return inst, info, err
+}
+
+// importPack 导入整合包，失败时删除已创建的实例
+func (pi *PackImporter) importPack(packPath string, opts ImportOptions) (*instance.Instance, *PackInfo, error) {
+	reader, err := zip.OpenReader(packPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开整合包失败: %w", err)
+	}
+	defer reader.Close()
+
+	info, root, err := inspectZip(&reader.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.Format == PackFormatCurseForge && info.FileCount > 0 {
+		return nil, info, fmt.Errorf("这是CurseForge客户端整合包（%d 个文件需要通过CurseForge API下载），请下载该整合包的服务端包后再导入", info.FileCount)
+	}
+	if info.MCVersion == "" {
+		return nil, info, fmt.Errorf("无法确定整合包的Minecraft版本")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = instanceNameFromPack(info.Name, packPath)
+	}
+
+	javaPath := "java"
+	if opts.JavaResolver != nil {
+		if resolved := opts.JavaResolver(info.MCVersion); resolved != "" {
+			javaPath = resolved
+		}
+	}
+
+	serverType := info.Loader
+	if serverType == "" {
+		serverType = "vanilla"
+	}
+
+	inst, err := pi.manager.CreateMinecraftInstance(name, info.MCVersion, serverType, javaPath)
+	if err != nil {
+		return nil, info, err
+	}
+
+	if err := pi.populate(&reader.Reader, root, info, inst, opts); err != nil {
+		pi.manager.DeleteInstance(inst.Name, true)
+		return nil, info, err
+	}
+
+	return inst, info, nil
+}
+
+// populate 下载文件并应用覆盖内容到实例工作目录
+func (pi *PackImporter) populate(reader *zip.Reader, root string, info *PackInfo, inst *instance.Instance, opts ImportOptions) error {
+	workDir := inst.GetWorkDir(pi.dataDir)
+
+	if info.Format == PackFormatCurseForge {
+		// 服务端包直接解压（去掉顶层目录）
+		if _, err := archive.Extract(reader, root, workDir); err != nil {
+			return fmt.Errorf("解压服务端包失败: %w", err)
+		}
+	} else {
+		data, err := readJarEntry(reader, mrpackIndex)
+		if err != nil {
+			return err
+		}
+		_, index, err := parseMrpackIndex(data)
+		if err != nil {
+			return err
+		}
+
+		if err := pi.downloadMrpackFiles(index, inst, workDir, opts); err != nil {
+			return err
+		}
+
+		// overrides先应用，server-overrides覆盖其上
+		for _, prefix := range []string{"overrides/", "server-overrides/"} {
+			if _, err := archive.Extract(reader, prefix, workDir); err != nil {
+				return fmt.Errorf("应用 %s 失败: %w", strings.TrimSuffix(prefix, "/"), err)
+			}
+		}
+	}
+
+	if info.ServerJar != "" {
+		inst.ServerJar = info.ServerJar
+		if err := pi.manager.UpdateInstance(inst); err != nil {
+			return err
+		}
+	} else if err := pi.installCore(info, inst, workDir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// installCore 安装整合包指定的加载器（没有加载器时安装原版服务端）并记录核心来源
+func (pi *PackImporter) installCore(info *PackInfo, inst *instance.Instance, workDir string) error {
+	provider := pi.dm.CoreProvider(inst.ServerType)
+	result, err := provider.Install(download.InstallRequest{
+		MCVersion:    info.MCVersion,
+		Build:        info.LoaderVersion,
+		WorkDir:      workDir,
+		JavaPath:     inst.JavaPath,
+		ShowProgress: true,
+	})
+	if err != nil {
+		return fmt.Errorf("安装 %s %s 失败: %w", provider.Name(), info.LoaderVersion, err)
+	}
+
+	inst.ApplyCoreInstall(result)
+	return pi.manager.UpdateInstance(inst)
+}
+
+// downloadMrpackFiles 下载mrpack中列出的文件并校验哈希，同时记录来源以便之后更新
+func (pi *PackImporter) downloadMrpackFiles(index *mrpackIndexJSON, inst *instance.Instance, workDir string, opts ImportOptions) error {
+	provenance, err := LoadProvenance(workDir)
+	if err != nil {
+		return err
+	}
+
+	var files []int
+	for i, file := range index.Files {
+		if file.Env != nil && file.Env.Server == "unsupported" {
+			continue
+		}
+		files = append(files, i)
+	}
+
+	// 一次提交所有文件，由下载队列并发下载
+	jobs := make([]*download.Job, 0, len(files))
+	defer func() {
+		for _, job := range jobs {
+			pi.dm.Queue().Cancel(job.ID())
+		}
+	}()
+
+	for _, i := range files {
+		file := index.Files[i]
+		target, err := archive.SafeJoin(workDir, file.Path)
+		if err != nil {
+			return err
+		}
+		if len(file.Downloads) == 0 {
+			return fmt.Errorf("%s 没有下载地址", file.Path)
+		}
+		sums, err := fileChecksums(file.Hashes)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Path, err)
+		}
+		jobs = append(jobs, pi.submitVerified(file.Downloads, target, sums, file.Path))
+	}
+
+	for n, i := range files {
+		file := index.Files[i]
+		if err := jobs[n].Wait(); err != nil {
+			return fmt.Errorf("下载 %s 失败: %w", file.Path, err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(n+1, len(files), file.Path)
+		}
+
+		if m := modrinthCDNPattern.FindStringSubmatch(file.Downloads[0]); m != nil {
+			provenance.Put(InstalledContent{
+				Source:        "modrinth",
+				ProjectID:     m[1],
+				Name:          strings.TrimSuffix(path.Base(file.Path), path.Ext(file.Path)),
+				Kind:          KindMod,
+				VersionID:     m[2],
+				VersionNumber: m[2],
+				File:          path.Clean(strings.ReplaceAll(file.Path, "\\", "/")),
+				URL:           file.Downloads[0],
+				Hashes:        file.Hashes,
+				MCVersion:     inst.MCVersion,
+				Loaders:       LoadersForInstance(inst.ServerType, KindMod),
+				InstalledAt:   time.Now(),
+			})
+		}
+	}
+
+	return provenance.Save()
+}
+
+// submitVerified 提交下载任务，依次尝试下载地址，校验通过后移动到目标位置
+func (pi *PackImporter) submitVerified(urls []string, target string, sums download.Checksums, label string) *download.Job {
+	tempPath := target + ".download"
+	return pi.dm.Queue().Submit(download.JobRequest{
+		URLs:      urls,
+		Dest:      tempPath,
+		Label:     label,
+		Retries:   3,
+		Checksums: sums,
+		Verify: func(path string) error {
+			if err := os.Rename(path, target); err != nil {
+				return fmt.Errorf("移动文件失败: %w", err)
+			}
+			return nil
+		},
+	})
+}
+
+// instanceNameFromPack 根据整合包名称生成合法的实例名称
+func instanceNameFromPack(packName, packPath string) string {
+	name := packName
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(packPath), filepath.Ext(packPath))
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>| `, r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	result := strings.Trim(b.String(), "-.")
+	if len([]rune(result)) > 40 {
+		result = string([]rune(result)[:40])
+	}
+	if result == "" {
+		result = "modpack"
+	}
+	return result
+}
@@ -0,0 +1,226 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ModrinthBaseURL Modrinth API默认地址
+const ModrinthBaseURL = "https://api.modrinth.com/v2"
+
+// ModrinthSource Modrinth内容源
+type ModrinthSource struct {
+	*apiClient
+}
+
+// modrinthSearchResponse 搜索响应
+type modrinthSearchResponse struct {
+	Hits []struct {
+		ProjectID   string `json:"project_id"`
+		Slug        string `json:"slug"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Author      string `json:"author"`
+		Downloads   int64  `json:"downloads"`
+		ProjectType string `json:"project_type"`
+	} `json:"hits"`
+}
+
+// modrinthProject 项目详情
+type modrinthProject struct {
+	ID          string `json:"id"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Downloads   int64  `json:"downloads"`
+	ProjectType string `json:"project_type"`
+}
+
+// modrinthVersion 版本详情
+type modrinthVersion struct {
+	ID            string    `json:"id"`
+	ProjectID     string    `json:"project_id"`
+	Name          string    `json:"name"`
+	VersionNumber string    `json:"version_number"`
+	GameVersions  []string  `json:"game_versions"`
+	Loaders       []string  `json:"loaders"`
+	DatePublished time.Time `json:"date_published"`
+	Files         []struct {
+		URL      string            `json:"url"`
+		Filename string            `json:"filename"`
+		Primary  bool              `json:"primary"`
+		Size     int64             `json:"size"`
+		Hashes   map[string]string `json:"hashes"`
+	} `json:"files"`
+	Dependencies []struct {
+		VersionID      string `json:"version_id"`
+		ProjectID      string `json:"project_id"`
+		FileName       string `json:"file_name"`
+		DependencyType string `json:"dependency_type"`
+	} `json:"dependencies"`
+}
+
+// NewModrinthSource 创建Modrinth内容源，baseURL为空时使用官方地址
+func NewModrinthSource(baseURL string) *ModrinthSource {
+	if baseURL == "" {
+		baseURL = ModrinthBaseURL
+	}
+	return &ModrinthSource{apiClient: newAPIClient(baseURL)}
+}
+
+// Name 内容源名称
+func (s *ModrinthSource) Name() string {
+	return "modrinth"
+}
+
+// Search 搜索项目
+func (s *ModrinthSource) Search(query SearchQuery) ([]Project, error) {
+	// facets: 外层数组之间为"与"，内层数组之间为"或"
+	var facets [][]string
+	if query.Kind != "" {
+		facets = append(facets, []string{"project_type:" + query.Kind})
+	}
+	if query.MCVersion != "" {
+		facets = append(facets, []string{"versions:" + query.MCVersion})
+	}
+	if len(query.Loaders) > 0 {
+		var loaders []string
+		for _, loader := range query.Loaders {
+			loaders = append(loaders, "categories:"+loader)
+		}
+		facets = append(facets, loaders)
+	}
+
+	params := url.Values{}
+	params.Set("query", query.Query)
+	if len(facets) > 0 {
+		data, _ := json.Marshal(facets)
+		params.Set("facets", string(data))
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var response modrinthSearchResponse
+	if err := s.getJSON("/search?"+params.Encode(), &response); err != nil {
+		return nil, fmt.Errorf("搜索Modrinth失败: %w", err)
+	}
+
+	projects := make([]Project, 0, len(response.Hits))
+	for _, hit := range response.Hits {
+		projects = append(projects, Project{
+			ID:        hit.ProjectID,
+			Slug:      hit.Slug,
+			Name:      hit.Title,
+			Summary:   hit.Description,
+			Author:    hit.Author,
+			Downloads: hit.Downloads,
+			Kind:      hit.ProjectType,
+			Source:    s.Name(),
+		})
+	}
+
+	return projects, nil
+}
+
+// GetProject 按ID或slug获取项目
+func (s *ModrinthSource) GetProject(idOrSlug string) (*Project, error) {
+	var project modrinthProject
+	if err := s.getJSON("/project/"+url.PathEscape(idOrSlug), &project); err != nil {
+		return nil, fmt.Errorf("获取Modrinth项目失败: %w", err)
+	}
+
+	return &Project{
+		ID:        project.ID,
+		Slug:      project.Slug,
+		Name:      project.Title,
+		Summary:   project.Description,
+		Downloads: project.Downloads,
+		Kind:      project.ProjectType,
+		Source:    s.Name(),
+	}, nil
+}
+
+// ListVersions 列出兼容的版本
+func (s *ModrinthSource) ListVersions(projectID, mcVersion string, loaders []string) ([]ProjectVersion, error) {
+	params := url.Values{}
+	if mcVersion != "" {
+		data, _ := json.Marshal([]string{mcVersion})
+		params.Set("game_versions", string(data))
+	}
+	if len(loaders) > 0 {
+		data, _ := json.Marshal(loaders)
+		params.Set("loaders", string(data))
+	}
+
+	endpoint := "/project/" + url.PathEscape(projectID) + "/version"
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	var versions []modrinthVersion
+	if err := s.getJSON(endpoint, &versions); err != nil {
+		return nil, fmt.Errorf("获取Modrinth版本列表失败: %w", err)
+	}
+
+	result := make([]ProjectVersion, 0, len(versions))
+	for i := range versions {
+		result = append(result, versions[i].toProjectVersion())
+	}
+	sortVersionsByDate(result)
+
+	return result, nil
+}
+
+// GetVersion 获取指定版本，version可以是版本ID或版本号
+func (s *ModrinthSource) GetVersion(projectID, version string) (*ProjectVersion, error) {
+	var v modrinthVersion
+	err := s.getJSON("/version/"+url.PathEscape(version), &v)
+	if err != nil && projectID != "" {
+		// 按版本号查找
+		err = s.getJSON("/project/"+url.PathEscape(projectID)+"/version/"+url.PathEscape(version), &v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取Modrinth版本失败: %w", err)
+	}
+
+	result := v.toProjectVersion()
+	return &result, nil
+}
+
+// toProjectVersion 转换为通用版本结构
+func (v *modrinthVersion) toProjectVersion() ProjectVersion {
+	result := ProjectVersion{
+		ID:            v.ID,
+		ProjectID:     v.ProjectID,
+		Name:          v.Name,
+		VersionNumber: v.VersionNumber,
+		GameVersions:  v.GameVersions,
+		Loaders:       v.Loaders,
+		Published:     v.DatePublished,
+	}
+
+	for _, file := range v.Files {
+		result.Files = append(result.Files, VersionFile{
+			URL:      file.URL,
+			Filename: file.Filename,
+			Size:     file.Size,
+			Hashes:   file.Hashes,
+			Primary:  file.Primary,
+		})
+	}
+
+	for _, dep := range v.Dependencies {
+		result.Dependencies = append(result.Dependencies, VersionDependency{
+			ProjectID: dep.ProjectID,
+			VersionID: dep.VersionID,
+			Name:      dep.FileName,
+			Type:      dep.DependencyType,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,522 @@
+package content
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"easilypanel/internal/audit"
+	"easilypanel/internal/instance"
+)
+
+const (
+	// PluginsDirName 插件目录名
+	PluginsDirName = "plugins"
+	// DisabledDirName 已禁用内容存放目录名
+	DisabledDirName = ".disabled"
+
+	bukkitDescriptor = "plugin.yml"
+	paperDescriptor  = "paper-plugin.yml"
+)
+
+// PluginInfo 插件信息
+type PluginInfo struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Main        string   `json:"main,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Authors     []string `json:"authors,omitempty"`
+	APIVersion  string   `json:"api_version,omitempty"`
+	Depend      []string `json:"depend,omitempty"`      // 硬依赖，缺失时插件无法加载
+	SoftDepend  []string `json:"soft_depend,omitempty"` // 软依赖
+	Provides    []string `json:"provides,omitempty"`    // 插件声明可替代的其他插件名
+	Descriptor  string   `json:"descriptor,omitempty"`  // plugin.yml 或 paper-plugin.yml
+	File        string   `json:"file"`
+	Enabled     bool     `json:"enabled"`
+	Error       string   `json:"error,omitempty"` // 解析失败原因
+}
+
+// Problem 插件/模组检查发现的问题
+type Problem struct {
+	Severity string `json:"severity"` // error, warning
+	Subject  string `json:"subject"`
+	Message  string `json:"message"`
+}
+
+// 问题级别
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// pluginDescriptor plugin.yml / paper-plugin.yml 的公共字段
+type pluginDescriptor struct {
+	Name         string    `yaml:"name"`
+	Version      string    `yaml:"version"`
+	Main         string    `yaml:"main"`
+	Description  string    `yaml:"description"`
+	Author       string    `yaml:"author"`
+	Authors      []string  `yaml:"authors"`
+	APIVersion   string    `yaml:"api-version"`
+	Depend       []string  `yaml:"depend"`
+	SoftDepend   []string  `yaml:"softdepend"`
+	Provides     []string  `yaml:"provides"`
+	Dependencies yaml.Node `yaml:"dependencies"` // 仅paper-plugin.yml使用，格式随版本不同
+}
+
+// ParsePluginJar 解析插件jar中的描述文件
+func ParsePluginJar(path string) (*PluginInfo, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开jar失败: %w", err)
+	}
+	defer reader.Close()
+
+	// Paper插件优先使用paper-plugin.yml
+	descriptorName := paperDescriptor
+	data, err := readJarEntry(&reader.Reader, paperDescriptor)
+	if errors.Is(err, os.ErrNotExist) {
+		descriptorName = bukkitDescriptor
+		data, err = readJarEntry(&reader.Reader, bukkitDescriptor)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("未找到 %s 或 %s", bukkitDescriptor, paperDescriptor)
+		}
+		return nil, err
+	}
+
+	var desc pluginDescriptor
+	if err := yaml.Unmarshal(data, &desc); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", descriptorName, err)
+	}
+
+	if desc.Name == "" {
+		return nil, fmt.Errorf("%s 缺少name字段", descriptorName)
+	}
+
+	info := &PluginInfo{
+		Name:        desc.Name,
+		Version:     desc.Version,
+		Main:        desc.Main,
+		Description: desc.Description,
+		APIVersion:  desc.APIVersion,
+		Depend:      desc.Depend,
+		SoftDepend:  desc.SoftDepend,
+		Provides:    desc.Provides,
+		Descriptor:  descriptorName,
+		File:        path,
+	}
+
+	if desc.Author != "" {
+		info.Authors = append(info.Authors, desc.Author)
+	}
+	info.Authors = append(info.Authors, desc.Authors...)
+
+	if descriptorName == paperDescriptor {
+		hard, soft := parsePaperDependencies(&desc.Dependencies)
+		info.Depend = append(info.Depend, hard...)
+		info.SoftDepend = append(info.SoftDepend, soft...)
+	}
+
+	return info, nil
+}
+
+// parsePaperDependencies 解析paper-plugin.yml的dependencies字段
+//
+// 新格式按bootstrap/server分组：
+//
+//	dependencies:
+//	  server:
+//	    Vault: {load: BEFORE, required: true}
+//
+// 早期格式为列表：
+//
+//	dependencies:
+//	  - name: Vault
+//	    required: true
+func parsePaperDependencies(node *yaml.Node) (hard, soft []string) {
+	type paperDependency struct {
+		Name     string `yaml:"name"`
+		Required *bool  `yaml:"required"`
+	}
+
+	add := func(name string, required *bool) {
+		// required默认为true
+		if required == nil || *required {
+			hard = append(hard, name)
+		} else {
+			soft = append(soft, name)
+		}
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var deps []paperDependency
+		if err := node.Decode(&deps); err == nil {
+			for _, dep := range deps {
+				if dep.Name != "" {
+					add(dep.Name, dep.Required)
+				}
+			}
+		}
+	case yaml.MappingNode:
+		var groups map[string]map[string]paperDependency
+		if err := node.Decode(&groups); err == nil {
+			// bootstrap依赖只在引导阶段使用，运行时以server为准
+			for name, dep := range groups["server"] {
+				add(name, dep.Required)
+			}
+		}
+	}
+
+	sort.Strings(hard)
+	sort.Strings(soft)
+	return hard, soft
+}
+
+// PluginManager 实例插件管理器（Paper/Spigot等）
+type PluginManager struct {
+	instance *instance.Instance
+	workDir  string
+	actor    audit.Actor
+}
+
+// NewPluginManager 创建插件管理器
+func NewPluginManager(inst *instance.Instance, dataDir string) *PluginManager {
+	return &PluginManager{
+		instance: inst,
+		workDir:  inst.GetWorkDir(dataDir),
+		actor:    audit.DefaultActor(),
+	}
+}
+
+// SetActor 设置审计日志中记录的操作者
+func (pm *PluginManager) SetActor(actor audit.Actor) {
+	pm.actor = actor
+}
+
+// PluginsDir 获取插件目录
+func (pm *PluginManager) PluginsDir() string {
+	return filepath.Join(pm.workDir, PluginsDirName)
+}
+
+// DisabledDir 获取已禁用插件目录
+func (pm *PluginManager) DisabledDir() string {
+	return filepath.Join(pm.PluginsDir(), DisabledDirName)
+}
+
+// List 列出所有插件（包括已禁用的），解析失败的jar也会列出并带有错误信息
+func (pm *PluginManager) List() ([]*PluginInfo, error) {
+	var plugins []*PluginInfo
+
+	for _, dir := range []struct {
+		path    string
+		enabled bool
+	}{
+		{pm.PluginsDir(), true},
+		{pm.DisabledDir(), false},
+	} {
+		jars, err := listJars(dir.path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, jar := range jars {
+			info, err := ParsePluginJar(jar)
+			if err != nil {
+				info = &PluginInfo{
+					Name:  strings.TrimSuffix(filepath.Base(jar), filepath.Ext(jar)),
+					File:  jar,
+					Error: err.Error(),
+				}
+			}
+			info.Enabled = dir.enabled
+			plugins = append(plugins, info)
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		return normalizeName(plugins[i].Name) < normalizeName(plugins[j].Name)
+	})
+
+	return plugins, nil
+}
+
+// Find 按插件名或文件名查找插件
+func (pm *PluginManager) Find(name string) (*PluginInfo, error) {
+	plugins, err := pm.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*PluginInfo
+	for _, plugin := range plugins {
+		if normalizeName(plugin.Name) == normalizeName(name) ||
+			normalizeName(filepath.Base(plugin.File)) == normalizeName(name) {
+			matches = append(matches, plugin)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("未找到插件: %s", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("找到多个名为 %s 的插件，请使用文件名指定", name)
+	}
+}
+
+// Add 安装插件jar到插件目录
+func (pm *PluginManager) Add(jarPath string) (*PluginInfo, error) {
+	info, err := pm.add(jarPath)
+	audit.Record(pm.actor, "plugins.Add", pm.instance.Name, map[string]interface{}{
+		"file": filepath.Base(jarPath),
+	}, err)
+	return info, err
+}
+
+// add 校验并复制插件jar
+func (pm *PluginManager) add(jarPath string) (*PluginInfo, error) {
+	info, err := ParsePluginJar(jarPath)
+	if err != nil {
+		return nil, fmt.Errorf("无效的插件: %w", err)
+	}
+
+	// 同名插件已启用时拒绝安装，避免重复加载
+	if existing, err := pm.Find(info.Name); err == nil && existing.Enabled {
+		return nil, fmt.Errorf("插件 %s 已安装 (%s)，请先移除旧版本", info.Name, filepath.Base(existing.File))
+	}
+
+	target := filepath.Join(pm.PluginsDir(), filepath.Base(jarPath))
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("文件已存在: %s", target)
+	}
+
+	if err := copyFile(jarPath, target); err != nil {
+		return nil, err
+	}
+
+	info.File = target
+	info.Enabled = true
+	return info, nil
+}
+
+// Remove 删除插件文件
+func (pm *PluginManager) Remove(name string) error {
+	file, err := pm.remove(name)
+	audit.Record(pm.actor, "plugins.Remove", pm.instance.Name, map[string]interface{}{
+		"plugin": name,
+		"file":   file,
+	}, err)
+	return err
+}
+
+// remove 删除插件文件，返回被删除的文件名
+func (pm *PluginManager) remove(name string) (string, error) {
+	plugin, err := pm.Find(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Remove(plugin.File); err != nil {
+		return "", fmt.Errorf("删除插件失败: %w", err)
+	}
+	forgetFile(pm.workDir, plugin.File)
+
+	return filepath.Base(plugin.File), nil
+}
+
+// Enable 启用插件（从 plugins/.disabled 移回插件目录）
+func (pm *PluginManager) Enable(name string) error {
+	err := pm.setEnabled(name, true)
+	audit.Record(pm.actor, "plugins.Enable", pm.instance.Name, map[string]interface{}{
+		"plugin": name,
+	}, err)
+	return err
+}
+
+// Disable 禁用插件（移动到 plugins/.disabled，可随时恢复）
+func (pm *PluginManager) Disable(name string) error {
+	err := pm.setEnabled(name, false)
+	audit.Record(pm.actor, "plugins.Disable", pm.instance.Name, map[string]interface{}{
+		"plugin": name,
+	}, err)
+	return err
+}
+
+// setEnabled 在插件目录和禁用目录之间移动插件
+func (pm *PluginManager) setEnabled(name string, enabled bool) error {
+	plugin, err := pm.Find(name)
+	if err != nil {
+		return err
+	}
+
+	if plugin.Enabled == enabled {
+		if enabled {
+			return fmt.Errorf("插件 %s 已处于启用状态", plugin.Name)
+		}
+		return fmt.Errorf("插件 %s 已处于禁用状态", plugin.Name)
+	}
+
+	targetDir := pm.DisabledDir()
+	if enabled {
+		targetDir = pm.PluginsDir()
+	}
+
+	target := filepath.Join(targetDir, filepath.Base(plugin.File))
+	if err := moveFile(plugin.File, target); err != nil {
+		return err
+	}
+
+	trackMove(pm.workDir, plugin.File, target)
+	return nil
+}
+
+// Check 检查已启用插件的问题（缺失硬依赖、重复插件、无法解析的jar）
+func (pm *PluginManager) Check(plugins []*PluginInfo) []Problem {
+	var problems []Problem
+
+	// 收集已启用插件提供的名称
+	available := make(map[string]bool)
+	byName := make(map[string][]*PluginInfo)
+	for _, plugin := range plugins {
+		if !plugin.Enabled || plugin.Error != "" {
+			continue
+		}
+		key := normalizeName(plugin.Name)
+		available[key] = true
+		byName[key] = append(byName[key], plugin)
+		for _, alias := range plugin.Provides {
+			available[normalizeName(alias)] = true
+		}
+	}
+
+	// 重复插件
+	var names []string
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		duplicates := byName[name]
+		if len(duplicates) < 2 {
+			continue
+		}
+		var files []string
+		for _, plugin := range duplicates {
+			files = append(files, fmt.Sprintf("%s (%s)", filepath.Base(plugin.File), plugin.Version))
+		}
+		problems = append(problems, Problem{
+			Severity: SeverityError,
+			Subject:  duplicates[0].Name,
+			Message:  fmt.Sprintf("检测到重复插件: %s", strings.Join(files, ", ")),
+		})
+	}
+
+	for _, plugin := range plugins {
+		if !plugin.Enabled {
+			continue
+		}
+
+		if plugin.Error != "" {
+			problems = append(problems, Problem{
+				Severity: SeverityWarning,
+				Subject:  filepath.Base(plugin.File),
+				Message:  fmt.Sprintf("无法解析插件: %s", plugin.Error),
+			})
+			continue
+		}
+
+		// 缺失的硬依赖
+		var missing []string
+		for _, dep := range plugin.Depend {
+			if !available[normalizeName(dep)] {
+				missing = append(missing, dep)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, Problem{
+				Severity: SeverityError,
+				Subject:  plugin.Name,
+				Message:  fmt.Sprintf("缺少必需的依赖: %s", strings.Join(missing, ", ")),
+			})
+		}
+	}
+
+	return problems
+}
+
+// SupportsPlugins 判断实例的服务端类型是否支持Bukkit插件
+func SupportsPlugins(inst *instance.Instance) bool {
+	serverType := normalizeName(inst.ServerType)
+	for _, keyword := range []string{"paper", "spigot", "bukkit", "purpur", "folia", "leaves", "pufferfish", "mohist", "arclight", "catserver"} {
+		if strings.Contains(serverType, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintPluginList 打印插件列表及检查结果
+func PrintPluginList(plugins []*PluginInfo, problems []Problem) {
+	if len(plugins) == 0 {
+		fmt.Println("未找到任何插件")
+		return
+	}
+
+	fmt.Printf("找到 %d 个插件:\n\n", len(plugins))
+	fmt.Println("状态 | 名称                 | 版本         | API版本 | 作者")
+	fmt.Println("-----|----------------------|--------------|---------|----")
+
+	for _, plugin := range plugins {
+		status := "✓"
+		if !plugin.Enabled {
+			status = "✗"
+		}
+		if plugin.Error != "" {
+			fmt.Printf("%-4s | %-20s | %-12s | %-7s | 解析失败: %s\n", status, plugin.Name, "-", "-", plugin.Error)
+			continue
+		}
+
+		apiVersion := plugin.APIVersion
+		if apiVersion == "" {
+			apiVersion = "-"
+		}
+		fmt.Printf("%-4s | %-20s | %-12s | %-7s | %s\n",
+			status, plugin.Name, plugin.Version, apiVersion, strings.Join(plugin.Authors, ", "))
+
+		if len(plugin.Depend) > 0 {
+			fmt.Printf("     |   依赖: %s\n", strings.Join(plugin.Depend, ", "))
+		}
+		if len(plugin.SoftDepend) > 0 {
+			fmt.Printf("     |   可选依赖: %s\n", strings.Join(plugin.SoftDepend, ", "))
+		}
+	}
+
+	fmt.Println("\n✓ = 已启用, ✗ = 已禁用")
+	printProblems(problems)
+}
+
+// printProblems 打印检查发现的问题
+func printProblems(problems []Problem) {
+	if len(problems) == 0 {
+		return
+	}
+
+	fmt.Printf("\n发现 %d 个问题:\n", len(problems))
+	for _, problem := range problems {
+		icon := "⚠️"
+		if problem.Severity == SeverityError {
+			icon = "❌"
+		}
+		fmt.Printf("  %s %s: %s\n", icon, problem.Subject, problem.Message)
+	}
+}
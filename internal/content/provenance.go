@@ -0,0 +1,154 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ProvenanceFileName 实例工作目录中记录内容来源的文件名
+const ProvenanceFileName = "content-sources.json"
+
+// InstalledContent 通过内容源安装的插件/模组记录，用于后续更新
+type InstalledContent struct {
+	Source        string            `json:"source"`
+	ProjectID     string            `json:"project_id"`
+	Slug          string            `json:"slug,omitempty"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind"`
+	VersionID     string            `json:"version_id"`
+	VersionNumber string            `json:"version_number"`
+	File          string            `json:"file"` // 相对于实例工作目录的路径
+	URL           string            `json:"url"`
+	Hashes        map[string]string `json:"hashes,omitempty"`
+	MCVersion     string            `json:"mc_version,omitempty"`
+	Loaders       []string          `json:"loaders,omitempty"`
+	Dependency    bool              `json:"dependency"` // 作为依赖自动安装
+	InstalledAt   time.Time         `json:"installed_at"`
+}
+
+// Provenance 实例内容来源记录
+type Provenance struct {
+	path    string
+	Entries []InstalledContent `json:"entries"`
+}
+
+// LoadProvenance 加载实例的内容来源记录，文件不存在时返回空记录
+func LoadProvenance(workDir string) (*Provenance, error) {
+	p := &Provenance{path: filepath.Join(workDir, ProvenanceFileName)}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("读取内容来源记录失败: %w", err)
+	}
+
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("解析内容来源记录失败: %w", err)
+	}
+
+	return p, nil
+}
+
+// Save 保存内容来源记录
+func (p *Provenance) Save() error {
+	sort.Slice(p.Entries, func(i, j int) bool {
+		return p.Entries[i].File < p.Entries[j].File
+	})
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化内容来源记录失败: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	if err := os.WriteFile(p.path, data, 0644); err != nil {
+		return fmt.Errorf("写入内容来源记录失败: %w", err)
+	}
+
+	return nil
+}
+
+// Find 按内容源和项目ID查找记录
+func (p *Provenance) Find(source, projectID string) *InstalledContent {
+	for i := range p.Entries {
+		if p.Entries[i].Source == source && p.Entries[i].ProjectID == projectID {
+			return &p.Entries[i]
+		}
+	}
+	return nil
+}
+
+// FindByFile 按文件路径查找记录
+func (p *Provenance) FindByFile(file string) *InstalledContent {
+	for i := range p.Entries {
+		if p.Entries[i].File == file {
+			return &p.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Put 添加或替换记录（按内容源和项目ID）
+func (p *Provenance) Put(entry InstalledContent) {
+	if existing := p.Find(entry.Source, entry.ProjectID); existing != nil {
+		*existing = entry
+		return
+	}
+	p.Entries = append(p.Entries, entry)
+}
+
+// RemoveFile 删除指定文件的记录，返回是否存在
+func (p *Provenance) RemoveFile(file string) bool {
+	for i := range p.Entries {
+		if p.Entries[i].File == file {
+			p.Entries = append(p.Entries[:i], p.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// forgetFile 内容被删除时同步删除来源记录，记录不存在时不做任何事
+func forgetFile(workDir, file string) {
+	provenance, err := LoadProvenance(workDir)
+	if err != nil {
+		return
+	}
+
+	rel, err := filepath.Rel(workDir, file)
+	if err != nil {
+		return
+	}
+
+	if provenance.RemoveFile(filepath.ToSlash(rel)) {
+		provenance.Save()
+	}
+}
+
+// trackMove 内容被移动（启用/禁用）时同步更新来源记录中的文件路径
+func trackMove(workDir, oldPath, newPath string) {
+	provenance, err := LoadProvenance(workDir)
+	if err != nil {
+		return
+	}
+
+	oldRel, err1 := filepath.Rel(workDir, oldPath)
+	newRel, err2 := filepath.Rel(workDir, newPath)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	if entry := provenance.FindByFile(filepath.ToSlash(oldRel)); entry != nil {
+		entry.File = filepath.ToSlash(newRel)
+		provenance.Save()
+	}
+}
@@ -0,0 +1,233 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"easilypanel/internal/download"
+	"easilypanel/internal/httpclient"
+)
+
+// 内容类型
+const (
+	KindPlugin = "plugin"
+	KindMod    = "mod"
+)
+
+// defaultUserAgent 内容源请求使用的User-Agent，Modrinth要求包含应用的联系方式
+const defaultUserAgent = httpclient.DefaultUserAgent + " (github.com/Easily-miku/EasilyPanel5)"
+
+// contentUserAgent 配置了 network.user_agent 时使用配置的值，否则使用带项目地址的默认值
+func contentUserAgent() string {
+	if userAgent := httpclient.UserAgent(); userAgent != httpclient.DefaultUserAgent {
+		return userAgent
+	}
+	return defaultUserAgent
+}
+
+// Project 内容源中的项目（插件或模组）
+type Project struct {
+	ID        string `json:"id"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	Summary   string `json:"summary"`
+	Author    string `json:"author"`
+	Downloads int64  `json:"downloads"`
+	Kind      string `json:"kind"`
+	Source    string `json:"source"`
+}
+
+// VersionFile 版本文件
+type VersionFile struct {
+	URL      string            `json:"url"`
+	Filename string            `json:"filename"`
+	Size     int64             `json:"size"`
+	Hashes   map[string]string `json:"hashes"` // 算法 -> 十六进制摘要（sha1/sha256/sha512）
+	Primary  bool              `json:"primary"`
+}
+
+// VersionDependency 版本依赖
+type VersionDependency struct {
+	ProjectID string `json:"project_id"`
+	VersionID string `json:"version_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Type      string `json:"type"`          // required, optional, incompatible, embedded
+	URL       string `json:"url,omitempty"` // 外部下载地址，无法自动安装
+}
+
+// ProjectVersion 项目的一个版本
+type ProjectVersion struct {
+	ID            string              `json:"id"`
+	ProjectID     string              `json:"project_id"`
+	Name          string              `json:"name"`
+	VersionNumber string              `json:"version_number"`
+	GameVersions  []string            `json:"game_versions"`
+	Loaders       []string            `json:"loaders"`
+	Files         []VersionFile       `json:"files"`
+	Dependencies  []VersionDependency `json:"dependencies"`
+	Published     time.Time           `json:"published"`
+}
+
+// PrimaryFile 获取版本的主文件
+func (v *ProjectVersion) PrimaryFile() (*VersionFile, error) {
+	if len(v.Files) == 0 {
+		return nil, fmt.Errorf("版本 %s 没有可下载的文件", v.VersionNumber)
+	}
+	for i := range v.Files {
+		if v.Files[i].Primary {
+			return &v.Files[i], nil
+		}
+	}
+	return &v.Files[0], nil
+}
+
+// sortVersionsByDate 按发布时间从新到旧排序
+func sortVersionsByDate(versions []ProjectVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Published.After(versions[j].Published)
+	})
+}
+
+// SearchQuery 搜索条件
+type SearchQuery struct {
+	Query     string
+	Kind      string   // plugin 或 mod
+	MCVersion string   // 为空表示不过滤
+	Loaders   []string // 为空表示不过滤
+	Limit     int
+}
+
+// Source 内容源（插件/模组仓库）
+type Source interface {
+	// Name 内容源名称
+	Name() string
+	// Search 搜索项目
+	Search(query SearchQuery) ([]Project, error)
+	// GetProject 按ID或slug获取项目
+	GetProject(idOrSlug string) (*Project, error)
+	// ListVersions 列出兼容的版本，按发布时间从新到旧排列
+	ListVersions(projectID, mcVersion string, loaders []string) ([]ProjectVersion, error)
+	// GetVersion 获取指定版本（ID或版本号）
+	GetVersion(projectID, version string) (*ProjectVersion, error)
+}
+
+// apiClient 内容源共用的HTTP客户端
+type apiClient struct {
+	httpClient *http.Client
+	baseURL    string
+	userAgent  string
+}
+
+// newAPIClient 创建HTTP客户端，baseURL末尾的斜杠会被去掉
+func newAPIClient(baseURL string) *apiClient {
+	return &apiClient{
+		httpClient: httpclient.New(0),
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		userAgent:  contentUserAgent(),
+	}
+}
+
+// SetTimeout 设置请求超时时间
+func (c *apiClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// getJSON 发送GET请求并解析JSON响应
+func (c *apiClient) getJSON(endpoint string, v interface{}) error {
+	req, err := http.NewRequest("GET", c.baseURL+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("资源不存在: %s", endpoint)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP错误: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("解析JSON失败: %w", err)
+	}
+
+	return nil
+}
+
+// fileChecksums 内容源提供的文件哈希，内容文件始终要求哈希
+func fileChecksums(hashes map[string]string) (download.Checksums, error) {
+	sums := download.Checksums(hashes)
+	if algorithm, _ := sums.Strongest(); algorithm == "" {
+		return nil, fmt.Errorf("内容源未提供文件哈希，无法校验")
+	}
+	return sums, nil
+}
+
+// LoadersForInstance 获取实例可以使用的加载器/平台名称（Modrinth的loaders）
+func LoadersForInstance(serverType, kind string) []string {
+	if kind == KindMod {
+		switch LoaderForServerType(serverType) {
+		case LoaderFabric:
+			return []string{"fabric"}
+		case LoaderQuilt:
+			return []string{"quilt", "fabric"}
+		case LoaderForge:
+			return []string{"forge"}
+		case LoaderNeoForge:
+			return []string{"neoforge"}
+		}
+		return nil
+	}
+
+	serverType = normalizeName(serverType)
+	switch {
+	case strings.Contains(serverType, "folia"):
+		return []string{"folia"}
+	case strings.Contains(serverType, "purpur"):
+		return []string{"purpur", "paper", "spigot", "bukkit"}
+	case strings.Contains(serverType, "paper"), strings.Contains(serverType, "leaves"),
+		strings.Contains(serverType, "pufferfish"):
+		return []string{"paper", "spigot", "bukkit"}
+	case strings.Contains(serverType, "spigot"), isHybridServer(serverType):
+		return []string{"spigot", "bukkit"}
+	case strings.Contains(serverType, "bukkit"):
+		return []string{"bukkit"}
+	default:
+		return nil
+	}
+}
+
+// KindForInstance 判断实例默认使用插件还是模组（混合端默认为插件）
+func KindForInstance(serverType string) string {
+	if LoaderForServerType(serverType) != "" && !isHybridServer(normalizeName(serverType)) {
+		return KindMod
+	}
+	return KindPlugin
+}
+
+// isHybridServer 判断是否为同时支持插件和模组的混合端
+func isHybridServer(serverType string) bool {
+	for _, keyword := range []string{"mohist", "arclight", "catserver"} {
+		if strings.Contains(serverType, keyword) {
+			return true
+		}
+	}
+	return false
+}
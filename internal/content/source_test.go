@@ -0,0 +1,240 @@
+package content
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeModrinth Modrinth API的测试替身，项目、版本和文件都保存在内存中
+type fakeModrinth struct {
+	t        *testing.T
+	server   *httptest.Server
+	mu       sync.Mutex
+	projects map[string]map[string]interface{} // 项目ID -> 项目
+	versions map[string][]map[string]interface{}
+	files    map[string][]byte
+	queries  []url.Values // 收到的搜索请求参数
+}
+
+func newFakeModrinth(t *testing.T) *fakeModrinth {
+	t.Helper()
+	f := &fakeModrinth{
+		t:        t,
+		projects: make(map[string]map[string]interface{}),
+		versions: make(map[string][]map[string]interface{}),
+		files:    make(map[string][]byte),
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// addProject 添加项目及其一个版本，deps为 [类型, 项目ID, 版本ID] 的列表
+func (f *fakeModrinth) addProject(id, slug, versionID string, content []byte, deps ...[3]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.projects[id] = map[string]interface{}{
+		"id": id, "slug": slug, "title": strings.ToUpper(slug[:1]) + slug[1:],
+		"description": slug + " mod", "downloads": 100, "project_type": "mod",
+	}
+
+	filename := slug + "-1.0.jar"
+	f.files[filename] = content
+	sha1Sum := sha1.Sum(content)
+	sha512Sum := sha512.Sum512(content)
+
+	var dependencies []map[string]string
+	for _, dep := range deps {
+		dependencies = append(dependencies, map[string]string{
+			"dependency_type": dep[0], "project_id": dep[1], "version_id": dep[2],
+		})
+	}
+	f.versions[id] = append(f.versions[id], map[string]interface{}{
+		"id": versionID, "project_id": id, "name": slug + " 1.0", "version_number": "1.0",
+		"game_versions": []string{"1.20.1"}, "loaders": []string{"fabric"},
+		"date_published": time.Now().Format(time.RFC3339),
+		"files": []map[string]interface{}{{
+			"url": f.server.URL + "/files/" + filename, "filename": filename, "primary": true,
+			"size": len(content),
+			"hashes": map[string]string{
+				"sha1":   hex.EncodeToString(sha1Sum[:]),
+				"sha512": hex.EncodeToString(sha512Sum[:]),
+			},
+		}},
+		"dependencies": dependencies,
+	})
+}
+
+// corruptFile 修改已发布文件的内容，使其与API提供的哈希不一致
+func (f *fakeModrinth) corruptFile(slug string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[slug+"-1.0.jar"] = []byte("tampered")
+}
+
+func (f *fakeModrinth) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case parts[0] == "search":
+		f.queries = append(f.queries, r.URL.Query())
+		var hits []map[string]interface{}
+		for _, project := range f.projects {
+			hits = append(hits, map[string]interface{}{
+				"project_id": project["id"], "slug": project["slug"], "title": project["title"],
+				"description": project["description"], "author": "tester",
+				"downloads": project["downloads"], "project_type": project["project_type"],
+			})
+		}
+		writeJSON(w, map[string]interface{}{"hits": hits})
+
+	case parts[0] == "project" && len(parts) == 2:
+		if project := f.findProject(parts[1]); project != nil {
+			writeJSON(w, project)
+			return
+		}
+		http.NotFound(w, r)
+
+	case parts[0] == "project" && len(parts) == 3 && parts[2] == "version":
+		project := f.findProject(parts[1])
+		if project == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, f.versions[project["id"].(string)])
+
+	case parts[0] == "version" && len(parts) == 2:
+		for _, versions := range f.versions {
+			for _, version := range versions {
+				if version["id"] == parts[1] {
+					writeJSON(w, version)
+					return
+				}
+			}
+		}
+		http.NotFound(w, r)
+
+	case parts[0] == "files" && len(parts) == 2:
+		content, ok := f.files[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+
+	default:
+		f.t.Errorf("意外的请求: %s", r.URL)
+		http.NotFound(w, r)
+	}
+}
+
+// findProject 按ID或slug查找项目，调用时已持有锁
+func (f *fakeModrinth) findProject(idOrSlug string) map[string]interface{} {
+	for id, project := range f.projects {
+		if id == idOrSlug || project["slug"] == idOrSlug {
+			return project
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestModrinthSearchFilters(t *testing.T) {
+	fake := newFakeModrinth(t)
+	fake.addProject("AAAA", "sodium", "v-sodium", []byte("sodium"))
+
+	source := NewModrinthSource(fake.server.URL + "/")
+	projects, err := source.Search(SearchQuery{
+		Query:     "sodium",
+		Kind:      KindMod,
+		MCVersion: "1.20.1",
+		Loaders:   []string{"quilt", "fabric"},
+		Limit:     5,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	if len(projects) != 1 || projects[0].Slug != "sodium" || projects[0].Source != "modrinth" || projects[0].Author != "tester" {
+		t.Fatalf("projects = %+v", projects)
+	}
+
+	query := fake.queries[0]
+	if query.Get("query") != "sodium" || query.Get("limit") != "5" {
+		t.Errorf("query = %v", query)
+	}
+	var facets [][]string
+	if err := json.Unmarshal([]byte(query.Get("facets")), &facets); err != nil {
+		t.Fatalf("facets = %q: %v", query.Get("facets"), err)
+	}
+	want := [][]string{
+		{"project_type:mod"},
+		{"versions:1.20.1"},
+		{"categories:quilt", "categories:fabric"}, // 同一组内为"或"
+	}
+	if fmt.Sprint(facets) != fmt.Sprint(want) {
+		t.Errorf("facets = %v, 期望 %v", facets, want)
+	}
+}
+
+func TestModrinthSearchWithoutFilters(t *testing.T) {
+	fake := newFakeModrinth(t)
+	source := NewModrinthSource(fake.server.URL)
+	if _, err := source.Search(SearchQuery{Query: "anything"}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if facets := fake.queries[0].Get("facets"); facets != "" {
+		t.Errorf("没有过滤条件时不应发送facets: %q", facets)
+	}
+}
+
+func TestHangarSearchFilters(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects" {
+			t.Errorf("意外的请求: %s", r.URL)
+		}
+		got = r.URL.Query()
+		writeJSON(w, map[string]interface{}{
+			"result": []map[string]interface{}{{
+				"id": 42, "name": "ViaVersion", "description": "protocol support",
+				"namespace": map[string]string{"owner": "ViaVersion", "slug": "ViaVersion"},
+				"stats":     map[string]int64{"downloads": 1000},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	source := NewHangarSource(server.URL)
+	projects, err := source.Search(SearchQuery{Query: "via", Kind: KindPlugin, MCVersion: "1.21", Limit: 3})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got.Get("q") != "via" || got.Get("platform") != "PAPER" || got.Get("version") != "1.21" || got.Get("limit") != "3" {
+		t.Errorf("query = %v", got)
+	}
+	if len(projects) != 1 || projects[0].ID != "42" || projects[0].Slug != "ViaVersion" || projects[0].Kind != KindPlugin {
+		t.Fatalf("projects = %+v", projects)
+	}
+
+	if _, err := source.Search(SearchQuery{Query: "via", Kind: KindMod}); err == nil {
+		t.Error("Hangar搜索模组应当返回错误")
+	}
+}
@@ -0,0 +1,245 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"easilypanel/internal/audit"
+)
+
+// BackupDirName 实例工作目录中存放内容更新备份的目录名
+const BackupDirName = "backups"
+
+// Update 可用的更新
+type Update struct {
+	Entry  InstalledContent `json:"entry"`
+	Latest ProjectVersion   `json:"latest"`
+}
+
+// UpdatedItem 一次更新中被替换的内容
+type UpdatedItem struct {
+	Old        InstalledContent `json:"old"`
+	New        InstalledContent `json:"new"`
+	BackupFile string           `json:"backup_file"` // 旧jar的备份路径
+}
+
+// UpdateBatch 一次更新操作，用于回滚
+type UpdateBatch struct {
+	BackupDir string        `json:"backup_dir"`
+	Items     []UpdatedItem `json:"items"`
+}
+
+// Outdated 检查通过内容源安装的内容是否有更新的兼容版本
+func (in *Installer) Outdated() ([]Update, []error) {
+	provenance, err := LoadProvenance(in.workDir)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var updates []Update
+	var errs []error
+	for _, entry := range provenance.Entries {
+		source, err := in.Source(entry.Source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name, err))
+			continue
+		}
+
+		latest, err := in.ResolveVersion(source, entry.ProjectID, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name, err))
+			continue
+		}
+
+		if latest.ID != entry.VersionID {
+			updates = append(updates, Update{Entry: entry, Latest: *latest})
+		}
+	}
+
+	return updates, errs
+}
+
+// SelectUpdates 按名称、slug、项目ID或文件名筛选更新
+func SelectUpdates(updates []Update, names []string) ([]Update, error) {
+	var selected []Update
+	for _, name := range names {
+		found := false
+		for _, update := range updates {
+			entry := update.Entry
+			if normalizeName(entry.Name) == normalizeName(name) || normalizeName(entry.Slug) == normalizeName(name) ||
+				entry.ProjectID == name || normalizeName(filepath.Base(entry.File)) == normalizeName(name) {
+				selected = append(selected, update)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s 没有可用的更新", name)
+		}
+	}
+	return selected, nil
+}
+
+// ApplyUpdates 备份旧jar并替换为新版本，check不为nil时在替换后调用，失败则自动回滚
+func (in *Installer) ApplyUpdates(updates []Update, check func() error) (*UpdateBatch, error) {
+	batch, err := in.applyUpdates(updates)
+
+	var projects []string
+	for _, update := range updates {
+		projects = append(projects, fmt.Sprintf("%s %s -> %s", update.Entry.Name, update.Entry.VersionNumber, update.Latest.VersionNumber))
+	}
+	audit.Record(in.actor, "content.Update", in.instance.Name, map[string]interface{}{
+		"updates": projects,
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if check != nil {
+		if checkErr := check(); checkErr != nil {
+			if rollbackErr := in.Rollback(batch); rollbackErr != nil {
+				return batch, fmt.Errorf("就绪检查失败: %v，且回滚失败: %w", checkErr, rollbackErr)
+			}
+			return batch, fmt.Errorf("就绪检查失败，已回滚到旧版本: %w", checkErr)
+		}
+	}
+
+	return batch, nil
+}
+
+// applyUpdates 逐个替换jar，任何一个失败都会恢复已替换的内容
+func (in *Installer) applyUpdates(updates []Update) (*UpdateBatch, error) {
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("没有需要更新的内容")
+	}
+
+	provenance, err := LoadProvenance(in.workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &UpdateBatch{
+		BackupDir: filepath.Join(in.workDir, BackupDirName, "content-"+time.Now().Format("20060102-150405")),
+	}
+
+	for _, update := range updates {
+		item, err := in.replace(update, batch.BackupDir)
+		if err != nil {
+			in.restore(batch)
+			return nil, fmt.Errorf("更新 %s 失败: %w", update.Entry.Name, err)
+		}
+		batch.Items = append(batch.Items, *item)
+	}
+
+	for _, item := range batch.Items {
+		provenance.Put(item.New)
+	}
+	if err := provenance.Save(); err != nil {
+		in.restore(batch)
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// replace 备份旧文件并下载新版本到同一目录
+func (in *Installer) replace(update Update, backupDir string) (*UpdatedItem, error) {
+	source, err := in.Source(update.Entry.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPath := filepath.Join(in.workDir, filepath.FromSlash(update.Entry.File))
+	backupFile := filepath.Join(backupDir, filepath.Base(oldPath))
+	if err := moveFile(oldPath, backupFile); err != nil {
+		return nil, fmt.Errorf("备份旧版本失败: %w", err)
+	}
+
+	project := &Project{
+		ID:   update.Entry.ProjectID,
+		Slug: update.Entry.Slug,
+		Name: update.Entry.Name,
+	}
+	latest := update.Latest
+
+	// 保持在原目录（已禁用的内容更新后仍为禁用状态）
+	entry, err := in.installVersionTo(plannedInstall{
+		source:     source,
+		project:    project,
+		version:    &latest,
+		dependency: update.Entry.Dependency,
+	}, filepath.Dir(filepath.FromSlash(update.Entry.File)))
+	if err != nil {
+		moveFile(backupFile, oldPath)
+		return nil, err
+	}
+
+	return &UpdatedItem{Old: update.Entry, New: *entry, BackupFile: backupFile}, nil
+}
+
+// Rollback 回滚一次更新：删除新jar、恢复备份并还原来源记录
+func (in *Installer) Rollback(batch *UpdateBatch) error {
+	err := in.restore(batch)
+
+	if err == nil {
+		var provenance *Provenance
+		provenance, err = LoadProvenance(in.workDir)
+		if err == nil {
+			for _, item := range batch.Items {
+				provenance.Put(item.Old)
+			}
+			err = provenance.Save()
+		}
+	}
+
+	var files []string
+	for _, item := range batch.Items {
+		files = append(files, item.Old.File)
+	}
+	audit.Record(in.actor, "content.Rollback", in.instance.Name, map[string]interface{}{
+		"files":  files,
+		"backup": batch.BackupDir,
+	}, err)
+
+	return err
+}
+
+// restore 删除新文件并把备份移回原位置
+func (in *Installer) restore(batch *UpdateBatch) error {
+	var failed []string
+	for _, item := range batch.Items {
+		os.Remove(filepath.Join(in.workDir, filepath.FromSlash(item.New.File)))
+
+		oldPath := filepath.Join(in.workDir, filepath.FromSlash(item.Old.File))
+		if err := moveFile(item.BackupFile, oldPath); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", item.Old.File, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("恢复备份失败: %s", strings.Join(failed, "; "))
+	}
+
+	// 备份已全部移回，删除空的备份目录
+	os.Remove(batch.BackupDir)
+	return nil
+}
+
+// PrintUpdates 打印可用的更新
+func PrintUpdates(updates []Update) {
+	if len(updates) == 0 {
+		fmt.Println("✓ 所有内容均为最新版本")
+		return
+	}
+
+	fmt.Printf("发现 %d 个可用更新:\n\n", len(updates))
+	fmt.Println("名称                     | 当前版本       | 最新版本       | 来源")
+	fmt.Println("-------------------------|----------------|----------------|---------")
+	for _, update := range updates {
+		fmt.Printf("%-24s | %-14s | %-14s | %s\n",
+			update.Entry.Name, update.Entry.VersionNumber, update.Latest.VersionNumber, update.Entry.Source)
+	}
+}
@@ -0,0 +1,446 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry 下载缓存中的一个文件，以SHA1为键
+type CacheEntry struct {
+	SHA1     string    `json:"sha1"`
+	Size     int64     `json:"size"`
+	Name     string    `json:"name"`            // 下载时的文件名
+	URL      string    `json:"url,omitempty"`   // 下载地址
+	Alias    string    `json:"alias,omitempty"` // 下载目录中以原文件名链接的路径
+	Refs     []string  `json:"refs,omitempty"`  // 链接或复制到实例目录的路径
+	Hits     int       `json:"hits"`
+	AddedAt  time.Time `json:"added_at"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// cacheIndex 缓存索引文件的内容
+type cacheIndex struct {
+	Hits    int                    `json:"hits"`
+	Misses  int                    `json:"misses"`
+	Entries map[string]*CacheEntry `json:"entries"`
+}
+
+// CacheStats 下载缓存统计
+type CacheStats struct {
+	Entries     int
+	Referenced  int
+	Size        int64 // 缓存文件占用的空间
+	LogicalSize int64 // 所有引用按独立文件计算的大小
+	Hits        int
+	Misses      int
+	HardLinked  int // 以硬链接共享的引用数量（reflink和复制的引用无法区分）
+}
+
+// Cache 以SHA1寻址的下载缓存，相同内容只保存一份，实例目录通过硬链接或reflink共享
+type Cache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewCache 创建下载缓存
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Dir 缓存目录
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Path 缓存文件路径
+func (c *Cache) Path(sha1 string) string {
+	sha1 = strings.ToLower(sha1)
+	prefix := sha1
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return filepath.Join(c.dir, prefix, sha1)
+}
+
+// indexPath 索引文件路径
+func (c *Cache) indexPath() string {
+	return filepath.Join(c.dir, "index.json")
+}
+
+// load 读取索引
+func (c *Cache) load() (*cacheIndex, error) {
+	index := &cacheIndex{Entries: make(map[string]*CacheEntry)}
+	data, err := os.ReadFile(c.indexPath())
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存索引失败: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("解析缓存索引失败: %w", err)
+	}
+	if index.Entries == nil {
+		index.Entries = make(map[string]*CacheEntry)
+	}
+	return index, nil
+}
+
+// save 保存索引
+func (c *Cache) save(index *cacheIndex) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化缓存索引失败: %w", err)
+	}
+	tempPath := c.indexPath() + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("保存缓存索引失败: %w", err)
+	}
+	return os.Rename(tempPath, c.indexPath())
+}
+
+// update 在锁内读取、修改并保存索引
+func (c *Cache) update(fn func(index *cacheIndex) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, err := c.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(index); err != nil {
+		return err
+	}
+	return c.save(index)
+}
+
+// Lookup 查找缓存文件，并记录命中或未命中
+func (c *Cache) Lookup(sha1 string) (string, bool) {
+	if sha1 == "" {
+		return "", false
+	}
+	sha1 = strings.ToLower(sha1)
+	path := c.Path(sha1)
+
+	found := false
+	c.update(func(index *cacheIndex) error {
+		entry, ok := index.Entries[sha1]
+		if ok {
+			if _, err := os.Stat(path); err != nil {
+				delete(index.Entries, sha1)
+				ok = false
+			}
+		}
+		if !ok {
+			index.Misses++
+			return nil
+		}
+		found = true
+		index.Hits++
+		entry.Hits++
+		entry.LastUsed = time.Now()
+		return nil
+	})
+	return path, found
+}
+
+// Add 把下载完成的文件移入缓存，并在原位置留下链接（alias）；sha1为空时计算
+func (c *Cache) Add(file, sha1, url string) (string, error) {
+	if sha1 == "" {
+		var err error
+		if sha1, err = fileSHA1(file); err != nil {
+			return "", err
+		}
+	}
+	sha1 = strings.ToLower(sha1)
+	path := c.Path(sha1)
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		// 内容已在缓存中，原文件改为指向缓存的链接
+		if err := os.Remove(file); err != nil {
+			return "", fmt.Errorf("删除重复文件失败: %w", err)
+		}
+	} else if err := os.Rename(file, path); err != nil {
+		return "", fmt.Errorf("移动文件到缓存失败: %w", err)
+	}
+	if _, err := linkFile(path, file); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = c.update(func(index *cacheIndex) error {
+		entry, ok := index.Entries[sha1]
+		if !ok {
+			entry = &CacheEntry{SHA1: sha1, AddedAt: now}
+			index.Entries[sha1] = entry
+		}
+		entry.Size = info.Size()
+		entry.Name = filepath.Base(file)
+		entry.Alias = file
+		if abs, err := filepath.Abs(file); err == nil {
+			entry.Alias = abs
+		}
+		if url != "" {
+			entry.URL = url
+		}
+		entry.LastUsed = now
+		return nil
+	})
+	return sha1, err
+}
+
+// Link 把缓存文件链接到dst，依次尝试硬链接、reflink和复制，并记录引用
+func (c *Cache) Link(sha1, dst string) error {
+	sha1 = strings.ToLower(sha1)
+	path := c.Path(sha1)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("缓存中没有 %s", sha1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if _, err := linkFile(path, dst); err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(dst)
+	if err != nil {
+		abs = dst
+	}
+	return c.update(func(index *cacheIndex) error {
+		entry, ok := index.Entries[sha1]
+		if !ok {
+			return nil
+		}
+		for _, ref := range entry.Refs {
+			if ref == abs {
+				entry.LastUsed = time.Now()
+				return nil
+			}
+		}
+		entry.Refs = append(entry.Refs, abs)
+		entry.LastUsed = time.Now()
+		return nil
+	})
+}
+
+// liveRefs 返回仍然引用缓存文件的路径
+//
+// 硬链接通过os.SameFile判断；reflink和复制的文件需要大小和SHA1都一致。
+// 已删除或被替换为其他内容的路径不再算作引用。
+func liveRefs(path string, entry *CacheEntry) []string {
+	cached, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	var refs []string
+	for _, ref := range entry.Refs {
+		info, err := os.Stat(ref)
+		if err != nil {
+			continue
+		}
+		if os.SameFile(cached, info) ||
+			(info.Size() == entry.Size && VerifyChecksums(ref, Checksums{"sha1": entry.SHA1}) == nil) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// Stats 统计缓存的命中率和磁盘占用
+func (c *Cache) Stats() (*CacheStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &CacheStats{Hits: index.Hits, Misses: index.Misses}
+	for _, entry := range index.Entries {
+		path := c.Path(entry.SHA1)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Size += info.Size()
+		stats.LogicalSize += info.Size()
+
+		refs := liveRefs(path, entry)
+		if len(refs) > 0 {
+			stats.Referenced++
+		}
+		for _, ref := range refs {
+			stats.LogicalSize += info.Size()
+			if refInfo, err := os.Stat(ref); err == nil && os.SameFile(info, refInfo) {
+				stats.HardLinked++
+			}
+		}
+	}
+	return stats, nil
+}
+
+// Entries 列出缓存条目，最近使用的在前
+func (c *Cache) Entries() ([]CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]CacheEntry, 0, len(index.Entries))
+	for _, entry := range index.Entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Evict 删除没有实例引用且超过maxAge未使用的缓存文件（连同下载目录中的链接），maxAge为0时删除所有未引用的文件
+func (c *Cache) Evict(maxAge time.Duration) (int, int64, error) {
+	removed := 0
+	var freed int64
+
+	err := c.update(func(index *cacheIndex) error {
+		for sha1, entry := range index.Entries {
+			entry.Refs = liveRefs(c.Path(sha1), entry)
+			if len(entry.Refs) > 0 || time.Since(entry.LastUsed) < maxAge {
+				continue
+			}
+
+			path := c.Path(sha1)
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("删除缓存文件失败: %w", err)
+			}
+			if entry.Alias != "" {
+				if info, err := os.Stat(entry.Alias); err == nil && info.Size() == entry.Size {
+					os.Remove(entry.Alias)
+				}
+			}
+			delete(index.Entries, sha1)
+			removed++
+			freed += entry.Size
+		}
+		return nil
+	})
+	return removed, freed, err
+}
+
+// linkFile 依次尝试硬链接、reflink和复制，返回使用的方式
+func linkFile(src, dst string) (string, error) {
+	// 先删除目标，避免写入与缓存共享的文件
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("删除旧文件失败: %w", err)
+	}
+
+	if err := os.Link(src, dst); err == nil {
+		return "hardlink", nil
+	}
+	if err := reflink(src, dst); err == nil {
+		return "reflink", nil
+	}
+	os.Remove(dst)
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", fmt.Errorf("创建文件失败: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", fmt.Errorf("复制文件失败: %w", err)
+	}
+	return "copy", out.Close()
+}
+
+// Cache 获取下载缓存
+func (dm *DownloadManager) Cache() *Cache {
+	return dm.cache
+}
+
+// SetCacheCleanup 设置下载后自动清理超过days天未使用且没有实例引用的缓存（download.auto_cleanup、download.cleanup_days）
+func (dm *DownloadManager) SetCacheCleanup(enabled bool, days int) {
+	dm.autoCleanup = enabled
+	dm.cleanupDays = days
+}
+
+// useCached 缓存中有sha1对应的文件时，在下载目录中以filePath链接到缓存
+func (dm *DownloadManager) useCached(sha1, filePath string) bool {
+	cached, ok := dm.cache.Lookup(sha1)
+	if !ok {
+		return false
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		if cachedInfo, err := os.Stat(cached); err == nil && os.SameFile(info, cachedInfo) {
+			return true
+		}
+	}
+	if _, err := linkFile(cached, filePath); err != nil {
+		return false
+	}
+	return true
+}
+
+// cacheFile 把下载目录中的文件移入缓存，返回文件的SHA1；失败时文件保留在原处
+func (dm *DownloadManager) cacheFile(filePath, sha1, url string) string {
+	added, err := dm.cache.Add(filePath, sha1, url)
+	if err != nil {
+		fmt.Printf("⚠️  加入下载缓存失败: %v\n", err)
+		return sha1
+	}
+
+	if dm.autoCleanup && dm.cleanupDays > 0 {
+		if removed, freed, err := dm.cache.Evict(time.Duration(dm.cleanupDays) * 24 * time.Hour); err == nil && removed > 0 {
+			fmt.Printf("已清理 %d 个过期缓存文件，释放 %s\n", removed, FormatBytes(freed))
+		}
+	}
+	return added
+}
+
+// LinkToWorkDir 把下载的文件放入工作目录：缓存中有sha1对应的文件时使用硬链接或reflink，否则复制
+func (dm *DownloadManager) LinkToWorkDir(src, sha1, workDir, name string) error {
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("创建工作目录失败: %w", err)
+	}
+
+	dst := filepath.Join(workDir, name)
+	if sha1 != "" {
+		if _, err := os.Stat(dm.cache.Path(sha1)); err == nil {
+			return dm.cache.Link(sha1, dst)
+		}
+	}
+	_, err := linkFile(src, dst)
+	return err
+}
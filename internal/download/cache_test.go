@@ -0,0 +1,59 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvictDropsReplacedRefs(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(filepath.Join(dir, "cache"))
+
+	content := []byte("server jar content")
+	file := filepath.Join(dir, "downloads", "server.jar")
+	os.MkdirAll(filepath.Dir(file), 0755)
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sha1, err := cache.Add(file, "", "")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	linked := filepath.Join(dir, "a", "server.jar")
+	copied := filepath.Join(dir, "b", "server.jar")
+	replaced := filepath.Join(dir, "c", "server.jar")
+	for _, dst := range []string{linked, copied, replaced} {
+		if err := cache.Link(sha1, dst); err != nil {
+			t.Fatalf("Link %s: %v", dst, err)
+		}
+	}
+
+	// 复制的文件内容相同，仍然算作引用；被替换的文件即使大小相同也不再引用缓存
+	os.Remove(copied)
+	os.WriteFile(copied, content, 0644)
+	os.Remove(replaced)
+	os.WriteFile(replaced, []byte("server jar CONTENT"), 0644)
+
+	if removed, _, err := cache.Evict(0); err != nil || removed != 0 {
+		t.Fatalf("Evict = %d, %v, 仍有引用的文件不应删除", removed, err)
+	}
+	entries, _ := cache.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	refs := entries[0].Refs
+	if len(refs) != 2 || refs[0] != linked || refs[1] != copied {
+		t.Errorf("refs = %v, 期望 [%s %s]", refs, linked, copied)
+	}
+
+	os.Remove(linked)
+	os.WriteFile(copied, []byte("another jar"), 0644)
+	if removed, _, err := cache.Evict(0); err != nil || removed != 1 {
+		t.Fatalf("Evict = %d, %v, 没有引用的文件应被删除", removed, err)
+	}
+	if _, err := os.Stat(cache.Path(sha1)); !os.IsNotExist(err) {
+		t.Error("缓存文件应当已删除")
+	}
+}
@@ -0,0 +1,320 @@
+package download
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"easilypanel/internal/archive"
+)
+
+// 目录缓存的有效期：服务端列表很少变化，构建列表变化最频繁，单个构建的信息不会变化
+const (
+	catalogServersTTL  = 24 * time.Hour
+	catalogVersionsTTL = 6 * time.Hour
+	catalogBuildsTTL   = time.Hour
+	catalogCoreTTL     = 30 * 24 * time.Hour
+)
+
+// catalogEntry 缓存的API响应
+type catalogEntry struct {
+	Endpoint  string          `json:"endpoint"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Response  json.RawMessage `json:"response"`
+}
+
+// CatalogStatus 最近一次使用目录缓存的情况
+type CatalogStatus struct {
+	Offline   bool      // 网络请求失败，使用了过期的缓存
+	FetchedAt time.Time // 使用的缓存中最旧的获取时间
+}
+
+// Age 缓存数据的年龄
+func (s CatalogStatus) Age() time.Duration {
+	if s.FetchedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.FetchedAt)
+}
+
+// Catalog 下载源API响应的磁盘缓存，网络不可用时提供离线浏览
+type Catalog struct {
+	dir string
+
+	mu     sync.Mutex
+	status CatalogStatus
+}
+
+// NewCatalog 创建目录缓存
+func NewCatalog(dir string) *Catalog {
+	return &Catalog{dir: dir}
+}
+
+// Dir 目录缓存的位置
+func (c *Catalog) Dir() string {
+	return c.dir
+}
+
+// catalogTTL 根据FastMirror接口层级确定缓存有效期
+func catalogTTL(endpoint string) time.Duration {
+	path, _, _ := strings.Cut(endpoint, "?")
+	switch strings.Count(path, "/") {
+	case 0:
+		return catalogServersTTL
+	case 1:
+		return catalogVersionsTTL
+	case 2:
+		return catalogBuildsTTL
+	default:
+		return catalogCoreTTL
+	}
+}
+
+// path 缓存文件路径
+func (c *Catalog) path(endpoint string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%x.json", sha1.Sum([]byte(endpoint))))
+}
+
+// get 读取缓存的响应
+func (c *Catalog) get(endpoint string) (*catalogEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(endpoint))
+	if err != nil {
+		return nil, false
+	}
+	var entry catalogEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Endpoint != endpoint {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// fresh 缓存是否仍在有效期内
+func (e *catalogEntry) fresh() bool {
+	return time.Since(e.FetchedAt) < catalogTTL(e.Endpoint)
+}
+
+// put 保存响应
+func (c *Catalog) put(endpoint string, response []byte) {
+	if c == nil {
+		return
+	}
+	data, err := json.Marshal(catalogEntry{Endpoint: endpoint, FetchedAt: time.Now(), Response: response})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return
+	}
+	tempPath := c.path(endpoint) + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return
+	}
+	os.Rename(tempPath, c.path(endpoint))
+}
+
+// used 记录使用了缓存，offline表示因网络失败使用了过期的缓存
+func (c *Catalog) used(entry *catalogEntry, offline bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if offline {
+		c.status.Offline = true
+	}
+	if c.status.FetchedAt.IsZero() || entry.FetchedAt.Before(c.status.FetchedAt) {
+		c.status.FetchedAt = entry.FetchedAt
+	}
+}
+
+// Status 返回并重置最近的缓存使用情况
+func (c *Catalog) Status() CatalogStatus {
+	if c == nil {
+		return CatalogStatus{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.status
+	c.status = CatalogStatus{}
+	return status
+}
+
+// CatalogInfo 目录缓存的概况
+type CatalogInfo struct {
+	Entries int
+	Stale   int // 超过有效期的条目
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// Info 统计目录缓存
+func (c *Catalog) Info() (*CatalogInfo, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &CatalogInfo{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry catalogEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		info.Entries++
+		if !entry.fresh() {
+			info.Stale++
+		}
+		if info.Oldest.IsZero() || entry.FetchedAt.Before(info.Oldest) {
+			info.Oldest = entry.FetchedAt
+		}
+		if entry.FetchedAt.After(info.Newest) {
+			info.Newest = entry.FetchedAt
+		}
+	}
+	return info, nil
+}
+
+// Export 把目录缓存和选定的已下载文件打包到zip，供离线的机器导入
+func (c *Catalog) Export(dest string, files []string) (int, error) {
+	out, err := os.Create(dest)
+	if err != nil {
+		return 0, fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer out.Close()
+
+	writer := zip.NewWriter(out)
+	count := 0
+
+	entries, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if err := addZipFile(writer, entry, "catalog/"+filepath.Base(entry)); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	for _, file := range files {
+		if err := addZipFile(writer, file, "files/"+filepath.Base(file)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, fmt.Errorf("写入zip失败: %w", err)
+	}
+	return count, nil
+}
+
+// addZipFile 把文件写入zip
+func addZipFile(writer *zip.Writer, path, name string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer in.Close()
+
+	w, err := writer.Create(name)
+	if err != nil {
+		return fmt.Errorf("写入zip失败: %w", err)
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		return fmt.Errorf("写入zip失败: %w", err)
+	}
+	return nil
+}
+
+// ExportCatalog 导出目录缓存和下载目录中选定的文件
+func (dm *DownloadManager) ExportCatalog(dest string, fileNames []string) (int, error) {
+	var files []string
+	for _, name := range fileNames {
+		path := dm.GetDownloadedFilePath(filepath.Base(name))
+		if _, err := os.Stat(path); err != nil {
+			return 0, fmt.Errorf("文件不存在: %s", name)
+		}
+		files = append(files, path)
+	}
+	return dm.catalog.Export(dest, files)
+}
+
+// ImportCatalog 导入其他机器导出的目录缓存和文件，较新的缓存条目不会被覆盖；文件放入下载缓存
+func (dm *DownloadManager) ImportCatalog(src string) (int, []string, error) {
+	reader, err := zip.OpenReader(src)
+	if err != nil {
+		return 0, nil, fmt.Errorf("打开导出文件失败: %w", err)
+	}
+	defer reader.Close()
+
+	// 解压到下载目录中的临时目录，保证之后可以直接移动文件
+	if err := os.MkdirAll(dm.GetDownloadDir(), 0755); err != nil {
+		return 0, nil, fmt.Errorf("创建下载目录失败: %w", err)
+	}
+	staging, err := os.MkdirTemp(dm.GetDownloadDir(), "import-")
+	if err != nil {
+		return 0, nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if _, err := archive.Extract(&reader.Reader, "", staging); err != nil {
+		return 0, nil, err
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(staging, "catalog", "*.json"))
+	imported := 0
+	for _, file := range entries {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry catalogEntry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.Endpoint == "" {
+			continue
+		}
+		if existing, ok := dm.catalog.get(entry.Endpoint); ok && !existing.FetchedAt.Before(entry.FetchedAt) {
+			continue
+		}
+		if err := os.MkdirAll(dm.catalog.dir, 0755); err != nil {
+			return imported, nil, fmt.Errorf("创建目录缓存失败: %w", err)
+		}
+		if err := os.WriteFile(dm.catalog.path(entry.Endpoint), data, 0644); err != nil {
+			return imported, nil, fmt.Errorf("写入目录缓存失败: %w", err)
+		}
+		imported++
+	}
+
+	var files []string
+	jars, _ := filepath.Glob(filepath.Join(staging, "files", "*"))
+	for _, jar := range jars {
+		target := dm.GetDownloadedFilePath(filepath.Base(jar))
+		if err := os.Rename(jar, target); err != nil {
+			return imported, files, fmt.Errorf("移动文件失败: %w", err)
+		}
+		dm.cacheFile(target, "", "")
+		files = append(files, filepath.Base(jar))
+	}
+	return imported, files, nil
+}
+
+// CatalogStatus 返回并重置最近一次浏览时的目录缓存使用情况
+func (dm *DownloadManager) CatalogStatus() CatalogStatus {
+	return dm.catalog.Status()
+}
+
+// Catalog 获取目录缓存
+func (dm *DownloadManager) Catalog() *Catalog {
+	return dm.catalog
+}
@@ -0,0 +1,250 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrNoChecksum 下载源没有提供校验和
+var ErrNoChecksum = errors.New("下载源未提供校验和，无法校验 (可设置 download.verify_checksum: false 跳过)")
+
+// hashAlgorithms 按优先级排列的支持的哈希算法
+var hashAlgorithms = []string{"sha512", "sha256", "sha1"}
+
+// Checksums 文件的哈希值，键为算法名 (sha1、sha256、sha512)
+type Checksums map[string]string
+
+// Strongest 返回最强的可用算法及其哈希值，没有可用的哈希时返回空字符串
+func (c Checksums) Strongest() (string, string) {
+	for _, algorithm := range hashAlgorithms {
+		if value := c[algorithm]; value != "" {
+			return algorithm, value
+		}
+	}
+	return "", ""
+}
+
+// NewHash 创建指定算法的哈希
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("不支持的哈希算法: %s", algorithm)
+	}
+}
+
+// VerifyChecksums 使用最强的可用算法校验文件，没有可用的哈希时返回ErrNoChecksum
+func VerifyChecksums(filePath string, sums Checksums) error {
+	algorithm, _ := sums.Strongest()
+	if algorithm == "" {
+		return ErrNoChecksum
+	}
+
+	verifier := newChecksumVerifier(sums, nil, nil)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(verifier, file); err != nil {
+		return fmt.Errorf("计算文件哈希失败: %w", err)
+	}
+	_, err = verifier.verify()
+	return err
+}
+
+// checksumVerifier 在写入数据的同时计算哈希，下载完成后无需再次读取文件
+//
+// 始终计算SHA1（下载缓存以SHA1为键）；配置了签名公钥时同时计算SHA-512用于校验签名。
+type checksumVerifier struct {
+	expected  Checksums
+	hashes    map[string]hash.Hash
+	writer    io.Writer
+	key       ed25519.PublicKey
+	signature []byte
+}
+
+// newChecksumVerifier 创建校验器，key和signature为空时不校验签名
+func newChecksumVerifier(expected Checksums, key ed25519.PublicKey, signature []byte) *checksumVerifier {
+	v := &checksumVerifier{
+		expected:  expected,
+		hashes:    map[string]hash.Hash{"sha1": sha1.New()},
+		key:       key,
+		signature: signature,
+	}
+	if algorithm, _ := expected.Strongest(); algorithm != "" {
+		if _, ok := v.hashes[algorithm]; !ok {
+			v.hashes[algorithm], _ = NewHash(algorithm)
+		}
+	}
+	if key != nil {
+		if _, ok := v.hashes["sha512"]; !ok {
+			v.hashes["sha512"] = sha512.New()
+		}
+	}
+
+	writers := make([]io.Writer, 0, len(v.hashes))
+	for _, h := range v.hashes {
+		writers = append(writers, h)
+	}
+	v.writer = io.MultiWriter(writers...)
+	return v
+}
+
+func (v *checksumVerifier) Write(p []byte) (int, error) {
+	return v.writer.Write(p)
+}
+
+// verify 比较计算出的哈希和签名，返回计算出的哈希
+func (v *checksumVerifier) verify() (Checksums, error) {
+	actual := make(Checksums, len(v.hashes))
+	for algorithm, h := range v.hashes {
+		actual[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	if algorithm, expected := v.expected.Strongest(); algorithm != "" {
+		if !strings.EqualFold(actual[algorithm], expected) {
+			return nil, fmt.Errorf("文件校验失败 (%s): 期望 %s, 实际 %s", algorithm, expected, actual[algorithm])
+		}
+	}
+
+	if v.key != nil {
+		digest, _ := hex.DecodeString(actual["sha512"])
+		if err := ed25519.VerifyWithOptions(v.key, digest, v.signature, &ed25519.Options{Hash: crypto.SHA512}); err != nil {
+			return nil, fmt.Errorf("签名校验失败: %w", err)
+		}
+	}
+
+	return actual, nil
+}
+
+// ParseChecksumFile 解析校验和文件，支持只包含哈希值、"哈希 文件名"（sha256sum格式）
+// 和 "SHA256 (文件名) = 哈希"（BSD格式），多行时按文件名匹配
+func ParseChecksumFile(data []byte, fileName string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var only string
+	lines := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines++
+
+		// BSD格式
+		if open := strings.Index(line, " ("); open > 0 {
+			if close := strings.Index(line, ") = "); close > open {
+				if line[open+2:close] == fileName {
+					return validHex(line[close+4:])
+				}
+				continue
+			}
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 1 {
+			only = fields[0]
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == fileName {
+			return validHex(fields[0])
+		}
+		only = fields[0]
+	}
+
+	if lines == 1 && only != "" {
+		return validHex(only)
+	}
+	return "", fmt.Errorf("校验和文件中没有 %s", fileName)
+}
+
+// validHex 检查哈希值是否为十六进制
+func validHex(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if _, err := hex.DecodeString(value); err != nil || value == "" {
+		return "", fmt.Errorf("无效的哈希值: %s", value)
+	}
+	return value, nil
+}
+
+// ParseSigningKey 解析base64编码的Ed25519公钥
+func ParseSigningKey(value string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("无效的Ed25519公钥")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// fetchSmall 下载校验和、签名等小文件
+func (d *Downloader) fetchSmall(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", d.userAgent)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d %s", resp.StatusCode, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+}
+
+// fetchDetachedChecksum 读取下载地址旁发布的校验和文件 (url.sha512、url.sha256、url.sha1)
+func (d *Downloader) fetchDetachedChecksum(ctx context.Context, url string) Checksums {
+	fileName := path.Base(strings.SplitN(url, "?", 2)[0])
+	for _, algorithm := range hashAlgorithms {
+		data, err := d.fetchSmall(ctx, url+"."+algorithm)
+		if err != nil {
+			continue
+		}
+		if value, err := ParseChecksumFile(data, fileName); err == nil {
+			return Checksums{algorithm: value}
+		}
+	}
+	return nil
+}
+
+// fetchSignature 读取下载地址旁发布的签名 (url.sig)，内容为原始或base64编码的Ed25519ph签名
+func (d *Downloader) fetchSignature(ctx context.Context, url string) ([]byte, error) {
+	data, err := d.fetchSmall(ctx, url+".sig")
+	if err != nil {
+		return nil, fmt.Errorf("获取签名失败: %w", err)
+	}
+	if len(data) == ed25519.SignatureSize {
+		return data, nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("无效的签名文件: %s.sig", url)
+	}
+	return signature, nil
+}
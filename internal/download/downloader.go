@@ -1,7 +1,10 @@
 package download
 
 import (
-	"crypto/sha1"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"easilypanel/internal/httpclient"
 )
 
 // ProgressCallback 下载进度回调函数
@@ -19,21 +24,29 @@ type ProgressCallback func(downloaded, total int64, percent float64)
 type Downloader struct {
 	httpClient *http.Client
 	userAgent  string
+
+	requireChecksum bool
+	signingKeys     map[string]ed25519.PublicKey // 下载源名称 -> 签名公钥
+	limiter         *RateLimiter                 // 全局限速，所有下载共享
+	idleTimeout     time.Duration                // 读取响应体时允许的最长停滞时间
 }
 
+// defaultIdleTimeout 下载过程中没有收到任何数据的最长时间，超过后中止（临时文件保留以便续传）
+const defaultIdleTimeout = 2 * time.Minute
+
 // NewDownloader 创建新的下载器
 func NewDownloader() *Downloader {
 	return &Downloader{
-		httpClient: &http.Client{
-			Timeout: 5 * time.Minute, // 5分钟超时
-		},
-		userAgent: "EasilyPanel5/1.0.0",
+		httpClient:  httpclient.NewDownload(), // 不限制总时长，只检测停滞
+		userAgent:   httpclient.UserAgent(),
+		limiter:     NewRateLimiter(0),
+		idleTimeout: defaultIdleTimeout,
 	}
 }
 
-// SetTimeout 设置下载超时时间
+// SetTimeout 设置下载停滞超时时间：超过该时间没有收到数据时中止下载，0表示不检测
 func (d *Downloader) SetTimeout(timeout time.Duration) {
-	d.httpClient.Timeout = timeout
+	d.idleTimeout = timeout
 }
 
 // SetUserAgent 设置User-Agent
@@ -41,15 +54,113 @@ func (d *Downloader) SetUserAgent(userAgent string) {
 	d.userAgent = userAgent
 }
 
+// SetRateLimit 设置所有下载共享的速率上限（每秒字节数），0表示不限速，可在下载过程中调整
+func (d *Downloader) SetRateLimit(bytesPerSecond int64) {
+	d.limiter.SetRate(bytesPerSecond)
+}
+
+// RateLimit 获取全局速率上限
+func (d *Downloader) RateLimit() int64 {
+	return d.limiter.Rate()
+}
+
+// SetRequireChecksum 设置是否要求校验和（download.verify_checksum），
+// 开启后下载源和地址旁都没有校验和的下载会失败
+func (d *Downloader) SetRequireChecksum(require bool) {
+	d.requireChecksum = require
+}
+
+// SetSigningKey 设置下载源的Ed25519公钥，之后该下载源的文件必须附带有效的 .sig 签名
+func (d *Downloader) SetSigningKey(source string, key ed25519.PublicKey) {
+	if d.signingKeys == nil {
+		d.signingKeys = make(map[string]ed25519.PublicKey)
+	}
+	d.signingKeys[source] = key
+}
+
+// partialMeta 未完成下载的校验信息，保存在 .tmp.json 中，用于断点续传
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validator 返回可用于 If-Range 的校验值（弱ETag不能用于 If-Range）
+func (m *partialMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// loadPartial 读取未完成的下载，返回已下载的字节数和校验信息
+func loadPartial(tempPath, url string) (int64, *partialMeta) {
+	info, err := os.Stat(tempPath)
+	if err != nil || info.Size() == 0 {
+		return 0, nil
+	}
+	data, err := os.ReadFile(tempPath + ".json")
+	if err != nil {
+		return 0, nil
+	}
+	var meta partialMeta
+	if err := json.Unmarshal(data, &meta); err != nil || meta.URL != url || meta.validator() == "" {
+		return 0, nil
+	}
+	return info.Size(), &meta
+}
+
+// savePartial 保存响应的校验信息，服务器不支持范围请求时删除
+func savePartial(tempPath, url string, resp *http.Response) {
+	metaPath := tempPath + ".json"
+	meta := partialMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.Header.Get("Accept-Ranges") != "bytes" || meta.validator() == "" {
+		os.Remove(metaPath)
+		return
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	os.WriteFile(metaPath, data, 0644)
+}
+
+// removePartial 删除未完成的下载
+func removePartial(tempPath string) {
+	os.Remove(tempPath)
+	os.Remove(tempPath + ".json")
+}
+
 // DownloadFile 下载文件
+//
+// 未完成的下载保留在 destPath.tmp 中。服务器声明 Accept-Ranges 并提供ETag或Last-Modified时，
+// 再次下载会用 Range 从断点继续，并用 If-Range 确保远程文件没有变化，否则从头下载。
 func (d *Downloader) DownloadFile(url, destPath string, callback ProgressCallback) error {
+	return d.DownloadFileContext(context.Background(), url, destPath, callback)
+}
+
+// DownloadFileContext 下载文件，ctx取消时中止下载（保留临时文件以便续传）
+func (d *Downloader) DownloadFileContext(ctx context.Context, url, destPath string, callback ProgressCallback) error {
+	return d.downloadFile(ctx, url, destPath, callback, nil)
+}
+
+// downloadFile 下载文件，verifier不为空时在下载的同时计算哈希，校验失败的文件不会保留
+func (d *Downloader) downloadFile(ctx context.Context, url, destPath string, callback ProgressCallback, verifier *checksumVerifier) error {
 	// 创建目标目录
 	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
 		return fmt.Errorf("创建目录失败: %w", err)
 	}
 	
+	// 读取停滞时通过取消请求中止下载
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	
 	// 创建HTTP请求
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -57,6 +168,14 @@ func (d *Downloader) DownloadFile(url, destPath string, callback ProgressCallbac
 	// 设置User-Agent
 	req.Header.Set("User-Agent", d.userAgent)
 	
+	// 存在可续传的临时文件时请求剩余部分
+	tempPath := destPath + ".tmp"
+	offset, meta := loadPartial(tempPath, url)
+	if meta != nil {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", meta.validator())
+	}
+	
 	// 发送请求
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
@@ -65,7 +184,20 @@ func (d *Downloader) DownloadFile(url, destPath string, callback ProgressCallbac
 	defer resp.Body.Close()
 	
 	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		if meta == nil || contentRangeStart(resp) != offset {
+			removePartial(tempPath)
+			return fmt.Errorf("下载失败: 续传位置不匹配，已删除临时文件")
+		}
+		// 从断点继续
+	case resp.StatusCode == http.StatusOK:
+		// 服务器不支持范围请求或文件已变化，从头下载
+		offset = 0
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		removePartial(tempPath)
+		return fmt.Errorf("下载失败: 断点无效，已删除临时文件")
+	default:
 		return fmt.Errorf("下载失败: HTTP %d %s", resp.StatusCode, resp.Status)
 	}
 	
@@ -74,61 +206,199 @@ func (d *Downloader) DownloadFile(url, destPath string, callback ProgressCallbac
 	var totalSize int64
 	if contentLength != "" {
 		if size, err := strconv.ParseInt(contentLength, 10, 64); err == nil {
-			totalSize = size
+			totalSize = offset + size
 		}
 	}
 	
-	// 创建临时文件
-	tempPath := destPath + ".tmp"
-	file, err := os.Create(tempPath)
+	// 打开临时文件（续传时追加）
+	var file *os.File
+	if offset > 0 {
+		file, err = os.OpenFile(tempPath, os.O_WRONLY|os.O_APPEND, 0644)
+	} else {
+		file, err = os.Create(tempPath)
+		savePartial(tempPath, url, resp)
+	}
 	if err != nil {
 		return fmt.Errorf("创建临时文件失败: %w", err)
 	}
 	defer file.Close()
 	
-	// 创建进度读取器
-	var reader io.Reader = resp.Body
+	// 续传时先计算已下载部分的哈希
+	var writer io.Writer = file
+	if verifier != nil {
+		if offset > 0 {
+			if err := hashPrefix(tempPath, offset, verifier); err != nil {
+				return err
+			}
+		}
+		writer = io.MultiWriter(file, verifier)
+	}
+	
+	// 限速（全局限速和任务单独的限速同时生效），再创建进度读取器
+	jobLimiter, _ := ctx.Value(rateLimiterKey{}).(*RateLimiter)
+	var body io.Reader = resp.Body
+	if d.idleTimeout > 0 {
+		body = newIdleReader(resp.Body, d.idleTimeout, cancel)
+	}
+	reader := newLimitedReader(ctx, body, d.limiter, jobLimiter)
 	if callback != nil && totalSize > 0 {
 		reader = &progressReader{
-			reader:   resp.Body,
-			total:    totalSize,
-			callback: callback,
+			reader:     reader,
+			total:      totalSize,
+			downloaded: offset,
+			callback:   callback,
 		}
 	}
 	
-	// 复制数据
-	_, err = io.Copy(file, reader)
+	// 复制数据（失败时保留临时文件以便续传）
+	_, err = io.Copy(writer, reader)
 	if err != nil {
-		os.Remove(tempPath) // 清理临时文件
+		if cause := context.Cause(ctx); errors.Is(cause, errStalled) {
+			err = cause
+		}
 		return fmt.Errorf("下载文件失败: %w", err)
 	}
 	
 	// 关闭文件
 	file.Close()
 	
+	if verifier != nil {
+		if _, err := verifier.verify(); err != nil {
+			removePartial(tempPath)
+			return err
+		}
+	}
+	
 	// 重命名临时文件为目标文件
 	if err := os.Rename(tempPath, destPath); err != nil {
-		os.Remove(tempPath) // 清理临时文件
+		removePartial(tempPath) // 清理临时文件
 		return fmt.Errorf("重命名文件失败: %w", err)
 	}
+	os.Remove(tempPath + ".json")
 	
 	return nil
 }
 
+// errStalled 下载停滞超时
+var errStalled = errors.New("下载停滞，长时间没有收到数据")
+
+// idleReader 单次读取阻塞超过timeout时调用cancel中止请求；限速等待不计入停滞时间
+type idleReader struct {
+	reader  io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleReader(reader io.Reader, timeout time.Duration, cancel context.CancelCauseFunc) *idleReader {
+	timer := time.AfterFunc(timeout, func() { cancel(errStalled) })
+	timer.Stop()
+	return &idleReader{reader: reader, timeout: timeout, timer: timer}
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	r.timer.Reset(r.timeout)
+	n, err := r.reader.Read(p)
+	r.timer.Stop()
+	return n, err
+}
+
+// hashPrefix 计算临时文件中已下载部分的哈希
+func hashPrefix(tempPath string, size int64, verifier *checksumVerifier) error {
+	file, err := os.Open(tempPath)
+	if err != nil {
+		return fmt.Errorf("打开临时文件失败: %w", err)
+	}
+	defer file.Close()
+	if _, err := io.CopyN(verifier, file, size); err != nil {
+		return fmt.Errorf("计算文件哈希失败: %w", err)
+	}
+	return nil
+}
+
+// contentRangeStart 解析 Content-Range 的起始位置，无法解析时返回-1
+func contentRangeStart(resp *http.Response) int64 {
+	// 格式: bytes 100-199/200
+	value, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(value, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
 // DownloadWithRetry 带重试的下载
 func (d *Downloader) DownloadWithRetry(url, destPath string, retries int, callback ProgressCallback) error {
+	return d.DownloadWithRetryContext(context.Background(), url, destPath, retries, callback)
+}
+
+// DownloadWithRetryContext 带重试的下载，ctx取消后不再重试
+func (d *Downloader) DownloadWithRetryContext(ctx context.Context, url, destPath string, retries int, callback ProgressCallback) error {
+	return d.retry(ctx, retries, func() error {
+		return d.DownloadFileContext(ctx, url, destPath, callback)
+	})
+}
+
+// DownloadVerifiedContext 带重试的下载，下载的同时校验哈希，返回计算出的哈希（始终包含SHA1）
+//
+// sums为空时尝试读取地址旁的校验和文件；仍然没有且要求校验和时返回ErrNoChecksum。
+// 下载源配置了签名公钥时，还要求 url.sig 是对文件SHA-512的有效Ed25519ph签名。
+func (d *Downloader) DownloadVerifiedContext(ctx context.Context, url, destPath, source string, sums Checksums, retries int, callback ProgressCallback) (Checksums, error) {
+	if algorithm, _ := sums.Strongest(); algorithm == "" && d.requireChecksum {
+		sums = d.fetchDetachedChecksum(ctx, url)
+		if algorithm, _ := sums.Strongest(); algorithm == "" {
+			return nil, ErrNoChecksum
+		}
+	}
+
+	key := d.signingKeys[source]
+	var signature []byte
+	if key != nil {
+		var err error
+		if signature, err = d.fetchSignature(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+
+	var actual Checksums
+	err := d.retry(ctx, retries, func() error {
+		verifier := newChecksumVerifier(sums, key, signature)
+		if err := d.downloadFile(ctx, url, destPath, callback, verifier); err != nil {
+			return err
+		}
+		actual, _ = verifier.verify()
+		return nil
+	})
+	return actual, err
+}
+
+// retry 执行下载直到成功或达到重试次数
+func (d *Downloader) retry(ctx context.Context, retries int, download func() error) error {
 	var lastErr error
 	
 	for i := 0; i <= retries; i++ {
 		if i > 0 {
 			fmt.Printf("下载失败，正在重试 (%d/%d)...\n", i, retries)
-			time.Sleep(time.Duration(i) * time.Second) // 递增延迟
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(i) * time.Second): // 递增延迟
+			}
 		}
 		
-		err := d.DownloadFile(url, destPath, callback)
+		err := download()
 		if err == nil {
 			return nil
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		
 		lastErr = err
 	}
@@ -136,29 +406,17 @@ func (d *Downloader) DownloadWithRetry(url, destPath string, retries int, callba
 	return fmt.Errorf("下载失败，已重试 %d 次: %w", retries, lastErr)
 }
 
-// VerifyFile 验证文件SHA1校验和
+// VerifyFile 验证文件SHA1校验和，没有提供校验和时跳过（要求校验和时返回ErrNoChecksum）
 func (d *Downloader) VerifyFile(filePath, expectedSHA1 string) error {
-	if expectedSHA1 == "" {
-		return nil // 没有提供校验和，跳过验证
-	}
-	
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
-	}
-	defer file.Close()
-	
-	hash := sha1.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return fmt.Errorf("计算文件哈希失败: %w", err)
-	}
-	
-	actualSHA1 := fmt.Sprintf("%x", hash.Sum(nil))
-	if !strings.EqualFold(actualSHA1, expectedSHA1) {
-		return fmt.Errorf("文件校验失败: 期望 %s, 实际 %s", expectedSHA1, actualSHA1)
+	return d.VerifyChecksums(filePath, Checksums{"sha1": expectedSHA1})
+}
+
+// VerifyChecksums 使用最强的可用算法校验文件，没有可用的哈希时跳过（要求校验和时返回ErrNoChecksum）
+func (d *Downloader) VerifyChecksums(filePath string, sums Checksums) error {
+	if algorithm, _ := sums.Strongest(); algorithm == "" && !d.requireChecksum {
+		return nil
 	}
-	
-	return nil
+	return VerifyChecksums(filePath, sums)
 }
 
 // GetFileSize 获取远程文件大小
@@ -240,14 +498,25 @@ func FormatSpeed(bytesPerSecond float64) string {
 	return FormatBytes(int64(bytesPerSecond)) + "/s"
 }
 
+// speedWindow 计算下载速度的时间窗口，限速或网络变化后速度能较快反映出来
+const speedWindow = 5 * time.Second
+
+// progressSample 进度采样
+type progressSample struct {
+	at         time.Time
+	downloaded int64
+}
+
 // DownloadProgress 下载进度信息
 type DownloadProgress struct {
 	Downloaded int64
 	Total      int64
 	Percent    float64
-	Speed      float64 // 字节/秒
+	Speed      float64 // 字节/秒，最近几秒的平均速度
 	ETA        time.Duration
 	StartTime  time.Time
+
+	samples []progressSample
 }
 
 // NewDownloadProgress 创建下载进度跟踪器
@@ -258,7 +527,10 @@ func NewDownloadProgress() *DownloadProgress {
 }
 
 // Update 更新进度信息
+//
+// 速度按最近几秒的采样计算：续传时已下载的部分不计入速度，限速调整后速度也能及时变化。
 func (dp *DownloadProgress) Update(downloaded, total int64) {
+	now := time.Now()
 	dp.Downloaded = downloaded
 	dp.Total = total
 	
@@ -266,26 +538,47 @@ func (dp *DownloadProgress) Update(downloaded, total int64) {
 		dp.Percent = float64(downloaded) / float64(total) * 100
 	}
 	
-	elapsed := time.Since(dp.StartTime)
-	if elapsed.Seconds() > 0 {
-		dp.Speed = float64(downloaded) / elapsed.Seconds()
-		
-		if dp.Speed > 0 && total > downloaded {
-			remaining := total - downloaded
-			dp.ETA = time.Duration(float64(remaining)/dp.Speed) * time.Second
-		}
+	if len(dp.samples) == 0 {
+		// 第一次采样作为起点（续传时为断点位置）
+		dp.samples = append(dp.samples, progressSample{at: dp.StartTime, downloaded: downloaded})
+		return
+	}
+	dp.samples = append(dp.samples, progressSample{at: now, downloaded: downloaded})
+	
+	// 丢弃窗口外的采样，至少保留一个作为起点
+	cut := 0
+	for cut < len(dp.samples)-2 && now.Sub(dp.samples[cut+1].at) >= speedWindow {
+		cut++
+	}
+	dp.samples = dp.samples[cut:]
+	
+	first := dp.samples[0]
+	if elapsed := now.Sub(first.at).Seconds(); elapsed > 0 {
+		dp.Speed = float64(downloaded-first.downloaded) / elapsed
+	}
+	
+	dp.ETA = 0
+	if dp.Speed > 0 && total > downloaded {
+		remaining := total - downloaded
+		dp.ETA = time.Duration(float64(remaining) / dp.Speed * float64(time.Second))
 	}
 }
 
 // String 返回进度的字符串表示
 func (dp *DownloadProgress) String() string {
 	if dp.Total > 0 {
-		return fmt.Sprintf("%.1f%% (%s/%s) %s ETA: %v",
+		eta := "--"
+		if dp.ETA > 0 {
+			eta = dp.ETA.Round(time.Second).String()
+		} else if dp.Downloaded >= dp.Total {
+			eta = "0s"
+		}
+		return fmt.Sprintf("%.1f%% (%s/%s) %s ETA: %s",
 			dp.Percent,
 			FormatBytes(dp.Downloaded),
 			FormatBytes(dp.Total),
 			FormatSpeed(dp.Speed),
-			dp.ETA.Round(time.Second))
+			eta)
 	}
 	return fmt.Sprintf("%s %s",
 		FormatBytes(dp.Downloaded),
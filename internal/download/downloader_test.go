@@ -0,0 +1,244 @@
+package download
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rangeServer 支持范围请求的测试服务器，可以在传输途中断开连接或返回异常的范围响应
+type rangeServer struct {
+	mu        sync.Mutex
+	content   []byte
+	etag      string
+	dropAfter int           // 大于0时，下一次完整响应只发送这么多字节后断开连接
+	stall     time.Duration // 大于0时，发送一半数据后停顿这么久
+	rangeMode string        // "" 正常处理Range，"416" 返回416，"shift" 返回错误的起始位置
+	requests  []*http.Request
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Clone(r.Context()))
+	content, etag := s.content, s.etag
+	dropAfter, stall, rangeMode := s.dropAfter, s.stall, s.rangeMode
+	s.dropAfter = 0
+	s.mu.Unlock()
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", etag)
+
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader != "" && r.Header.Get("If-Range") == etag {
+		start, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+		if err != nil {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		switch rangeMode {
+		case "416":
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(content)))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		case "shift":
+			start--
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)-start))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(content[start:])
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	switch {
+	case dropAfter > 0:
+		w.Write(content[:dropAfter])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	case stall > 0:
+		w.Write(content[:len(content)/2])
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(stall):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write(content[len(content)/2:])
+	default:
+		w.Write(content)
+	}
+}
+
+// lastRequest 返回最后一次请求
+func (s *rangeServer) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func newRangeServer(t *testing.T, content []byte) (*rangeServer, string) {
+	t.Helper()
+	rs := &rangeServer{content: content, etag: `"v1"`}
+	server := httptest.NewServer(rs)
+	t.Cleanup(server.Close)
+	return rs, server.URL + "/file.jar"
+}
+
+func testContent(size int) []byte {
+	return bytes.Repeat([]byte("0123456789abcdef"), size/16)
+}
+
+// interruptedDownload 第一次下载在传输途中断开，返回目标路径
+func interruptedDownload(t *testing.T, rs *rangeServer, url string, d *Downloader) string {
+	t.Helper()
+	dest := filepath.Join(t.TempDir(), "file.jar")
+	rs.dropAfter = len(rs.content) / 2
+	if err := d.DownloadFile(url, dest, nil); err == nil {
+		t.Fatal("断开连接的下载应当失败")
+	}
+	info, err := os.Stat(dest + ".tmp")
+	if err != nil {
+		t.Fatalf("断开后应保留临时文件: %v", err)
+	}
+	if info.Size() != int64(len(rs.content)/2) {
+		t.Fatalf("临时文件大小 = %d, 期望 %d", info.Size(), len(rs.content)/2)
+	}
+	return dest
+}
+
+func TestDownloadResumesAfterDrop(t *testing.T) {
+	content := testContent(64 * 1024)
+	rs, url := newRangeServer(t, content)
+	d := NewDownloader()
+
+	dest := interruptedDownload(t, rs, url, d)
+	if err := d.DownloadFile(url, dest, nil); err != nil {
+		t.Fatalf("续传失败: %v", err)
+	}
+
+	req := rs.lastRequest()
+	if got, want := req.Header.Get("Range"), fmt.Sprintf("bytes=%d-", len(content)/2); got != want {
+		t.Errorf("Range = %q, 期望 %q", got, want)
+	}
+	if got := req.Header.Get("If-Range"); got != `"v1"` {
+		t.Errorf("If-Range = %q, 期望 %q", got, `"v1"`)
+	}
+	assertFile(t, dest, content)
+}
+
+func TestDownloadRestartsWhenETagChanges(t *testing.T) {
+	content := testContent(64 * 1024)
+	rs, url := newRangeServer(t, content)
+	d := NewDownloader()
+
+	dest := interruptedDownload(t, rs, url, d)
+
+	// 文件在两次请求之间被替换，服务器忽略Range返回完整的新文件
+	updated := bytes.ToUpper(content)
+	rs.mu.Lock()
+	rs.content, rs.etag = updated, `"v2"`
+	rs.mu.Unlock()
+
+	if err := d.DownloadFile(url, dest, nil); err != nil {
+		t.Fatalf("重新下载失败: %v", err)
+	}
+	if got := rs.lastRequest().Header.Get("If-Range"); got != `"v1"` {
+		t.Errorf("If-Range = %q, 期望旧的ETag", got)
+	}
+	assertFile(t, dest, updated)
+}
+
+func TestDownloadDiscardsPartialOn416(t *testing.T) {
+	content := testContent(64 * 1024)
+	rs, url := newRangeServer(t, content)
+	d := NewDownloader()
+
+	dest := interruptedDownload(t, rs, url, d)
+	rs.rangeMode = "416"
+
+	if err := d.DownloadFile(url, dest, nil); err == nil {
+		t.Fatal("416时应当返回错误")
+	}
+	assertNoPartial(t, dest)
+
+	// 临时文件已删除，再次下载从头开始
+	rs.rangeMode = ""
+	if err := d.DownloadFile(url, dest, nil); err != nil {
+		t.Fatalf("重新下载失败: %v", err)
+	}
+	if got := rs.lastRequest().Header.Get("Range"); got != "" {
+		t.Errorf("删除临时文件后不应再发送Range: %q", got)
+	}
+	assertFile(t, dest, content)
+}
+
+func TestDownloadRejectsMismatchedContentRange(t *testing.T) {
+	content := testContent(64 * 1024)
+	rs, url := newRangeServer(t, content)
+	d := NewDownloader()
+
+	dest := interruptedDownload(t, rs, url, d)
+	rs.rangeMode = "shift"
+
+	err := d.DownloadFile(url, dest, nil)
+	if err == nil || !strings.Contains(err.Error(), "续传位置不匹配") {
+		t.Fatalf("起始位置不匹配时应当失败, err = %v", err)
+	}
+	assertNoPartial(t, dest)
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("不应生成目标文件")
+	}
+}
+
+func TestDownloadAbortsStalledTransfer(t *testing.T) {
+	content := testContent(64 * 1024)
+	rs, url := newRangeServer(t, content)
+	rs.stall = 5 * time.Second
+	d := NewDownloader()
+	d.SetTimeout(200 * time.Millisecond)
+
+	dest := filepath.Join(t.TempDir(), "file.jar")
+	start := time.Now()
+	err := d.DownloadFile(url, dest, nil)
+	if !errors.Is(err, errStalled) {
+		t.Fatalf("err = %v, 期望停滞超时", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("停滞检测耗时 %v", elapsed)
+	}
+	if info, err := os.Stat(dest + ".tmp"); err != nil || info.Size() != int64(len(content)/2) {
+		t.Errorf("停滞后应保留已下载的部分: %v", err)
+	}
+}
+
+func assertFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取下载的文件失败: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("文件内容不一致: 长度 %d, 期望 %d", len(got), len(want))
+	}
+	assertNoPartial(t, path)
+}
+
+func assertNoPartial(t *testing.T, dest string) {
+	t.Helper()
+	for _, path := range []string{dest + ".tmp", dest + ".tmp.json"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s 应当已删除", filepath.Base(path))
+		}
+	}
+}
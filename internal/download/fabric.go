@@ -0,0 +1,147 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"easilypanel/internal/httpclient"
+)
+
+// fabricGameVersion Fabric支持的游戏版本
+type fabricGameVersion struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// fabricLoaderEntry 指定游戏版本可用的加载器
+type fabricLoaderEntry struct {
+	Loader struct {
+		Version string `json:"version"`
+		Stable  bool   `json:"stable"`
+	} `json:"loader"`
+}
+
+// fabricInstaller Fabric安装器版本
+type fabricInstaller struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// FabricProvider 从Fabric meta下载服务端启动器jar
+type FabricProvider struct {
+	dm      *DownloadManager
+	baseURL string
+}
+
+// Name 服务端类型名称
+func (p *FabricProvider) Name() string {
+	return "fabric"
+}
+
+// client 创建HTTP客户端
+func (p *FabricProvider) client() *http.Client {
+	return httpclient.New(0)
+}
+
+// ListVersions 列出Fabric支持的正式版本
+func (p *FabricProvider) ListVersions() ([]string, error) {
+	var games []fabricGameVersion
+	if err := getJSON(p.client(), p.baseURL+"/versions/game", &games); err != nil {
+		return nil, fmt.Errorf("获取Fabric游戏版本失败: %w", err)
+	}
+
+	var versions []string
+	for _, game := range games {
+		if game.Stable {
+			versions = append(versions, game.Version)
+		}
+	}
+	return versions, nil
+}
+
+// ListBuilds 列出加载器版本，稳定版在前
+func (p *FabricProvider) ListBuilds(mcVersion string) ([]string, error) {
+	var entries []fabricLoaderEntry
+	if err := getJSON(p.client(), p.baseURL+"/versions/loader/"+url.PathEscape(mcVersion), &entries); err != nil {
+		return nil, fmt.Errorf("获取Fabric加载器版本失败: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("Fabric不支持Minecraft %s", mcVersion)
+	}
+
+	var stable, unstable []string
+	for _, entry := range entries {
+		if entry.Loader.Stable {
+			stable = append(stable, entry.Loader.Version)
+		} else {
+			unstable = append(unstable, entry.Loader.Version)
+		}
+	}
+	return append(stable, unstable...), nil
+}
+
+// latestInstaller 获取最新的稳定版安装器
+func (p *FabricProvider) latestInstaller() (string, error) {
+	var installers []fabricInstaller
+	if err := getJSON(p.client(), p.baseURL+"/versions/installer", &installers); err != nil {
+		return "", fmt.Errorf("获取Fabric安装器版本失败: %w", err)
+	}
+	for _, installer := range installers {
+		if installer.Stable {
+			return installer.Version, nil
+		}
+	}
+	if len(installers) > 0 {
+		return installers[0].Version, nil
+	}
+	return "", fmt.Errorf("没有可用的Fabric安装器")
+}
+
+// Install 下载服务端启动器jar（首次启动时会自动下载原版服务端和依赖库）
+func (p *FabricProvider) Install(req InstallRequest) (*CoreInstall, error) {
+	loader := req.Build
+	if loader == "" {
+		builds, err := p.ListBuilds(req.MCVersion)
+		if err != nil {
+			return nil, err
+		}
+		loader = builds[0]
+	}
+
+	installer, err := p.latestInstaller()
+	if err != nil {
+		return nil, err
+	}
+
+	downloadURL := fmt.Sprintf("%s/versions/loader/%s/%s/%s/server/jar",
+		p.baseURL, url.PathEscape(req.MCVersion), url.PathEscape(loader), url.PathEscape(installer))
+	fileName := fmt.Sprintf("fabric-server-mc.%s-loader.%s-launcher.%s.jar", req.MCVersion, loader, installer)
+
+	// Fabric meta不提供校验和，记录下载后的SHA1用于之后的完整性校验
+	path, err := p.dm.fetchCached(downloadURL, fileName, "", "fabric-meta", req.ShowProgress)
+	if err != nil {
+		return nil, err
+	}
+	sha1, err := fileSHA1(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.dm.LinkToWorkDir(path, sha1, req.WorkDir, fileName); err != nil {
+		return nil, err
+	}
+
+	return &CoreInstall{
+		ServerJar: fileName,
+		Core: CoreDownload{
+			Path:        filepath.Join(req.WorkDir, fileName),
+			ServerName:  p.Name(),
+			MCVersion:   req.MCVersion,
+			CoreVersion: loader,
+			SHA1:        sha1,
+			Source:      "fabric-meta",
+			URL:         downloadURL,
+		},
+	}, nil
+}
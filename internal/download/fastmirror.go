@@ -9,6 +9,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"easilypanel/internal/httpclient"
 )
 
 const (
@@ -63,23 +65,35 @@ type CoreInfo struct {
 	CoreVersion string `json:"core_version"`
 	UpdateTime  string `json:"update_time"`
 	SHA1        string `json:"sha1"`
+	SHA256      string `json:"sha256,omitempty"`
+	SHA512      string `json:"sha512,omitempty"`
 	Filename    string `json:"filename"`
 	DownloadURL string `json:"download_url"`
 }
 
+// Checksums 下载源提供的哈希
+func (c *CoreInfo) Checksums() Checksums {
+	sums := Checksums{}
+	for algorithm, value := range map[string]string{"sha1": c.SHA1, "sha256": c.SHA256, "sha512": c.SHA512} {
+		if value != "" {
+			sums[algorithm] = value
+		}
+	}
+	return sums
+}
+
 // FastMirrorClient FastMirror API客户端
 type FastMirrorClient struct {
 	httpClient *http.Client
 	baseURL    string
+	catalog    *Catalog // API响应缓存，为空时不缓存
 }
 
 // NewFastMirrorClient 创建新的FastMirror客户端
 func NewFastMirrorClient() *FastMirrorClient {
 	return &FastMirrorClient{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		baseURL: FastMirrorAPIV3,
+		httpClient: httpclient.New(0),
+		baseURL:    FastMirrorAPIV3,
 	}
 }
 
@@ -88,8 +102,43 @@ func (c *FastMirrorClient) SetTimeout(timeout time.Duration) {
 	c.httpClient.Timeout = timeout
 }
 
-// makeRequest 发送HTTP请求
+// SetBaseURL 设置API地址
+func (c *FastMirrorClient) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetCatalog 设置API响应缓存
+func (c *FastMirrorClient) SetCatalog(catalog *Catalog) {
+	c.catalog = catalog
+}
+
+// makeRequest 发送HTTP请求，有效期内使用缓存的响应，网络不可用时使用过期的缓存
 func (c *FastMirrorClient) makeRequest(endpoint string) (*FastMirrorResponse, error) {
+	cached, ok := c.catalog.get(endpoint)
+	if ok && cached.fresh() {
+		c.catalog.used(cached, false)
+		return parseFastMirrorResponse(cached.Response)
+	}
+	
+	body, err := c.fetch(endpoint)
+	if err != nil {
+		if ok {
+			c.catalog.used(cached, true)
+			return parseFastMirrorResponse(cached.Response)
+		}
+		return nil, err
+	}
+	
+	response, err := parseFastMirrorResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	c.catalog.put(endpoint, body)
+	return response, nil
+}
+
+// fetch 请求API并返回响应内容
+func (c *FastMirrorClient) fetch(endpoint string) ([]byte, error) {
 	url := c.baseURL + endpoint
 	
 	resp, err := c.httpClient.Get(url)
@@ -106,7 +155,11 @@ func (c *FastMirrorClient) makeRequest(endpoint string) (*FastMirrorResponse, er
 	if err != nil {
 		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
-	
+	return body, nil
+}
+
+// parseFastMirrorResponse 解析API响应
+func parseFastMirrorResponse(body []byte) (*FastMirrorResponse, error) {
 	var response FastMirrorResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("解析JSON失败: %w", err)
@@ -226,7 +279,8 @@ func (c *FastMirrorClient) GetCoreInfo(name, mcVersion, coreVersion string) (*Co
 
 // GetLatestBuild 获取最新构建版本
 func (c *FastMirrorClient) GetLatestBuild(name, mcVersion string) (*BuildInfo, error) {
-	builds, err := c.GetBuilds(name, mcVersion, 0, 1)
+	// 与构建列表使用相同的请求，离线时可以共用缓存
+	builds, err := c.GetBuilds(name, mcVersion, 0, 10)
 	if err != nil {
 		return nil, err
 	}
@@ -291,3 +345,43 @@ func (c *FastMirrorClient) SearchServers(keyword string) ([]ServerInfo, error) {
 
 	return results, nil
 }
+
+// Name 下载源名称
+func (c *FastMirrorClient) Name() string {
+	return "fastmirror"
+}
+
+// ListServers 获取支持的服务端列表
+func (c *FastMirrorClient) ListServers() ([]ServerInfo, error) {
+	return c.GetServerList()
+}
+
+// ListVersions 列出服务端支持的MC版本
+func (c *FastMirrorClient) ListVersions(name string) ([]string, error) {
+	project, err := c.GetProjectInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	return project.MCVersions, nil
+}
+
+// ListBuilds 列出最新的limit个构建
+func (c *FastMirrorClient) ListBuilds(name, mcVersion string, limit int) ([]BuildInfo, error) {
+	builds, err := c.GetBuilds(name, mcVersion, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+	return builds.Builds, nil
+}
+
+// Probe 请求服务端列表以检查下载源是否可用
+func (c *FastMirrorClient) Probe() error {
+	probe := *c
+	probe.httpClient = httpclient.New(mirrorProbeTimeout)
+	body, err := probe.fetch("")
+	if err != nil {
+		return err
+	}
+	_, err = parseFastMirrorResponse(body)
+	return err
+}
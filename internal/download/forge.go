@@ -0,0 +1,261 @@
+package download
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"easilypanel/internal/httpclient"
+)
+
+// mavenMetadata Maven仓库的maven-metadata.xml
+type mavenMetadata struct {
+	Versioning struct {
+		Latest   string   `xml:"latest"`
+		Release  string   `xml:"release"`
+		Versions []string `xml:"versions>version"`
+	} `xml:"versioning"`
+}
+
+// ForgeProvider 下载Forge/NeoForge安装器并以 --installServer 安装服务端
+type ForgeProvider struct {
+	dm       *DownloadManager
+	name     string // forge 或 neoforge
+	mavenURL string
+	group    string // Maven路径，如 net/minecraftforge/forge
+	artifact string
+
+	// mcVersionOf 从Maven版本号中解析Minecraft版本
+	mcVersionOf func(mavenVersion string) string
+	// buildOf 从Maven版本号中解析加载器构建号
+	buildOf func(mavenVersion string) string
+	// mavenVersion 由Minecraft版本和构建号组成Maven版本号
+	mavenVersion func(mcVersion, build string) string
+}
+
+// newForgeProvider 创建Forge提供者，Maven版本格式为 "1.20.1-47.2.0"
+func newForgeProvider(dm *DownloadManager, mavenURL string) *ForgeProvider {
+	return &ForgeProvider{
+		dm:       dm,
+		name:     "forge",
+		mavenURL: mavenURL,
+		group:    "net/minecraftforge/forge",
+		artifact: "forge",
+		mcVersionOf: func(v string) string {
+			mc, _, _ := strings.Cut(v, "-")
+			return mc
+		},
+		buildOf: func(v string) string {
+			_, build, _ := strings.Cut(v, "-")
+			return build
+		},
+		mavenVersion: func(mc, build string) string {
+			return mc + "-" + build
+		},
+	}
+}
+
+// newNeoForgeProvider 创建NeoForge提供者，Maven版本格式为 "20.4.80-beta"、"21.1.57"
+//
+// NeoForge版本号的前两段对应Minecraft版本：20.4.x -> 1.20.4，21.0.x -> 1.21。
+func newNeoForgeProvider(dm *DownloadManager, mavenURL string) *ForgeProvider {
+	return &ForgeProvider{
+		dm:       dm,
+		name:     "neoforge",
+		mavenURL: mavenURL,
+		group:    "net/neoforged/neoforge",
+		artifact: "neoforge",
+		mcVersionOf: func(v string) string {
+			parts := strings.SplitN(v, ".", 3)
+			if len(parts) < 3 {
+				return ""
+			}
+			if parts[1] == "0" {
+				return "1." + parts[0]
+			}
+			return "1." + parts[0] + "." + parts[1]
+		},
+		buildOf: func(v string) string {
+			return v
+		},
+		mavenVersion: func(mc, build string) string {
+			return build
+		},
+	}
+}
+
+// Name 服务端类型名称
+func (p *ForgeProvider) Name() string {
+	return p.name
+}
+
+// metadata 获取Maven版本列表
+func (p *ForgeProvider) metadata() (*mavenMetadata, error) {
+	client := httpclient.New(0)
+	resp, err := client.Get(fmt.Sprintf("%s/%s/maven-metadata.xml", p.mavenURL, p.group))
+	if err != nil {
+		return nil, fmt.Errorf("获取%s版本列表失败: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取%s版本列表失败: HTTP %d", p.name, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var metadata mavenMetadata
+	if err := xml.Unmarshal(body, &metadata); err != nil {
+		return nil, fmt.Errorf("解析maven-metadata.xml失败: %w", err)
+	}
+	return &metadata, nil
+}
+
+// ListVersions 列出支持的Minecraft版本
+func (p *ForgeProvider) ListVersions() ([]string, error) {
+	metadata, err := p.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var versions []string
+	for _, v := range metadata.Versioning.Versions {
+		mc := p.mcVersionOf(v)
+		if mc != "" && !seen[mc] {
+			seen[mc] = true
+			versions = append(versions, mc)
+		}
+	}
+	sortVersionsDesc(versions)
+	return versions, nil
+}
+
+// ListBuilds 列出Minecraft版本可用的加载器构建
+func (p *ForgeProvider) ListBuilds(mcVersion string) ([]string, error) {
+	metadata, err := p.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	var builds []string
+	for _, v := range metadata.Versioning.Versions {
+		if p.mcVersionOf(v) == mcVersion {
+			builds = append(builds, p.buildOf(v))
+		}
+	}
+	if len(builds) == 0 {
+		return nil, fmt.Errorf("%s不支持Minecraft %s", p.name, mcVersion)
+	}
+	sortVersionsDesc(builds)
+	return builds, nil
+}
+
+// Install 下载安装器，在工作目录中运行 --installServer，并确定启动方式
+func (p *ForgeProvider) Install(req InstallRequest) (*CoreInstall, error) {
+	build := req.Build
+	if build == "" {
+		builds, err := p.ListBuilds(req.MCVersion)
+		if err != nil {
+			return nil, err
+		}
+		build = builds[0]
+	}
+
+	mavenVersion := p.mavenVersion(req.MCVersion, build)
+	fileName := fmt.Sprintf("%s-%s-installer.jar", p.artifact, mavenVersion)
+	installerURL := fmt.Sprintf("%s/%s/%s/%s", p.mavenURL, p.group, mavenVersion, fileName)
+
+	// Maven仓库为每个文件提供 .sha1
+	sha1, err := p.fetchSHA1(installerURL + ".sha1")
+	if err != nil {
+		return nil, err
+	}
+
+	installer, err := p.dm.fetchCached(installerURL, fileName, sha1, p.name+"-maven", req.ShowProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := runInstaller(req.JavaPath, installer, req.WorkDir); err != nil {
+		return nil, err
+	}
+
+	result := &CoreInstall{}
+
+	// 1.17以后的版本使用参数文件启动，旧版本生成可直接启动的jar
+	launchFile := filepath.ToSlash(filepath.Join("libraries", p.group, mavenVersion, argsFileName()))
+	if _, err := os.Stat(filepath.Join(req.WorkDir, filepath.FromSlash(launchFile))); err == nil {
+		result.ArgsFile = launchFile
+	} else {
+		launchFile = p.findServerJar(req.WorkDir, mavenVersion)
+		if launchFile == "" {
+			return nil, fmt.Errorf("安装完成但未找到启动文件，详见 %s", filepath.Join(req.WorkDir, "installer-output.log"))
+		}
+		result.ServerJar = launchFile
+	}
+
+	// 安装器已按Maven校验和校验，记录安装器生成的启动文件的SHA1用于之后的完整性校验
+	launchPath := filepath.Join(req.WorkDir, filepath.FromSlash(launchFile))
+	launchSHA1, err := fileSHA1(launchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Core = CoreDownload{
+		Path:        launchPath,
+		ServerName:  p.name,
+		MCVersion:   req.MCVersion,
+		CoreVersion: build,
+		SHA1:        launchSHA1,
+		Source:      p.name + "-maven",
+		URL:         installerURL,
+	}
+	return result, nil
+}
+
+// fetchSHA1 读取Maven的 .sha1 文件
+func (p *ForgeProvider) fetchSHA1(url string) (string, error) {
+	client := httpclient.New(0)
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("获取校验和失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("获取校验和失败: HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("读取校验和失败: %w", err)
+	}
+
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 || len(fields[0]) != 40 {
+		return "", fmt.Errorf("无效的校验和: %s", strings.TrimSpace(string(body)))
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// findServerJar 查找旧版安装器生成的启动jar
+func (p *ForgeProvider) findServerJar(workDir, mavenVersion string) string {
+	for _, name := range []string{
+		fmt.Sprintf("%s-%s-shim.jar", p.artifact, mavenVersion),
+		fmt.Sprintf("%s-%s.jar", p.artifact, mavenVersion),
+		fmt.Sprintf("%s-%s-universal.jar", p.artifact, mavenVersion),
+	} {
+		if _, err := os.Stat(filepath.Join(workDir, name)); err == nil {
+			return name
+		}
+	}
+	return ""
+}
@@ -5,22 +5,128 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"easilypanel/internal/audit"
 )
 
 // DownloadManager 下载管理器
 type DownloadManager struct {
 	fastMirror *FastMirrorClient
+	paperMC    *PaperMCClient
 	downloader *Downloader
 	dataDir    string
+	sources    map[string]string // 配置的下载源地址
+
+	mirrors       []MirrorSource // 启用的镜像下载源，按配置顺序
+	mirrorOrder   []string       // 配置的优先顺序（download.mirror_order）
+	defaultSource string
+	mirrorOnce    sync.Once
+	ordered       []MirrorSource // 按测速结果排序的镜像下载源
+
+	cache       *Cache
+	catalog     *Catalog // FastMirror API响应缓存
+	autoCleanup bool
+	cleanupDays int
+
+	queueOnce     sync.Once
+	queue         *Queue
+	maxConcurrent int
+	jobRateLimit  int64
 }
 
 // NewDownloadManager 创建新的下载管理器
 func NewDownloadManager(dataDir string) *DownloadManager {
-	return &DownloadManager{
-		fastMirror: NewFastMirrorClient(),
-		downloader: NewDownloader(),
-		dataDir:    dataDir,
+	fastMirror := NewFastMirrorClient()
+	catalog := NewCatalog(filepath.Join(dataDir, "downloads", "catalog"))
+	fastMirror.SetCatalog(catalog)
+	dm := &DownloadManager{
+		cache:         NewCache(filepath.Join(dataDir, "downloads", "cache")),
+		catalog:       catalog,
+		fastMirror:    fastMirror,
+		paperMC:       NewPaperMCClient(""),
+		downloader:    NewDownloader(),
+		dataDir:       dataDir,
+		maxConcurrent: 3,
+		defaultSource: fastMirror.Name(),
+	}
+	dm.buildMirrors()
+	return dm
+}
+
+// SetMaxConcurrent 设置下载队列同时进行的下载数量（download.max_concurrent），需在首次下载前调用
+func (dm *DownloadManager) SetMaxConcurrent(n int) {
+	if n > 0 {
+		dm.maxConcurrent = n
+	}
+}
+
+// SetRateLimit 设置全局和单个任务的速率上限（download.rate_limit、download.job_rate_limit），0表示不限速
+func (dm *DownloadManager) SetRateLimit(global, perJob int64) {
+	dm.downloader.SetRateLimit(global)
+	dm.jobRateLimit = perJob
+	if dm.queue != nil {
+		dm.queue.SetDefaultJobRateLimit(perJob)
+	}
+}
+
+// Queue 获取下载队列
+func (dm *DownloadManager) Queue() *Queue {
+	dm.queueOnce.Do(func() {
+		dm.queue = NewQueue(dm.downloader, dm.maxConcurrent, dm.GetQueueDir())
+		dm.queue.SetDefaultJobRateLimit(dm.jobRateLimit)
+	})
+	return dm.queue
+}
+
+// GetQueueDir 获取下载队列状态目录
+func (dm *DownloadManager) GetQueueDir() string {
+	return filepath.Join(dm.GetDownloadDir(), "queue")
+}
+
+// download 通过下载队列下载文件并等待完成
+func (dm *DownloadManager) download(url, filePath, source string, sums Checksums, callback ProgressCallback) (Checksums, error) {
+	job := dm.Queue().Submit(JobRequest{
+		URLs:      []string{url},
+		Dest:      filePath,
+		Source:    source,
+		Priority:  PriorityHigh,
+		Retries:   3,
+		Checksums: sums,
+		Progress:  callback,
+	})
+	if err := job.Wait(); err != nil {
+		return nil, err
+	}
+	return job.Checksums(), nil
+}
+
+// SetChecksumPolicy 设置校验要求：require对应 download.verify_checksum，
+// keys为下载源名称到base64编码Ed25519公钥的映射（download.signing_keys）
+func (dm *DownloadManager) SetChecksumPolicy(require bool, keys map[string]string) error {
+	dm.downloader.SetRequireChecksum(require)
+	for source, value := range keys {
+		key, err := ParseSigningKey(value)
+		if err != nil {
+			return fmt.Errorf("下载源 %s 的签名公钥无效: %w", source, err)
+		}
+		dm.downloader.SetSigningKey(source, key)
+	}
+	return nil
+}
+
+// SetSourceURLs 使用配置中的下载源地址（download.sources）
+//
+// 已注册的镜像下载源只有出现在其中且地址不为空时才启用，删除或留空即可禁用。
+func (dm *DownloadManager) SetSourceURLs(sources map[string]string) {
+	if sources == nil {
+		sources = map[string]string{}
+	}
+	dm.sources = sources
+	dm.buildMirrors()
+	if url := sources["papermc"]; url != "" {
+		dm.paperMC = NewPaperMCClient(url)
 	}
 }
 
@@ -31,7 +137,13 @@ func (dm *DownloadManager) GetDownloadDir() string {
 
 // ListAvailableServers 列出可用的服务端
 func (dm *DownloadManager) ListAvailableServers() ([]ServerInfo, error) {
-	return dm.fastMirror.GetServerList()
+	var servers []ServerInfo
+	_, err := dm.withMirrors(func(mirror MirrorSource) error {
+		var err error
+		servers, err = mirror.ListServers()
+		return err
+	})
+	return servers, err
 }
 
 // SearchServers 搜索服务端
@@ -46,11 +158,13 @@ func (dm *DownloadManager) GetServerInfo(name string) (*ProjectInfo, error) {
 
 // ListVersions 列出服务端支持的MC版本
 func (dm *DownloadManager) ListVersions(serverName string) ([]string, error) {
-	info, err := dm.fastMirror.GetProjectInfo(serverName)
-	if err != nil {
-		return nil, err
-	}
-	return info.MCVersions, nil
+	var versions []string
+	_, err := dm.withMirrors(func(mirror MirrorSource) error {
+		var err error
+		versions, err = mirror.ListVersions(serverName)
+		return err
+	})
+	return versions, err
 }
 
 // ListBuilds 列出构建版本
@@ -59,25 +173,94 @@ func (dm *DownloadManager) ListBuilds(serverName, mcVersion string, limit int) (
 		limit = 10 // 默认显示10个
 	}
 	
-	builds, err := dm.fastMirror.GetBuilds(serverName, mcVersion, 0, limit)
-	if err != nil {
-		return nil, err
-	}
-	
-	return builds.Builds, nil
+	var builds []BuildInfo
+	_, err := dm.withMirrors(func(mirror MirrorSource) error {
+		var err error
+		builds, err = mirror.ListBuilds(serverName, mcVersion, limit)
+		return err
+	})
+	return builds, err
 }
 
 // GetLatestBuild 获取最新构建
 func (dm *DownloadManager) GetLatestBuild(serverName, mcVersion string) (*BuildInfo, error) {
-	return dm.fastMirror.GetLatestBuild(serverName, mcVersion)
+	var build *BuildInfo
+	_, err := dm.withMirrors(func(mirror MirrorSource) error {
+		var err error
+		build, err = mirror.GetLatestBuild(serverName, mcVersion)
+		return err
+	})
+	return build, err
+}
+
+// GetCoreInfo 获取指定构建的核心信息
+func (dm *DownloadManager) GetCoreInfo(serverName, mcVersion, coreVersion string) (*CoreInfo, error) {
+	var core *CoreInfo
+	_, err := dm.withMirrors(func(mirror MirrorSource) error {
+		var err error
+		core, err = mirror.GetCoreInfo(serverName, mcVersion, coreVersion)
+		return err
+	})
+	return core, err
+}
+
+// VerifyFile 校验文件SHA1
+func (dm *DownloadManager) VerifyFile(filePath, expectedSHA1 string) error {
+	return dm.downloader.VerifyFile(filePath, expectedSHA1)
+}
+
+// GetChangelog 获取两个构建之间的更新日志，不支持的服务端返回ErrNoChangelog
+func (dm *DownloadManager) GetChangelog(serverName, mcVersion, fromBuild, toBuild string) ([]BuildChange, error) {
+	return dm.paperMC.GetChangelog(serverName, mcVersion, fromBuild, toBuild)
+}
+
+// CoreDownload 通过下载管理器获取的服务端核心及其来源
+type CoreDownload struct {
+	Path        string `json:"path"`
+	ServerName  string `json:"server_name"`
+	MCVersion   string `json:"mc_version"`
+	CoreVersion string `json:"core_version"`
+	UpdateTime  string `json:"update_time"`
+	SHA1        string `json:"sha1"`
+	Source      string `json:"source"` // 下载源名称
+	URL         string `json:"url"`
 }
 
 // DownloadServer 下载服务端
 func (dm *DownloadManager) DownloadServer(serverName, mcVersion, coreVersion string, showProgress bool) (string, error) {
+	core, err := dm.DownloadCore(serverName, mcVersion, coreVersion, showProgress)
+	if err != nil {
+		return "", err
+	}
+	return core.Path, nil
+}
+
+// DownloadCore 下载服务端核心并返回来源信息，下载源失败时使用下一个下载源
+func (dm *DownloadManager) DownloadCore(serverName, mcVersion, coreVersion string, showProgress bool) (*CoreDownload, error) {
+	var core *CoreDownload
+	_, err := dm.withMirrors(func(mirror MirrorSource) error {
+		var err error
+		core, err = dm.downloadCoreFrom(mirror, serverName, mcVersion, coreVersion, showProgress)
+		return err
+	})
+	return core, err
+}
+
+// DownloadCoreFrom 从指定的下载源下载服务端核心
+func (dm *DownloadManager) DownloadCoreFrom(source, serverName, mcVersion, coreVersion string, showProgress bool) (*CoreDownload, error) {
+	mirror, err := dm.Mirror(source)
+	if err != nil {
+		return nil, err
+	}
+	return dm.downloadCoreFrom(mirror, serverName, mcVersion, coreVersion, showProgress)
+}
+
+// downloadCoreFrom 从下载源获取核心信息并下载
+func (dm *DownloadManager) downloadCoreFrom(mirror MirrorSource, serverName, mcVersion, coreVersion string, showProgress bool) (*CoreDownload, error) {
 	// 获取核心信息
-	coreInfo, err := dm.fastMirror.GetCoreInfo(serverName, mcVersion, coreVersion)
+	coreInfo, err := mirror.GetCoreInfo(serverName, mcVersion, coreVersion)
 	if err != nil {
-		return "", fmt.Errorf("获取核心信息失败: %w", err)
+		return nil, fmt.Errorf("获取核心信息失败: %w", err)
 	}
 	
 	// 确定下载路径
@@ -89,12 +272,19 @@ func (dm *DownloadManager) DownloadServer(serverName, mcVersion, coreVersion str
 	
 	filePath := filepath.Join(downloadDir, fileName)
 	
+	// 下载缓存中已有相同内容
+	if coreInfo.SHA1 != "" && dm.useCached(coreInfo.SHA1, filePath) {
+		fmt.Printf("使用下载缓存: %s\n", filePath)
+		return newCoreDownload(filePath, serverName, mcVersion, coreVersion, coreInfo, mirror.Name()), nil
+	}
+	
 	// 检查文件是否已存在
 	if _, err := os.Stat(filePath); err == nil {
 		// 文件存在，验证校验和
-		if err := dm.downloader.VerifyFile(filePath, coreInfo.SHA1); err == nil {
+		if err := dm.downloader.VerifyChecksums(filePath, coreInfo.Checksums()); err == nil {
 			fmt.Printf("文件已存在且校验通过: %s\n", filePath)
-			return filePath, nil
+			coreInfo.SHA1 = dm.cacheFile(filePath, coreInfo.SHA1, coreInfo.DownloadURL)
+			return newCoreDownload(filePath, serverName, mcVersion, coreVersion, coreInfo, mirror.Name()), nil
 		} else {
 			fmt.Printf("文件校验失败，重新下载: %v\n", err)
 			os.Remove(filePath) // 删除损坏的文件
@@ -103,11 +293,11 @@ func (dm *DownloadManager) DownloadServer(serverName, mcVersion, coreVersion str
 	
 	// 创建下载目录
 	if err := os.MkdirAll(downloadDir, 0755); err != nil {
-		return "", fmt.Errorf("创建下载目录失败: %w", err)
+		return nil, fmt.Errorf("创建下载目录失败: %w", err)
 	}
 	
 	fmt.Printf("开始下载: %s\n", fileName)
-	fmt.Printf("下载地址: %s\n", coreInfo.DownloadURL)
+	fmt.Printf("下载地址: %s (%s)\n", coreInfo.DownloadURL, mirror.Name())
 	
 	// 设置进度回调
 	var progress *DownloadProgress
@@ -121,25 +311,18 @@ func (dm *DownloadManager) DownloadServer(serverName, mcVersion, coreVersion str
 		}
 	}
 	
-	// 开始下载
+	// 开始下载，下载的同时校验哈希
 	startTime := time.Now()
-	err = dm.downloader.DownloadWithRetry(coreInfo.DownloadURL, filePath, 3, callback)
+	sums, err := dm.download(coreInfo.DownloadURL, filePath, mirror.Name(), coreInfo.Checksums(), callback)
 	if err != nil {
-		return "", fmt.Errorf("下载失败: %w", err)
+		return nil, fmt.Errorf("下载失败: %w", err)
 	}
 	
 	if showProgress {
 		fmt.Println() // 换行
 	}
-	
-	// 验证文件
 	if coreInfo.SHA1 != "" {
-		fmt.Print("正在验证文件...")
-		if err := dm.downloader.VerifyFile(filePath, coreInfo.SHA1); err != nil {
-			os.Remove(filePath) // 删除损坏的文件
-			return "", fmt.Errorf("文件校验失败: %w", err)
-		}
-		fmt.Println(" 校验通过")
+		fmt.Println("文件校验通过")
 	}
 	
 	duration := time.Since(startTime)
@@ -147,7 +330,24 @@ func (dm *DownloadManager) DownloadServer(serverName, mcVersion, coreVersion str
 	fmt.Printf("下载完成: %s (耗时: %v, 大小: %s)\n", 
 		fileName, duration.Round(time.Second), FormatBytes(fileInfo.Size()))
 	
-	return filePath, nil
+	// 放入下载缓存；下载源未提供SHA1时记录下载时计算出的SHA1
+	coreInfo.SHA1 = dm.cacheFile(filePath, sums["sha1"], coreInfo.DownloadURL)
+	
+	return newCoreDownload(filePath, serverName, mcVersion, coreVersion, coreInfo, mirror.Name()), nil
+}
+
+// newCoreDownload 根据镜像下载源的核心信息创建下载记录
+func newCoreDownload(path, serverName, mcVersion, coreVersion string, info *CoreInfo, source string) *CoreDownload {
+	return &CoreDownload{
+		Path:        path,
+		ServerName:  serverName,
+		MCVersion:   mcVersion,
+		CoreVersion: coreVersion,
+		UpdateTime:  info.UpdateTime,
+		SHA1:        info.SHA1,
+		Source:      source,
+		URL:         info.DownloadURL,
+	}
 }
 
 // DownloadLatest 下载最新版本
@@ -193,6 +393,13 @@ func (dm *DownloadManager) GetDownloadedFilePath(filename string) string {
 
 // DeleteDownloadedFile 删除已下载的文件
 func (dm *DownloadManager) DeleteDownloadedFile(filename string) error {
+	err := dm.deleteDownloadedFile(filename)
+	audit.Record(audit.DefaultActor(), "download.DeleteFile", filename, nil, err)
+	return err
+}
+
+// deleteDownloadedFile 删除下载目录中的文件
+func (dm *DownloadManager) deleteDownloadedFile(filename string) error {
 	filePath := dm.GetDownloadedFilePath(filename)
 	
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -0,0 +1,175 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"easilypanel/internal/httpclient"
+)
+
+// MCSLSyncAPI MCSL-Sync默认API地址
+const MCSLSyncAPI = "https://sync.mcsl.com.cn/api"
+
+// mcslResponse MCSL-Sync API统一响应格式
+type mcslResponse struct {
+	Data json.RawMessage `json:"data"`
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+}
+
+// MCSLClient MCSL-Sync API客户端
+type MCSLClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewMCSLClient 创建MCSL-Sync客户端，baseURL为空时使用默认地址
+func NewMCSLClient(baseURL string) *MCSLClient {
+	if baseURL == "" {
+		baseURL = MCSLSyncAPI
+	}
+	return &MCSLClient{
+		httpClient: httpclient.New(0),
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Name 下载源名称
+func (c *MCSLClient) Name() string {
+	return "mcsl"
+}
+
+// SetBaseURL 设置API地址
+func (c *MCSLClient) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// get 发送请求并解析data字段
+func (c *MCSLClient) get(endpoint string, v interface{}) error {
+	resp, err := c.httpClient.Get(c.baseURL + endpoint)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP错误: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var response mcslResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("解析JSON失败: %w", err)
+	}
+	if response.Code != http.StatusOK {
+		return fmt.Errorf("API错误: %s (%d)", response.Msg, response.Code)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Data, v); err != nil {
+		return fmt.Errorf("解析数据失败: %w", err)
+	}
+	return nil
+}
+
+// ListServers 获取支持的服务端列表
+func (c *MCSLClient) ListServers() ([]ServerInfo, error) {
+	var names []string
+	if err := c.get("/core", &names); err != nil {
+		return nil, fmt.Errorf("获取服务端列表失败: %w", err)
+	}
+
+	servers := make([]ServerInfo, 0, len(names))
+	for _, name := range names {
+		servers = append(servers, ServerInfo{Name: name})
+	}
+	return servers, nil
+}
+
+// ListVersions 列出服务端支持的MC版本
+func (c *MCSLClient) ListVersions(name string) ([]string, error) {
+	var data struct {
+		Versions []string `json:"versions"`
+	}
+	if err := c.get("/core/"+url.PathEscape(name), &data); err != nil {
+		return nil, fmt.Errorf("获取版本列表失败: %w", err)
+	}
+	return data.Versions, nil
+}
+
+// ListBuilds 列出最新的limit个构建（limit<=0时返回全部）
+func (c *MCSLClient) ListBuilds(name, mcVersion string, limit int) ([]BuildInfo, error) {
+	var data struct {
+		Builds []string `json:"builds"`
+	}
+	endpoint := fmt.Sprintf("/core/%s/%s", url.PathEscape(name), url.PathEscape(mcVersion))
+	if err := c.get(endpoint, &data); err != nil {
+		return nil, fmt.Errorf("获取构建列表失败: %w", err)
+	}
+
+	builds := make([]BuildInfo, 0, len(data.Builds))
+	for _, build := range data.Builds {
+		if limit > 0 && len(builds) >= limit {
+			break
+		}
+		builds = append(builds, BuildInfo{Name: name, MCVersion: mcVersion, CoreVersion: build})
+	}
+	return builds, nil
+}
+
+// GetLatestBuild 获取最新构建版本
+func (c *MCSLClient) GetLatestBuild(name, mcVersion string) (*BuildInfo, error) {
+	builds, err := c.ListBuilds(name, mcVersion, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(builds) == 0 {
+		return nil, fmt.Errorf("未找到 %s %s 的构建版本", name, mcVersion)
+	}
+	return &builds[0], nil
+}
+
+// GetCoreInfo 获取指定核心的下载信息（MCSL-Sync不提供SHA1）
+func (c *MCSLClient) GetCoreInfo(name, mcVersion, coreVersion string) (*CoreInfo, error) {
+	var data struct {
+		Build struct {
+			SyncTime    string `json:"sync_time"`
+			DownloadURL string `json:"download_url"`
+			CoreType    string `json:"core_type"`
+			MCVersion   string `json:"mc_version"`
+			CoreVersion string `json:"core_version"`
+		} `json:"build"`
+	}
+	endpoint := fmt.Sprintf("/core/%s/%s/%s", url.PathEscape(name), url.PathEscape(mcVersion), url.PathEscape(coreVersion))
+	if err := c.get(endpoint, &data); err != nil {
+		return nil, fmt.Errorf("获取核心信息失败: %w", err)
+	}
+	if data.Build.DownloadURL == "" {
+		return nil, fmt.Errorf("获取核心信息失败: 没有下载地址")
+	}
+
+	return &CoreInfo{
+		Name:        name,
+		MCVersion:   mcVersion,
+		CoreVersion: coreVersion,
+		UpdateTime:  data.Build.SyncTime,
+		Filename:    fmt.Sprintf("%s-%s-%s.jar", name, mcVersion, coreVersion),
+		DownloadURL: data.Build.DownloadURL,
+	}, nil
+}
+
+// Probe 请求服务端列表以检查下载源是否可用
+func (c *MCSLClient) Probe() error {
+	probe := *c
+	probe.httpClient = httpclient.New(mirrorProbeTimeout)
+	return probe.get("/core", nil)
+}
@@ -0,0 +1,296 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// mirrorProbeTTL 测速结果的有效期
+	mirrorProbeTTL = time.Hour
+	// mirrorProbeTimeout 测速请求的超时时间，超时的下载源视为不可用
+	mirrorProbeTimeout = 5 * time.Second
+)
+
+// MirrorSource 提供预构建服务端核心的镜像下载源（FastMirror、MCSL-Sync）
+type MirrorSource interface {
+	// Name 下载源名称，与 download.sources 中的键一致
+	Name() string
+	SetBaseURL(baseURL string)
+	ListServers() ([]ServerInfo, error)
+	ListVersions(serverName string) ([]string, error)
+	ListBuilds(serverName, mcVersion string, limit int) ([]BuildInfo, error)
+	GetLatestBuild(serverName, mcVersion string) (*BuildInfo, error)
+	GetCoreInfo(serverName, mcVersion, coreVersion string) (*CoreInfo, error)
+	// Probe 发送一个轻量请求检查下载源是否可用
+	Probe() error
+}
+
+// MirrorFactory 创建镜像下载源，baseURL为空时使用默认地址
+type MirrorFactory func(baseURL string) MirrorSource
+
+// mirrorEntry 已注册的镜像下载源
+type mirrorEntry struct {
+	name    string
+	factory MirrorFactory
+}
+
+var (
+	mirrorRegistryMu sync.RWMutex
+	// mirrorRegistry 可用的镜像下载源，按注册顺序；名称与 download.sources 中的键一致
+	mirrorRegistry = []mirrorEntry{
+		{"fastmirror", func(baseURL string) MirrorSource {
+			client := NewFastMirrorClient()
+			if baseURL != "" {
+				client.SetBaseURL(baseURL)
+			}
+			return client
+		}},
+		{"mcsl", func(baseURL string) MirrorSource { return NewMCSLClient(baseURL) }},
+	}
+)
+
+// RegisterMirror 注册镜像下载源，已存在同名下载源时替换；之后创建的下载管理器即可在 download.sources 中使用它
+func RegisterMirror(name string, factory MirrorFactory) {
+	mirrorRegistryMu.Lock()
+	defer mirrorRegistryMu.Unlock()
+	for i := range mirrorRegistry {
+		if mirrorRegistry[i].name == name {
+			mirrorRegistry[i].factory = factory
+			return
+		}
+	}
+	mirrorRegistry = append(mirrorRegistry, mirrorEntry{name: name, factory: factory})
+}
+
+// MirrorNames 获取已注册的镜像下载源名称
+func MirrorNames() []string {
+	mirrorRegistryMu.RLock()
+	defer mirrorRegistryMu.RUnlock()
+	names := make([]string, 0, len(mirrorRegistry))
+	for _, entry := range mirrorRegistry {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
+// findMirrorFactory 按名称查找已注册的镜像下载源
+func findMirrorFactory(name string) MirrorFactory {
+	mirrorRegistryMu.RLock()
+	defer mirrorRegistryMu.RUnlock()
+	for _, entry := range mirrorRegistry {
+		if entry.name == name {
+			return entry.factory
+		}
+	}
+	return nil
+}
+
+// MirrorProbe 下载源测速结果
+type MirrorProbe struct {
+	Name    string        `json:"name"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Reachable 下载源是否可用
+func (p MirrorProbe) Reachable() bool {
+	return p.Error == ""
+}
+
+// mirrorProbeCache 保存在下载目录中的测速结果
+type mirrorProbeCache struct {
+	CheckedAt time.Time     `json:"checked_at"`
+	Probes    []MirrorProbe `json:"probes"`
+}
+
+// SetDefaultSource 设置首选下载源（download.default_source），测速结果相同时优先使用
+func (dm *DownloadManager) SetDefaultSource(name string) {
+	dm.defaultSource = name
+}
+
+// SetMirrorOrder 设置镜像下载源的优先顺序（download.mirror_order），测速结果相同时靠前的优先；
+// 未列出的已启用下载源排在最后
+func (dm *DownloadManager) SetMirrorOrder(order []string) error {
+	var unknown []string
+	for _, name := range order {
+		if findMirrorFactory(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	dm.mirrorOrder = order
+	dm.buildMirrors()
+	if len(unknown) > 0 {
+		return fmt.Errorf("未知的镜像下载源: %s (可用: %s)", strings.Join(unknown, ", "), strings.Join(MirrorNames(), ", "))
+	}
+	return nil
+}
+
+// buildMirrors 根据已注册的下载源、配置的地址和优先顺序创建镜像下载源列表
+//
+// 未配置 download.sources 时启用所有已注册的下载源；配置后只启用其中地址不为空的。
+func (dm *DownloadManager) buildMirrors() {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, dm.mirrorOrder...), MirrorNames()...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	dm.mirrors = nil
+	for _, name := range names {
+		factory := findMirrorFactory(name)
+		if factory == nil {
+			continue
+		}
+		url, configured := dm.sources[name]
+		if dm.sources != nil && (!configured || strings.TrimSpace(url) == "") {
+			continue // 从配置中删除或留空表示禁用
+		}
+
+		mirror := factory(strings.TrimSpace(url))
+		if client, ok := mirror.(*FastMirrorClient); ok {
+			client.SetCatalog(dm.catalog)
+			dm.fastMirror = client // FastMirror特有的接口（搜索、推荐等）使用同一个客户端
+		}
+		dm.mirrors = append(dm.mirrors, mirror)
+	}
+}
+
+// Mirrors 获取所有启用的镜像下载源，按优先顺序
+func (dm *DownloadManager) Mirrors() []MirrorSource {
+	return dm.mirrors
+}
+
+// Mirror 按名称获取镜像下载源，名称为空时返回首选下载源
+func (dm *DownloadManager) Mirror(name string) (MirrorSource, error) {
+	if len(dm.mirrors) == 0 {
+		return nil, errNoMirrors
+	}
+	lookup := name
+	if lookup == "" {
+		lookup = dm.defaultSource
+	}
+	for _, mirror := range dm.mirrors {
+		if mirror.Name() == lookup {
+			return mirror, nil
+		}
+	}
+	if name == "" {
+		return dm.mirrors[0], nil
+	}
+	return nil, fmt.Errorf("未知或未启用的下载源: %s", name)
+}
+
+// errNoMirrors 所有镜像下载源都被禁用
+var errNoMirrors = errors.New("没有启用的镜像下载源，请检查配置中的 download.sources")
+
+// ProbeMirrors 并发测试所有镜像下载源的延迟，结果按延迟排序并缓存
+func (dm *DownloadManager) ProbeMirrors() []MirrorProbe {
+	probes := make([]MirrorProbe, len(dm.mirrors))
+	var wg sync.WaitGroup
+	for i, mirror := range dm.mirrors {
+		wg.Add(1)
+		go func(i int, mirror MirrorSource) {
+			defer wg.Done()
+			start := time.Now()
+			err := mirror.Probe()
+			probes[i] = MirrorProbe{Name: mirror.Name(), Latency: time.Since(start)}
+			if err != nil {
+				probes[i].Error = err.Error()
+			}
+		}(i, mirror)
+	}
+	wg.Wait()
+
+	dm.sortProbes(probes)
+
+	cache := mirrorProbeCache{CheckedAt: time.Now(), Probes: probes}
+	if data, err := json.MarshalIndent(cache, "", "  "); err == nil {
+		if err := os.MkdirAll(dm.GetDownloadDir(), 0755); err == nil {
+			os.WriteFile(dm.mirrorCachePath(), data, 0644)
+		}
+	}
+	return probes
+}
+
+// sortProbes 可用的下载源按延迟排序，不可用的排在最后；相同条件下首选下载源优先，其次按配置的顺序
+func (dm *DownloadManager) sortProbes(probes []MirrorProbe) {
+	rank := make(map[string]int)
+	for i, mirror := range dm.mirrors {
+		rank[mirror.Name()] = i
+	}
+	sort.SliceStable(probes, func(i, j int) bool {
+		a, b := probes[i], probes[j]
+		if a.Reachable() != b.Reachable() {
+			return a.Reachable()
+		}
+		if !a.Reachable() || a.Latency == b.Latency {
+			if (a.Name == dm.defaultSource) != (b.Name == dm.defaultSource) {
+				return a.Name == dm.defaultSource
+			}
+			return rank[a.Name] < rank[b.Name]
+		}
+		return a.Latency < b.Latency
+	})
+}
+
+// mirrorCachePath 测速结果缓存文件
+func (dm *DownloadManager) mirrorCachePath() string {
+	return filepath.Join(dm.GetDownloadDir(), "mirrors.json")
+}
+
+// orderedMirrors 按测速结果排列下载源，测速结果过期时重新测速
+func (dm *DownloadManager) orderedMirrors() []MirrorSource {
+	dm.mirrorOnce.Do(func() {
+		var probes []MirrorProbe
+		var cache mirrorProbeCache
+		if data, err := os.ReadFile(dm.mirrorCachePath()); err == nil &&
+			json.Unmarshal(data, &cache) == nil &&
+			time.Since(cache.CheckedAt) < mirrorProbeTTL &&
+			len(cache.Probes) == len(dm.mirrors) {
+			probes = cache.Probes
+			dm.sortProbes(probes)
+		} else {
+			probes = dm.ProbeMirrors()
+		}
+
+		for _, probe := range probes {
+			if mirror, err := dm.Mirror(probe.Name); err == nil {
+				dm.ordered = append(dm.ordered, mirror)
+			}
+		}
+		if len(dm.ordered) != len(dm.mirrors) {
+			dm.ordered = dm.mirrors
+		}
+	})
+	return dm.ordered
+}
+
+// withMirrors 依次在下载源上执行操作，直到成功；返回成功的下载源
+func (dm *DownloadManager) withMirrors(fn func(MirrorSource) error) (MirrorSource, error) {
+	if len(dm.mirrors) == 0 {
+		return nil, errNoMirrors
+	}
+	var errs []string
+	for _, mirror := range dm.orderedMirrors() {
+		if err := fn(mirror); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", mirror.Name(), err))
+			continue
+		}
+		return mirror, nil
+	}
+	if len(errs) == 1 {
+		return nil, fmt.Errorf("%s", errs[0])
+	}
+	return nil, fmt.Errorf("所有下载源均失败: %s", strings.Join(errs, "; "))
+}
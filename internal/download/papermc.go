@@ -0,0 +1,136 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"easilypanel/internal/httpclient"
+)
+
+// PaperMCAPIV2 PaperMC官方API地址
+const PaperMCAPIV2 = "https://api.papermc.io/v2"
+
+// ErrNoChangelog 下载源不提供该服务端的更新日志
+var ErrNoChangelog = errors.New("该服务端没有可用的更新日志")
+
+// paperProjects FastMirror服务端名称对应的PaperMC项目
+var paperProjects = map[string]string{
+	"paper":     "paper",
+	"folia":     "folia",
+	"velocity":  "velocity",
+	"waterfall": "waterfall",
+}
+
+// buildNumberPattern 从核心版本中提取构建号，如 "build196" -> 196
+var buildNumberPattern = regexp.MustCompile(`(\d+)$`)
+
+// BuildChange 构建中的一条变更
+type BuildChange struct {
+	Build   int    `json:"build"`
+	Commit  string `json:"commit"`
+	Summary string `json:"summary"`
+}
+
+// paperBuildsResponse PaperMC构建列表响应
+type paperBuildsResponse struct {
+	Builds []struct {
+		Build   int       `json:"build"`
+		Time    time.Time `json:"time"`
+		Changes []struct {
+			Commit  string `json:"commit"`
+			Summary string `json:"summary"`
+		} `json:"changes"`
+	} `json:"builds"`
+}
+
+// PaperMCClient PaperMC API客户端，用于获取构建更新日志
+type PaperMCClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewPaperMCClient 创建PaperMC客户端，baseURL为空时使用官方地址
+func NewPaperMCClient(baseURL string) *PaperMCClient {
+	if baseURL == "" {
+		baseURL = PaperMCAPIV2
+	}
+	return &PaperMCClient{
+		httpClient: httpclient.New(0),
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// ParseBuildNumber 从核心版本字符串中解析构建号
+func ParseBuildNumber(coreVersion string) (int, bool) {
+	m := buildNumberPattern.FindStringSubmatch(coreVersion)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
+
+// GetChangelog 获取 fromBuild（不含）到 toBuild（含）之间的变更
+func (c *PaperMCClient) GetChangelog(serverName, mcVersion, fromBuild, toBuild string) ([]BuildChange, error) {
+	project, ok := paperProjects[strings.ToLower(serverName)]
+	if !ok {
+		return nil, ErrNoChangelog
+	}
+
+	from, okFrom := ParseBuildNumber(fromBuild)
+	to, okTo := ParseBuildNumber(toBuild)
+	if !okFrom || !okTo {
+		return nil, ErrNoChangelog
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/versions/%s/builds", c.baseURL, project, url.PathEscape(mcVersion))
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP错误: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var builds paperBuildsResponse
+	if err := json.Unmarshal(body, &builds); err != nil {
+		return nil, fmt.Errorf("解析构建列表失败: %w", err)
+	}
+
+	// 降级时显示被撤销的变更
+	low, high := from, to
+	if low > high {
+		low, high = high, low
+	}
+
+	var changes []BuildChange
+	for _, build := range builds.Builds {
+		if build.Build <= low || build.Build > high {
+			continue
+		}
+		for _, change := range build.Changes {
+			changes = append(changes, BuildChange{
+				Build:   build.Build,
+				Commit:  change.Commit,
+				Summary: change.Summary,
+			})
+		}
+	}
+
+	return changes, nil
+}
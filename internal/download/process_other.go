@@ -0,0 +1,20 @@
+//go:build !windows
+
+package download
+
+import (
+	"os"
+	"syscall"
+)
+
+// processAlive 检查进程是否存在
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package download
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+)
+
+// processAlive 检查进程是否存在；Windows不支持信号0，通过进程的退出码判断
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// 没有权限打开的进程仍然存在
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,315 @@
+package download
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"easilypanel/internal/version"
+)
+
+// 官方核心提供者的默认地址
+const (
+	MojangVersionManifest = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+	FabricMetaURL         = "https://meta.fabricmc.net/v2"
+	ForgeMavenURL         = "https://maven.minecraftforge.net"
+	NeoForgeMavenURL      = "https://maven.neoforged.net/releases"
+)
+
+// installerTimeout Forge/NeoForge安装器的最长运行时间
+const installerTimeout = 15 * time.Minute
+
+// CoreProvider 服务端核心提供者（FastMirror、Mojang、Fabric、Forge、NeoForge）
+type CoreProvider interface {
+	// Name 服务端类型名称，创建实例时作为ServerType
+	Name() string
+	// ListVersions 列出支持的Minecraft版本，从新到旧
+	ListVersions() ([]string, error)
+	// ListBuilds 列出Minecraft版本可用的构建，从新到旧
+	ListBuilds(mcVersion string) ([]string, error)
+	// Install 下载核心到工作目录，需要时运行安装器
+	Install(req InstallRequest) (*CoreInstall, error)
+}
+
+// InstallRequest 核心安装请求
+type InstallRequest struct {
+	MCVersion    string
+	Build        string // 为空时使用最新构建
+	WorkDir      string
+	JavaPath     string // 运行安装器使用的Java
+	ShowProgress bool
+}
+
+// CoreInstall 核心安装结果
+type CoreInstall struct {
+	ServerJar string       // 启动jar（相对工作目录），使用参数文件启动时可能为空
+	ArgsFile  string       // JVM参数文件（相对工作目录），如 libraries/.../unix_args.txt
+	Core      CoreDownload // 核心的版本和来源
+}
+
+// CoreProvider 按服务端类型获取核心提供者，非官方类型使用FastMirror
+func (dm *DownloadManager) CoreProvider(serverType string) CoreProvider {
+	switch strings.ToLower(serverType) {
+	case "vanilla":
+		return &VanillaProvider{dm: dm, manifestURL: dm.sourceURL("mojang", MojangVersionManifest)}
+	case "fabric":
+		return &FabricProvider{dm: dm, baseURL: dm.sourceURL("fabric", FabricMetaURL)}
+	case "forge":
+		return newForgeProvider(dm, dm.sourceURL("forge", ForgeMavenURL))
+	case "neoforge":
+		return newNeoForgeProvider(dm, dm.sourceURL("neoforge", NeoForgeMavenURL))
+	default:
+		return &FastMirrorProvider{dm: dm, serverName: serverType}
+	}
+}
+
+// sourceURL 获取配置的下载源地址
+func (dm *DownloadManager) sourceURL(name, fallback string) string {
+	if url := dm.sources[name]; url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return fallback
+}
+
+// FastMirrorProvider 通过FastMirror下载已构建好的核心
+type FastMirrorProvider struct {
+	dm         *DownloadManager
+	serverName string
+}
+
+// Name 服务端类型名称
+func (p *FastMirrorProvider) Name() string {
+	return p.serverName
+}
+
+// ListVersions 列出支持的Minecraft版本
+func (p *FastMirrorProvider) ListVersions() ([]string, error) {
+	return p.dm.ListVersions(p.serverName)
+}
+
+// ListBuilds 列出构建
+func (p *FastMirrorProvider) ListBuilds(mcVersion string) ([]string, error) {
+	builds, err := p.dm.ListBuilds(p.serverName, mcVersion, 20)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, build := range builds {
+		result = append(result, build.CoreVersion)
+	}
+	return result, nil
+}
+
+// Install 下载核心并复制到工作目录
+func (p *FastMirrorProvider) Install(req InstallRequest) (*CoreInstall, error) {
+	build := req.Build
+	if build == "" {
+		latest, err := p.dm.GetLatestBuild(p.serverName, req.MCVersion)
+		if err != nil {
+			return nil, err
+		}
+		build = latest.CoreVersion
+	}
+
+	core, err := p.dm.DownloadCore(p.serverName, req.MCVersion, build, req.ShowProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	jar := filepath.Base(core.Path)
+	if err := p.dm.LinkToWorkDir(core.Path, core.SHA1, req.WorkDir, jar); err != nil {
+		return nil, err
+	}
+	return &CoreInstall{ServerJar: jar, Core: *core}, nil
+}
+
+// getJSON 请求JSON接口
+func getJSON(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("资源不存在: %s", url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP错误: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("解析JSON失败: %w", err)
+	}
+	return nil
+}
+
+// fetchCached 从下载源source下载文件到下载目录（已存在且校验通过时直接使用），下载时校验sha1
+func (dm *DownloadManager) fetchCached(url, fileName, sha1, source string, showProgress bool) (string, error) {
+	filePath := filepath.Join(dm.GetDownloadDir(), fileName)
+
+	if sha1 != "" {
+		if dm.useCached(sha1, filePath) {
+			return filePath, nil
+		}
+		if _, err := os.Stat(filePath); err == nil {
+			if err := dm.downloader.VerifyFile(filePath, sha1); err == nil {
+				return filePath, nil
+			}
+			os.Remove(filePath)
+		}
+	}
+
+	fmt.Printf("开始下载: %s\n", fileName)
+	fmt.Printf("下载地址: %s\n", url)
+
+	var callback ProgressCallback
+	if showProgress {
+		progress := NewDownloadProgress()
+		callback = func(downloaded, total int64, percent float64) {
+			progress.Update(downloaded, total)
+			fmt.Printf("\r下载进度: %s", progress.String())
+		}
+	}
+
+	var expected Checksums
+	if sha1 != "" {
+		expected = Checksums{"sha1": sha1}
+	}
+	sums, err := dm.download(url, filePath, source, expected, callback)
+	if err != nil {
+		return "", fmt.Errorf("下载失败: %w", err)
+	}
+	if showProgress {
+		fmt.Println()
+	}
+
+	dm.cacheFile(filePath, sums["sha1"], url)
+	return filePath, nil
+}
+
+// DownloadTo 通过下载队列下载文件到指定位置并在下载时校验，sums为空时只在要求校验和时报错
+func (dm *DownloadManager) DownloadTo(url, filePath, source string, sums Checksums, showProgress bool) error {
+	var callback ProgressCallback
+	if showProgress {
+		progress := NewDownloadProgress()
+		callback = func(downloaded, total int64, percent float64) {
+			progress.Update(downloaded, total)
+			fmt.Printf("\r下载进度: %s", progress.String())
+		}
+	}
+
+	_, err := dm.download(url, filePath, source, sums, callback)
+	if showProgress {
+		fmt.Println()
+	}
+	return err
+}
+
+// fileSHA1 计算文件的SHA1
+func fileSHA1(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("计算文件哈希失败: %w", err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// runInstaller 在工作目录中无界面运行Forge/NeoForge安装器
+func runInstaller(javaPath, installer, workDir string) error {
+	if javaPath == "" {
+		javaPath = "java"
+	}
+	installer, err := filepath.Abs(installer)
+	if err != nil {
+		return fmt.Errorf("解析安装器路径失败: %w", err)
+	}
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("创建工作目录失败: %w", err)
+	}
+
+	logPath := filepath.Join(workDir, "installer-output.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("创建安装日志失败: %w", err)
+	}
+	defer logFile.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), installerTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, javaPath, "-jar", installer, "--installServer")
+	cmd.Dir = workDir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	fmt.Println("正在运行安装器 (--installServer)，可能需要几分钟...")
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("安装器运行超时 (%s)，详见 %s", installerTimeout, logPath)
+		}
+		return fmt.Errorf("安装器运行失败: %w\n%s", err, tailFile(logPath, 10))
+	}
+
+	return nil
+}
+
+// tailFile 读取文件最后几行，用于错误提示
+func tailFile(path string, lines int) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var all []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		all = append(all, scanner.Text())
+		if len(all) > lines {
+			all = all[1:]
+		}
+	}
+	return strings.Join(all, "\n")
+}
+
+// argsFileName 当前平台Forge/NeoForge安装器生成的JVM参数文件名
+func argsFileName() string {
+	if runtime.GOOS == "windows" {
+		return "win_args.txt"
+	}
+	return "unix_args.txt"
+}
+
+// sortVersionsDesc 按版本号从新到旧排序，无法解析的版本排在最后
+func sortVersionsDesc(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, errA := version.Parse(versions[i])
+		b, errB := version.Parse(versions[j])
+		if errA != nil || errB != nil {
+			return errA == nil && errB != nil
+		}
+		return a.Compare(b) > 0
+	})
+}
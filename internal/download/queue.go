@@ -0,0 +1,597 @@
+package download
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobStatus 下载任务状态
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobDone      JobStatus = "done"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// 下载任务优先级，数值越大越先执行
+const (
+	PriorityLow    = -10
+	PriorityNormal = 0
+	PriorityHigh   = 10
+)
+
+// ErrJobCancelled 任务被取消
+var ErrJobCancelled = errors.New("下载任务已取消")
+
+// JobRequest 提交到下载队列的任务
+type JobRequest struct {
+	URLs     []string // 依次尝试的下载地址
+	Dest     string
+	Label    string // 显示名称，默认为文件名
+	Source   string // 下载源名称
+	Priority int
+	Retries  int
+	// Checksums 期望的哈希，下载时同时校验
+	Checksums Checksums
+	// RateLimit 单个任务的速率上限（每秒字节数），0时使用队列的默认值
+	RateLimit int64
+	// Verify 下载完成后校验文件，失败时尝试下一个地址
+	Verify   func(path string) error
+	Progress ProgressCallback
+}
+
+// JobInfo 下载任务的状态快照，也是队列状态文件的内容
+type JobInfo struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label"`
+	URL        string     `json:"url"`
+	Dest       string     `json:"dest"`
+	Source     string     `json:"source,omitempty"`
+	Priority   int        `json:"priority"`
+	Status     JobStatus  `json:"status"`
+	Downloaded int64      `json:"downloaded"`
+	Total      int64      `json:"total"`
+	Speed      float64    `json:"speed,omitempty"`      // 最近几秒的速度（字节/秒）
+	RateLimit  int64      `json:"rate_limit,omitempty"` // 任务的速率上限，0表示只受全局限速
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	PID        int        `json:"pid"`
+}
+
+// Job 队列中的下载任务
+type Job struct {
+	req    JobRequest
+	info   JobInfo
+	seq    int
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+	sums   Checksums
+
+	limiter  *RateLimiter
+	progress *DownloadProgress
+}
+
+// ID 任务ID
+func (j *Job) ID() string {
+	return j.info.ID
+}
+
+// Wait 等待任务结束并返回结果
+func (j *Job) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Checksums 下载时计算出的哈希（始终包含SHA1），任务结束后可用
+func (j *Job) Checksums() Checksums {
+	<-j.done
+	return j.sums
+}
+
+// jobHeap 按优先级排序的待执行任务，优先级相同时先提交的先执行
+type jobHeap []*Job
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].info.Priority != h[j].info.Priority {
+		return h[i].info.Priority > h[j].info.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h jobHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x interface{}) { *h = append(*h, x.(*Job)) }
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	job := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return job
+}
+
+// Queue 并发下载队列
+//
+// 队列状态保存在 stateDir/<pid>.json 中，其他进程可以通过 ListQueueJobs 查看，
+// 通过 RequestCancel 写入取消标记来取消任务，通过 RequestRateLimit 调整限速。
+type Queue struct {
+	downloader   *Downloader
+	stateDir     string
+	jobRateLimit int64 // 新任务默认的速率上限
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending jobHeap
+	jobs    map[string]*Job
+	order   []*Job
+	seq     int
+	workers int
+	started int
+	running int
+	closed  bool
+
+	lastSave time.Time
+}
+
+// NewQueue 创建下载队列，workers为同时进行的下载数量，stateDir为空时不保存状态
+func NewQueue(downloader *Downloader, workers int, stateDir string) *Queue {
+	if workers <= 0 {
+		workers = 1
+	}
+	q := &Queue{
+		downloader: downloader,
+		stateDir:   stateDir,
+		jobs:       make(map[string]*Job),
+		workers:    workers,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Submit 提交下载任务
+func (q *Queue) Submit(req JobRequest) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	ctx, cancel := context.WithCancel(context.Background())
+	label := req.Label
+	if label == "" {
+		label = filepath.Base(req.Dest)
+	}
+	url := ""
+	if len(req.URLs) > 0 {
+		url = req.URLs[0]
+	}
+
+	rateLimit := req.RateLimit
+	if rateLimit == 0 {
+		rateLimit = q.jobRateLimit
+	}
+
+	job := &Job{
+		req: req,
+		info: JobInfo{
+			ID:        fmt.Sprintf("%d-%d", os.Getpid(), q.seq),
+			Label:     label,
+			URL:       url,
+			Dest:      req.Dest,
+			Source:    req.Source,
+			Priority:  req.Priority,
+			RateLimit: rateLimit,
+			Status:    JobQueued,
+			CreatedAt: time.Now(),
+			PID:       os.Getpid(),
+		},
+		seq:      q.seq,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		limiter:  NewRateLimiter(rateLimit),
+		progress: NewDownloadProgress(),
+	}
+
+	if q.closed {
+		q.finishLocked(job, errors.New("下载队列已关闭"))
+		return job
+	}
+
+	q.jobs[job.info.ID] = job
+	q.order = append(q.order, job)
+	heap.Push(&q.pending, job)
+
+	// 按需启动工作协程，最多workers个
+	if q.started < q.workers && q.started < q.running+len(q.pending) {
+		q.started++
+		go q.worker()
+		if q.started == 1 && q.stateDir != "" {
+			go q.watchRequests()
+		}
+	}
+
+	q.cond.Signal()
+	q.saveLocked(true)
+	return job
+}
+
+// worker 执行队列中的任务
+func (q *Queue) worker() {
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		job := heap.Pop(&q.pending).(*Job)
+		if job.ctx.Err() != nil {
+			q.mu.Unlock()
+			continue
+		}
+		now := time.Now()
+		job.info.Status = JobRunning
+		job.info.StartedAt = &now
+		q.running++
+		q.saveLocked(true)
+		q.mu.Unlock()
+
+		err := q.run(job)
+
+		q.mu.Lock()
+		q.running--
+		q.finishLocked(job, err)
+		q.saveLocked(true)
+		q.mu.Unlock()
+	}
+}
+
+// run 依次尝试下载地址直到下载并校验成功
+func (q *Queue) run(job *Job) error {
+	if len(job.req.URLs) == 0 {
+		return errors.New("没有可用的下载地址")
+	}
+
+	ctx := withRateLimiter(job.ctx, job.limiter)
+	callback := func(downloaded, total int64, percent float64) {
+		q.mu.Lock()
+		job.progress.Update(downloaded, total)
+		job.info.Downloaded = downloaded
+		job.info.Total = total
+		job.info.Speed = job.progress.Speed
+		q.saveLocked(false)
+		q.mu.Unlock()
+		if job.req.Progress != nil {
+			job.req.Progress(downloaded, total, percent)
+		}
+	}
+
+	var lastErr error
+	for _, url := range job.req.URLs {
+		q.mu.Lock()
+		job.info.URL = url
+		q.mu.Unlock()
+
+		sums, err := q.downloader.DownloadVerifiedContext(ctx, url, job.req.Dest, job.req.Source, job.req.Checksums, job.req.Retries, callback)
+		if job.ctx.Err() != nil {
+			return ErrJobCancelled
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if job.req.Verify != nil {
+			if err := job.req.Verify(job.req.Dest); err != nil {
+				os.Remove(job.req.Dest)
+				lastErr = err
+				continue
+			}
+		}
+		job.sums = sums
+		return nil
+	}
+	return lastErr
+}
+
+// finishLocked 记录任务结果并通知等待者
+func (q *Queue) finishLocked(job *Job, err error) {
+	now := time.Now()
+	job.err = err
+	job.info.FinishedAt = &now
+	switch {
+	case errors.Is(err, ErrJobCancelled):
+		job.info.Status = JobCancelled
+	case err != nil:
+		job.info.Status = JobFailed
+		job.info.Error = err.Error()
+	default:
+		job.info.Status = JobDone
+	}
+	job.cancel()
+	close(job.done)
+}
+
+// Cancel 取消任务，排队中的任务直接移出队列
+func (q *Queue) Cancel(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return fmt.Errorf("下载任务不存在: %s", id)
+	}
+
+	switch job.info.Status {
+	case JobQueued:
+		for i, pending := range q.pending {
+			if pending == job {
+				heap.Remove(&q.pending, i)
+				break
+			}
+		}
+		q.finishLocked(job, ErrJobCancelled)
+		q.saveLocked(true)
+	case JobRunning:
+		job.cancel()
+	default:
+		return fmt.Errorf("下载任务已结束: %s", id)
+	}
+	return nil
+}
+
+// CancelAll 取消所有未结束的任务
+func (q *Queue) CancelAll() {
+	for _, info := range q.Jobs() {
+		if info.Status == JobQueued || info.Status == JobRunning {
+			q.Cancel(info.ID)
+		}
+	}
+}
+
+// SetRateLimit 调整所有下载共享的速率上限，0表示不限速，正在进行的下载立即生效
+func (q *Queue) SetRateLimit(bytesPerSecond int64) {
+	q.downloader.SetRateLimit(bytesPerSecond)
+}
+
+// RateLimit 获取全局速率上限
+func (q *Queue) RateLimit() int64 {
+	return q.downloader.RateLimit()
+}
+
+// SetDefaultJobRateLimit 设置之后提交的任务默认的速率上限
+func (q *Queue) SetDefaultJobRateLimit(bytesPerSecond int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobRateLimit = bytesPerSecond
+}
+
+// SetJobRateLimit 调整单个任务的速率上限，0表示只受全局限速
+func (q *Queue) SetJobRateLimit(id string, bytesPerSecond int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return fmt.Errorf("下载任务不存在: %s", id)
+	}
+	if job.info.Status != JobQueued && job.info.Status != JobRunning {
+		return fmt.Errorf("下载任务已结束: %s", id)
+	}
+	job.limiter.SetRate(bytesPerSecond)
+	job.info.RateLimit = bytesPerSecond
+	q.saveLocked(true)
+	return nil
+}
+
+// Jobs 返回所有任务的状态快照（按提交顺序）
+func (q *Queue) Jobs() []JobInfo {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(q.order))
+	for _, job := range q.order {
+		infos = append(infos, job.info)
+	}
+	return infos
+}
+
+// Close 停止接受新任务，等待中的工作协程在队列清空后退出，并删除状态文件
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+	if q.stateDir != "" {
+		os.Remove(q.statePath())
+	}
+}
+
+// statePath 当前进程的状态文件
+func (q *Queue) statePath() string {
+	return filepath.Join(q.stateDir, fmt.Sprintf("%d.json", os.Getpid()))
+}
+
+// saveLocked 保存队列状态，进度更新最多每秒保存一次
+func (q *Queue) saveLocked(force bool) {
+	if q.stateDir == "" || q.closed {
+		return
+	}
+	if !force && time.Since(q.lastSave) < time.Second {
+		return
+	}
+	q.lastSave = time.Now()
+
+	infos := make([]JobInfo, 0, len(q.order))
+	for _, job := range q.order {
+		infos = append(infos, job.info)
+	}
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(q.stateDir, 0755); err != nil {
+		return
+	}
+	tempPath := q.statePath() + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return
+	}
+	os.Rename(tempPath, q.statePath())
+}
+
+// watchRequests 处理其他进程写入的取消和限速标记
+func (q *Queue) watchRequests() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		q.mu.Lock()
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return
+		}
+
+		markers, _ := filepath.Glob(filepath.Join(q.stateDir, fmt.Sprintf("cancel-%d-*", os.Getpid())))
+		for _, marker := range markers {
+			id := strings.TrimPrefix(filepath.Base(marker), "cancel-")
+			q.Cancel(id)
+			os.Remove(marker)
+		}
+
+		// limit-<pid> 为全局限速，limit-<pid>-<seq> 为单个任务的限速
+		markers, _ = filepath.Glob(filepath.Join(q.stateDir, fmt.Sprintf("limit-%d-*", os.Getpid())))
+		global := filepath.Join(q.stateDir, fmt.Sprintf("limit-%d", os.Getpid()))
+		if _, err := os.Stat(global); err == nil {
+			markers = append(markers, global)
+		}
+		for _, marker := range markers {
+			data, err := os.ReadFile(marker)
+			os.Remove(marker)
+			if err != nil {
+				continue
+			}
+			rate, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+			if err != nil {
+				continue
+			}
+			id := strings.TrimPrefix(filepath.Base(marker), "limit-")
+			if id == strconv.Itoa(os.Getpid()) {
+				q.SetRateLimit(rate)
+			} else {
+				q.SetJobRateLimit(id, rate)
+			}
+		}
+	}
+}
+
+// ListQueueJobs 读取所有进程的下载队列状态，已退出进程的状态文件会被清理
+func ListQueueJobs(stateDir string) ([]JobInfo, error) {
+	files, err := filepath.Glob(filepath.Join(stateDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []JobInfo
+	for _, file := range files {
+		pid, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		if !processAlive(pid) {
+			os.Remove(file)
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var jobs []JobInfo
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			continue
+		}
+		infos = append(infos, jobs...)
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos, nil
+}
+
+// RequestCancel 请求取消其他进程中的下载任务
+func RequestCancel(stateDir, id string) error {
+	jobs, err := ListQueueJobs(stateDir)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.ID != id {
+			continue
+		}
+		if job.Status != JobQueued && job.Status != JobRunning {
+			return fmt.Errorf("下载任务已结束: %s", id)
+		}
+		if err := os.WriteFile(filepath.Join(stateDir, "cancel-"+id), nil, 0644); err != nil {
+			return fmt.Errorf("写入取消请求失败: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("下载任务不存在: %s", id)
+}
+
+// RequestRateLimit 请求调整其他进程中的限速，id为空时调整所有进程的全局限速
+func RequestRateLimit(stateDir, id string, bytesPerSecond int64) error {
+	jobs, err := ListQueueJobs(stateDir)
+	if err != nil {
+		return err
+	}
+
+	value := []byte(strconv.FormatInt(bytesPerSecond, 10))
+	if id == "" {
+		pids := make(map[int]bool)
+		for _, job := range jobs {
+			if !pids[job.PID] && (job.Status == JobQueued || job.Status == JobRunning) {
+				pids[job.PID] = true
+				if err := os.WriteFile(filepath.Join(stateDir, fmt.Sprintf("limit-%d", job.PID)), value, 0644); err != nil {
+					return fmt.Errorf("写入限速请求失败: %w", err)
+				}
+			}
+		}
+		if len(pids) == 0 {
+			return errors.New("没有正在进行的下载")
+		}
+		return nil
+	}
+
+	for _, job := range jobs {
+		if job.ID != id {
+			continue
+		}
+		if job.Status != JobQueued && job.Status != JobRunning {
+			return fmt.Errorf("下载任务已结束: %s", id)
+		}
+		if err := os.WriteFile(filepath.Join(stateDir, "limit-"+id), value, 0644); err != nil {
+			return fmt.Errorf("写入限速请求失败: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("下载任务不存在: %s", id)
+}
@@ -0,0 +1,181 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minBurst 令牌桶的最小容量，保证小限速下每次读取也不会太碎
+const minBurst = 4 * 1024
+
+// RateLimiter 令牌桶限速器，速率为每秒字节数，0表示不限速
+//
+// 桶容量为一秒的流量；令牌不足时预支并等待，所以运行时调整速率最多延迟一次读取生效。
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter 创建限速器
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetRate(bytesPerSecond)
+	return l
+}
+
+// SetRate 调整速率，0表示不限速
+func (l *RateLimiter) SetRate(bytesPerSecond int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+	l.rate = bytesPerSecond
+	l.tokens = 0
+	l.last = time.Now()
+}
+
+// Rate 当前速率，0表示不限速
+func (l *RateLimiter) Rate() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// burst 单次读取的最大字节数
+func (l *RateLimiter) burst() int {
+	rate := l.Rate()
+	if rate == 0 {
+		return 0
+	}
+	if rate < minBurst {
+		return minBurst
+	}
+	return int(rate)
+}
+
+// WaitN 取出n个令牌，不足时等待；ctx取消时返回错误
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if l.rate == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	wait := time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimiterKey 上下文中单个任务的限速器
+type rateLimiterKey struct{}
+
+// withRateLimiter 为下载附加单独的限速器，与全局限速同时生效
+func withRateLimiter(ctx context.Context, limiter *RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, limiter)
+}
+
+// limitedReader 按限速器读取数据
+type limitedReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	limiters []*RateLimiter
+}
+
+// newLimitedReader 创建限速读取器，没有生效的限速器时返回原读取器
+func newLimitedReader(ctx context.Context, reader io.Reader, limiters ...*RateLimiter) io.Reader {
+	var active []*RateLimiter
+	for _, limiter := range limiters {
+		if limiter != nil {
+			active = append(active, limiter)
+		}
+	}
+	if len(active) == 0 {
+		return reader
+	}
+	return &limitedReader{ctx: ctx, reader: reader, limiters: active}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	for _, limiter := range r.limiters {
+		if burst := limiter.burst(); burst > 0 && len(p) > burst {
+			p = p[:burst]
+		}
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		for _, limiter := range r.limiters {
+			if waitErr := limiter.WaitN(r.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
+
+// ParseRate 解析限速配置，如 "512K"、"2MB"、"1.5M/s"，纯数字为每秒字节数，空字符串或0表示不限速
+func ParseRate(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "/S")
+	s = strings.TrimSuffix(s, "B")
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := float64(1)
+	switch s[len(s)-1] {
+	case 'K':
+		multiplier = 1024
+	case 'M':
+		multiplier = 1024 * 1024
+	case 'G':
+		multiplier = 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的限速: %s", value)
+	}
+	return int64(n * multiplier), nil
+}
+
+// FormatRate 格式化限速，0显示为"不限速"
+func FormatRate(bytesPerSecond int64) string {
+	if bytesPerSecond <= 0 {
+		return "不限速"
+	}
+	return FormatSpeed(float64(bytesPerSecond))
+}
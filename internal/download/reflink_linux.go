@@ -0,0 +1,31 @@
+//go:build linux
+
+package download
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone ioctl(FICLONE)，在btrfs、xfs等文件系统上共享数据块
+const ficlone = 0x40049409
+
+// reflink 创建写时复制的文件副本，文件系统不支持时返回错误
+func reflink(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, out.Fd(), ficlone, in.Fd()); errno != 0 {
+		return errno
+	}
+	return nil
+}
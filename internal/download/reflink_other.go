@@ -0,0 +1,10 @@
+//go:build !linux
+
+package download
+
+import "errors"
+
+// reflink 当前平台不支持reflink
+func reflink(src, dst string) error {
+	return errors.New("当前平台不支持reflink")
+}
@@ -0,0 +1,135 @@
+package download
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"easilypanel/internal/httpclient"
+)
+
+// mojangManifest Mojang版本清单
+type mojangManifest struct {
+	Latest struct {
+		Release  string `json:"release"`
+		Snapshot string `json:"snapshot"`
+	} `json:"latest"`
+	Versions []struct {
+		ID          string    `json:"id"`
+		Type        string    `json:"type"` // release, snapshot, old_beta, old_alpha
+		URL         string    `json:"url"`
+		ReleaseTime time.Time `json:"releaseTime"`
+	} `json:"versions"`
+}
+
+// mojangVersion 单个版本的元数据
+type mojangVersion struct {
+	ID        string `json:"id"`
+	Downloads struct {
+		Server *struct {
+			SHA1 string `json:"sha1"`
+			Size int64  `json:"size"`
+			URL  string `json:"url"`
+		} `json:"server"`
+	} `json:"downloads"`
+}
+
+// VanillaProvider 从Mojang官方版本清单下载原版服务端
+type VanillaProvider struct {
+	dm          *DownloadManager
+	manifestURL string
+}
+
+// Name 服务端类型名称
+func (p *VanillaProvider) Name() string {
+	return "vanilla"
+}
+
+// manifest 获取版本清单
+func (p *VanillaProvider) manifest() (*mojangManifest, error) {
+	var manifest mojangManifest
+	client := httpclient.New(0)
+	if err := getJSON(client, p.manifestURL, &manifest); err != nil {
+		return nil, fmt.Errorf("获取Mojang版本清单失败: %w", err)
+	}
+	return &manifest, nil
+}
+
+// ListVersions 列出正式版本（清单本身按发布时间从新到旧排列）
+func (p *VanillaProvider) ListVersions() ([]string, error) {
+	manifest, err := p.manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, v := range manifest.Versions {
+		if v.Type == "release" {
+			versions = append(versions, v.ID)
+		}
+	}
+	return versions, nil
+}
+
+// ListBuilds 原版每个版本只有一个构建
+func (p *VanillaProvider) ListBuilds(mcVersion string) ([]string, error) {
+	return []string{mcVersion}, nil
+}
+
+// Install 下载原版服务端jar并校验SHA1
+func (p *VanillaProvider) Install(req InstallRequest) (*CoreInstall, error) {
+	manifest, err := p.manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	mcVersion := req.MCVersion
+	if mcVersion == "" || mcVersion == "latest" {
+		mcVersion = manifest.Latest.Release
+	}
+
+	var versionURL string
+	var releaseTime time.Time
+	for _, v := range manifest.Versions {
+		if v.ID == mcVersion {
+			versionURL, releaseTime = v.URL, v.ReleaseTime
+			break
+		}
+	}
+	if versionURL == "" {
+		return nil, fmt.Errorf("未找到Minecraft版本: %s", mcVersion)
+	}
+
+	var meta mojangVersion
+	client := httpclient.New(0)
+	if err := getJSON(client, versionURL, &meta); err != nil {
+		return nil, fmt.Errorf("获取版本信息失败: %w", err)
+	}
+	server := meta.Downloads.Server
+	if server == nil {
+		return nil, fmt.Errorf("Minecraft %s 没有官方服务端", mcVersion)
+	}
+
+	fileName := fmt.Sprintf("minecraft_server.%s.jar", mcVersion)
+	path, err := p.dm.fetchCached(server.URL, fileName, server.SHA1, "mojang", req.ShowProgress)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.dm.LinkToWorkDir(path, server.SHA1, req.WorkDir, fileName); err != nil {
+		return nil, err
+	}
+
+	return &CoreInstall{
+		ServerJar: fileName,
+		Core: CoreDownload{
+			Path:        filepath.Join(req.WorkDir, fileName),
+			ServerName:  p.Name(),
+			MCVersion:   mcVersion,
+			CoreVersion: mcVersion,
+			UpdateTime:  releaseTime.Format("2006-01-02T15:04:05"),
+			SHA1:        server.SHA1,
+			Source:      "mojang",
+			URL:         server.URL,
+		},
+	}, nil
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"easilypanel/internal/httpclient"
 )
 
 const (
@@ -29,12 +31,10 @@ func NewOpenFRPClient(baseURL, authorization string) *OpenFRPClient {
 	}
 	
 	return &OpenFRPClient{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient:    httpclient.New(0),
 		baseURL:       baseURL,
 		authorization: authorization,
-		userAgent:     DefaultUserAgent,
+		userAgent:     httpclient.UserAgent(),
 	}
 }
 
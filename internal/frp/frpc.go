@@ -14,6 +14,8 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"easilypanel/internal/httpclient"
 )
 
 // FRPCManager frpc客户端管理器
@@ -107,7 +109,7 @@ func (m *FRPCManager) DownloadFRPC() error {
 
 // getLatestVersion 获取最新版本信息
 func (m *FRPCManager) getLatestVersion() (*VersionInfo, error) {
-	resp, err := http.Get("https://api.openfrp.net/commonQuery/get?key=software")
+	resp, err := httpclient.New(0).Get("https://api.openfrp.net/commonQuery/get?key=software")
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +133,7 @@ func (m *FRPCManager) downloadFile(url, filename string) error {
 	}
 	
 	// 下载文件
-	resp, err := http.Get(url)
+	resp, err := httpclient.New(5 * time.Minute).Get(url)
 	if err != nil {
 		return err
 	}
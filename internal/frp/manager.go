@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"easilypanel/internal/audit"
 )
 
 // Manager FRP管理器，整合API客户端和frpc管理
@@ -15,6 +17,7 @@ type Manager struct {
 	frpc       *FRPCManager
 	dataDir    string
 	authorized bool
+	actor      audit.Actor
 }
 
 // NewManager 创建新的FRP管理器
@@ -32,9 +35,15 @@ func NewManager(dataDir string) *Manager {
 		client:  NewOpenFRPClient("", ""),
 		frpc:    NewFRPCManager(binaryPath, configPath, logPath),
 		dataDir: dataDir,
+		actor:   audit.DefaultActor(),
 	}
 }
 
+// SetActor 设置审计日志中记录的操作者
+func (m *Manager) SetActor(actor audit.Actor) {
+	m.actor = actor
+}
+
 // SetAuthorization 设置认证令牌
 func (m *Manager) SetAuthorization(auth string) {
 	m.client.SetAuthorization(auth)
@@ -112,6 +121,13 @@ func (m *Manager) GetAvailableNodes() ([]NodeInfo, error) {
 
 // CreateProxy 创建隧道
 func (m *Manager) CreateProxy(req *CreateProxyRequest) error {
+	err := m.createProxy(req)
+	audit.Record(m.actor, "frp.CreateProxy", req.Name, audit.StructParams(req), err)
+	return err
+}
+
+// createProxy 调用API创建隧道
+func (m *Manager) createProxy(req *CreateProxyRequest) error {
 	if !m.authorized {
 		return fmt.Errorf("未设置认证令牌")
 	}
@@ -121,6 +137,13 @@ func (m *Manager) CreateProxy(req *CreateProxyRequest) error {
 
 // EditProxy 编辑隧道
 func (m *Manager) EditProxy(req *EditProxyRequest) error {
+	err := m.editProxy(req)
+	audit.Record(m.actor, "frp.EditProxy", strconv.Itoa(req.ProxyID), audit.StructParams(req), err)
+	return err
+}
+
+// editProxy 调用API编辑隧道
+func (m *Manager) editProxy(req *EditProxyRequest) error {
 	if !m.authorized {
 		return fmt.Errorf("未设置认证令牌")
 	}
@@ -130,6 +153,13 @@ func (m *Manager) EditProxy(req *EditProxyRequest) error {
 
 // DeleteProxy 删除隧道
 func (m *Manager) DeleteProxy(proxyID int) error {
+	err := m.deleteProxy(proxyID)
+	audit.Record(m.actor, "frp.DeleteProxy", strconv.Itoa(proxyID), nil, err)
+	return err
+}
+
+// deleteProxy 调用API删除隧道
+func (m *Manager) deleteProxy(proxyID int) error {
 	if !m.authorized {
 		return fmt.Errorf("未设置认证令牌")
 	}
@@ -173,27 +203,44 @@ func (m *Manager) GenerateConfig(serverAddr, token string) error {
 		}
 	}
 	
-	return m.frpc.GenerateConfig(serverAddr, token, enabledProxies)
+	err = m.frpc.GenerateConfig(serverAddr, token, enabledProxies)
+	audit.Record(m.actor, "frp.GenerateConfig", "frpc", map[string]interface{}{
+		"server_addr": serverAddr,
+		"token":       token,
+		"proxies":     len(enabledProxies),
+	}, err)
+	return err
 }
 
 // StartFRPC 启动frpc（使用配置文件方式）
 func (m *Manager) StartFRPC() error {
-	return m.frpc.Start()
+	err := m.frpc.Start()
+	audit.Record(m.actor, "frp.StartFRPC", "frpc", nil, err)
+	return err
 }
 
 // StartFRPCWithCommand 使用命令行方式启动frpc
 func (m *Manager) StartFRPCWithCommand(userToken string, proxyIDs []string) error {
-	return m.frpc.StartWithCommand(userToken, proxyIDs)
+	err := m.frpc.StartWithCommand(userToken, proxyIDs)
+	audit.Record(m.actor, "frp.StartFRPC", "frpc", map[string]interface{}{
+		"user_token": userToken,
+		"proxy_ids":  proxyIDs,
+	}, err)
+	return err
 }
 
 // StopFRPC 停止frpc
 func (m *Manager) StopFRPC() error {
-	return m.frpc.Stop()
+	err := m.frpc.Stop()
+	audit.Record(m.actor, "frp.StopFRPC", "frpc", nil, err)
+	return err
 }
 
 // RestartFRPC 重启frpc
 func (m *Manager) RestartFRPC() error {
-	return m.frpc.Restart()
+	err := m.frpc.Restart()
+	audit.Record(m.actor, "frp.RestartFRPC", "frpc", nil, err)
+	return err
 }
 
 // GetFRPCStatus 获取frpc状态
@@ -0,0 +1,146 @@
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent 未配置 network.user_agent 时使用的User-Agent
+const DefaultUserAgent = "EasilyPanel5/1.0.0"
+
+// defaultTimeout 未配置 network.timeout 时API请求的超时时间
+const defaultTimeout = 30 * time.Second
+
+// Options 网络设置（对应配置中的 network 部分）
+type Options struct {
+	ProxyEnabled bool
+	ProxyType    string // http、https 或 socks5
+	ProxyHost    string
+	ProxyPort    int
+	ProxyUser    string
+	ProxyPass    string
+	Timeout      time.Duration // API请求的超时时间
+	UserAgent    string
+}
+
+// ProxyURL 根据设置生成代理地址，未启用代理时返回nil
+func (o Options) ProxyURL() (*url.URL, error) {
+	if !o.ProxyEnabled || o.ProxyHost == "" {
+		return nil, nil
+	}
+
+	scheme := strings.ToLower(o.ProxyType)
+	switch scheme {
+	case "", "http":
+		scheme = "http"
+	case "https":
+	case "socks5", "socks5h", "socks":
+		// 由代理解析域名，避免被墙的DNS
+		scheme = "socks5h"
+	default:
+		return nil, fmt.Errorf("不支持的代理类型: %s", o.ProxyType)
+	}
+
+	u := &url.URL{Scheme: scheme, Host: o.ProxyHost}
+	if o.ProxyPort > 0 {
+		u.Host = net.JoinHostPort(o.ProxyHost, strconv.Itoa(o.ProxyPort))
+	}
+	if o.ProxyUser != "" {
+		u.User = url.UserPassword(o.ProxyUser, o.ProxyPass)
+	}
+	return u, nil
+}
+
+var (
+	defaultMu        sync.RWMutex
+	defaultOptions   = Options{Timeout: defaultTimeout, UserAgent: DefaultUserAgent}
+	defaultTransport = newTransport(nil, defaultTimeout)
+)
+
+// Init 设置全局网络设置，之后所有通过 New 创建的客户端都会使用
+func Init(opts Options) error {
+	proxy, err := opts.ProxyURL()
+	if err != nil {
+		return err
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultOptions = opts
+	defaultTransport = newTransport(proxy, opts.Timeout)
+	return nil
+}
+
+// newTransport 创建使用代理的Transport，proxy为nil时使用环境变量中的代理
+//
+// 等待响应头的时间不超过timeout；响应体的读取时间不受限制，由下载器自行检测停滞。
+func newTransport(proxy *url.URL, timeout time.Duration) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return transport
+}
+
+// UserAgent 获取配置的User-Agent
+func UserAgent() string {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultOptions.UserAgent
+}
+
+// Timeout 获取配置的API请求超时时间
+func Timeout() time.Duration {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultOptions.Timeout
+}
+
+// New 创建使用全局网络设置的HTTP客户端，timeout为0时使用 network.timeout
+func New(timeout time.Duration) *http.Client {
+	if timeout == 0 {
+		timeout = Timeout()
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: roundTripper{},
+	}
+}
+
+// NewDownload 创建用于下载大文件的HTTP客户端
+//
+// 不设置整个请求的超时，慢速或限速的下载不会被中途中止；连接和等待响应头仍受 network.timeout 限制，
+// 读取响应体时的停滞由调用方检测。
+func NewDownload() *http.Client {
+	return &http.Client{Transport: roundTripper{}}
+}
+
+// roundTripper 每次请求时使用当前的全局Transport，Init之前创建的客户端也能使用代理；
+// 请求没有设置User-Agent时使用配置的User-Agent
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	defaultMu.RLock()
+	transport := defaultTransport
+	userAgent := defaultOptions.UserAgent
+	defaultMu.RUnlock()
+
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent)
+	}
+	return transport.RoundTrip(req)
+}
@@ -0,0 +1,85 @@
+package instance
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"easilypanel/internal/download"
+)
+
+// CoreVerification 核心文件校验结果
+type CoreVerification struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	OK       bool   `json:"ok"`
+}
+
+// SetCore 记录通过下载管理器安装的核心的版本和来源
+func (i *Instance) SetCore(core *download.CoreDownload) {
+	i.CoreVersion = core.CoreVersion
+	i.CoreBuildTime = core.UpdateTime
+	i.CoreSHA1 = strings.ToLower(core.SHA1)
+	i.CoreSource = core.Source
+	i.CoreSourceURL = core.URL
+}
+
+// ApplyCoreInstall 使用核心提供者的安装结果设置启动方式和来源
+func (i *Instance) ApplyCoreInstall(install *download.CoreInstall) {
+	i.ServerJar = install.ServerJar
+	i.ArgsFile = install.ArgsFile
+	i.SetCore(&install.Core)
+}
+
+// restoreCore 恢复核心版本和来源记录（升级回滚时使用）
+func (i *Instance) restoreCore(old *Instance) {
+	i.ServerJar = old.ServerJar
+	i.ArgsFile = old.ArgsFile
+	i.CoreVersion = old.CoreVersion
+	i.CoreBuildTime = old.CoreBuildTime
+	i.CoreSHA1 = old.CoreSHA1
+	i.CoreSource = old.CoreSource
+	i.CoreSourceURL = old.CoreSourceURL
+}
+
+// VerifyCore 重新计算核心jar（或参数文件）的SHA1并与下载时记录的值比较
+func (m *Manager) VerifyCore(name string) (*CoreVerification, error) {
+	inst, err := m.GetInstance(name)
+	if err != nil {
+		return nil, err
+	}
+	launchFile := inst.ServerJar
+	if inst.ArgsFile != "" {
+		launchFile = inst.ArgsFile
+	}
+	if launchFile == "" {
+		return nil, fmt.Errorf("实例 '%s' 未设置服务器JAR文件", name)
+	}
+	if inst.CoreSHA1 == "" {
+		return nil, fmt.Errorf("实例 '%s' 没有记录核心的SHA1（不是通过下载管理器安装的核心）", name)
+	}
+
+	path := jarPath(inst.GetWorkDir(m.dataDir), filepath.FromSlash(launchFile))
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开核心文件失败: %w", err)
+	}
+	defer file.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("计算文件哈希失败: %w", err)
+	}
+
+	actual := fmt.Sprintf("%x", h.Sum(nil))
+	return &CoreVerification{
+		Path:     path,
+		Expected: inst.CoreSHA1,
+		Actual:   actual,
+		OK:       strings.EqualFold(actual, inst.CoreSHA1),
+	}, nil
+}
@@ -0,0 +1,284 @@
+package instance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiagnosisKind 诊断类型
+type DiagnosisKind string
+
+const (
+	DiagnosisJVMCrash    DiagnosisKind = "jvm_crash"     // JVM崩溃，生成了 hs_err_pid*.log
+	DiagnosisOutOfMemory DiagnosisKind = "out_of_memory" // java.lang.OutOfMemoryError 或本机内存不足
+	DiagnosisPortInUse   DiagnosisKind = "port_in_use"   // 端口被占用
+	DiagnosisJavaTooOld  DiagnosisKind = "java_too_old"  // UnsupportedClassVersionError
+)
+
+// Diagnosis 对一次异常退出原因的诊断
+type Diagnosis struct {
+	Kind    DiagnosisKind `json:"kind"`
+	Summary string        `json:"summary"`
+	Detail  string        `json:"detail,omitempty"` // 日志或崩溃文件中的原始信息
+	Remedy  string        `json:"remedy"`           // 建议的处理方式
+	File    string        `json:"file,omitempty"`   // 相关文件，如崩溃日志
+}
+
+// Event 实例的运行事件，如异常退出
+type Event struct {
+	Time      time.Time   `json:"time"`
+	Message   string      `json:"message"`
+	Diagnoses []Diagnosis `json:"diagnoses,omitempty"`
+}
+
+// crashContext 诊断一次运行所需的信息
+type crashContext struct {
+	pid       int
+	startedAt time.Time
+	logOffset int64 // 启动前日志文件的大小，之后的内容属于本次运行
+}
+
+// maxEvents 实例配置中保留的事件数量
+const maxEvents = 20
+
+// diagnoseTailLines 诊断时检查的日志末尾行数
+const diagnoseTailLines = 300
+
+// AddEvent 记录事件，只保留最近的 maxEvents 条
+func (i *Instance) AddEvent(event Event) {
+	i.Events = append(i.Events, event)
+	if len(i.Events) > maxEvents {
+		i.Events = i.Events[len(i.Events)-maxEvents:]
+	}
+}
+
+// LastEvent 返回最近的事件，没有时返回nil
+func (i *Instance) LastEvent() *Event {
+	if len(i.Events) == 0 {
+		return nil
+	}
+	return &i.Events[len(i.Events)-1]
+}
+
+var (
+	oomPattern          = regexp.MustCompile(`java\.lang\.OutOfMemoryError:?\s*(.*)`)
+	classVersionPattern = regexp.MustCompile(`class file version (\d+)(?:\.\d+)?\).*?up to (\d+)(?:\.\d+)?`)
+	legacyClassPattern  = regexp.MustCompile(`Unsupported major\.minor version (\d+)`) // Java 7及更早版本的提示
+	portPattern         = regexp.MustCompile(`(?i)failed to bind to port|address already in use`)
+)
+
+// Diagnose 根据工作目录中的崩溃文件和本次运行的日志末尾，分析实例异常退出的原因
+//
+// 优先使用进程pid对应的 hs_err_pid<pid>.log，没有时使用since之后生成的最新崩溃文件。
+func Diagnose(inst *Instance, workDir string, logTail []string, pid int, since time.Time) []Diagnosis {
+	var diagnoses []Diagnosis
+	seen := make(map[DiagnosisKind]bool)
+	add := func(d Diagnosis) {
+		if !seen[d.Kind] {
+			seen[d.Kind] = true
+			diagnoses = append(diagnoses, d)
+		}
+	}
+
+	if crashFile := findCrashFile(workDir, pid, since); crashFile != "" {
+		for _, d := range diagnoseCrashFile(inst, crashFile) {
+			add(d)
+		}
+	}
+
+	for _, line := range logTail {
+		if m := oomPattern.FindStringSubmatch(line); m != nil {
+			add(outOfMemoryDiagnosis(inst, strings.TrimSpace(m[1]), strings.TrimSpace(line)))
+		}
+		if m := classVersionPattern.FindStringSubmatch(line); m != nil {
+			add(classVersionDiagnosis(inst, m[1], m[2], strings.TrimSpace(line)))
+		} else if m := legacyClassPattern.FindStringSubmatch(line); m != nil {
+			add(classVersionDiagnosis(inst, m[1], "", strings.TrimSpace(line)))
+		}
+		if portPattern.MatchString(line) {
+			add(portInUseDiagnosis(inst, strings.TrimSpace(line)))
+		}
+	}
+	return diagnoses
+}
+
+// findCrashFile 查找工作目录中本次运行生成的 hs_err_pid*.log
+func findCrashFile(workDir string, pid int, since time.Time) string {
+	if pid > 0 {
+		path := filepath.Join(workDir, fmt.Sprintf("hs_err_pid%d.log", pid))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(workDir, "hs_err_pid*.log"))
+	var newest string
+	var newestTime time.Time
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// diagnoseCrashFile 读取 hs_err_pid*.log 的头部，区分本机内存不足和JVM崩溃
+func diagnoseCrashFile(inst *Instance, path string) []Diagnosis {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var header []string
+	var frame string
+	scanner := bufio.NewScanner(file)
+	for lines := 0; scanner.Scan() && lines < 40; lines++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "#"))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "---------------") {
+			break
+		}
+		header = append(header, line)
+		if strings.HasPrefix(line, "Problematic frame:") && scanner.Scan() {
+			frame = strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "#"))
+			header = append(header, frame)
+		}
+	}
+	text := strings.Join(header, "\n")
+
+	if strings.Contains(text, "insufficient memory for the Java Runtime Environment") ||
+		strings.Contains(text, "Native memory allocation") {
+		return []Diagnosis{{
+			Kind:    DiagnosisOutOfMemory,
+			Summary: "本机内存不足，JVM无法分配内存",
+			Detail:  text,
+			Remedy: fmt.Sprintf("降低实例的最大内存 (当前 %s)，或关闭占用内存的其他程序；"+
+				"也可以在配置中降低 instance.max_memory_percent", displayMemory(inst.MaxMemory)),
+			File: path,
+		}}
+	}
+
+	summary := "JVM崩溃"
+	for _, line := range header {
+		if strings.HasPrefix(line, "SIG") || strings.HasPrefix(line, "EXCEPTION_") || strings.HasPrefix(line, "Internal Error") {
+			summary = fmt.Sprintf("JVM崩溃: %s", line)
+			break
+		}
+	}
+	remedy := "查看崩溃日志中的出错位置；如果在JVM内部 (libjvm)，尝试更换Java版本或供应商 ('instance java NAME 主版本:供应商')"
+	if frame != "" && !strings.Contains(frame, "libjvm") && !strings.Contains(frame, "jvm.dll") {
+		remedy = "出错位置不在JVM内部，检查服务端使用的本地库 (如插件或模组附带的 .so/.dll)，或尝试更换Java版本"
+	}
+	return []Diagnosis{{
+		Kind:    DiagnosisJVMCrash,
+		Summary: summary,
+		Detail:  text,
+		Remedy:  remedy,
+		File:    path,
+	}}
+}
+
+// outOfMemoryDiagnosis 根据 OutOfMemoryError 的原因给出建议
+func outOfMemoryDiagnosis(inst *Instance, cause, line string) Diagnosis {
+	d := Diagnosis{Kind: DiagnosisOutOfMemory, Detail: line}
+	switch {
+	case strings.Contains(cause, "unable to create native thread"), strings.Contains(cause, "unable to create new native thread"):
+		d.Summary = "无法创建新线程"
+		d.Remedy = "检查系统的线程数限制 (ulimit -u)，或排查创建大量线程的插件/模组"
+	case strings.Contains(cause, "Metaspace"):
+		d.Summary = "Metaspace内存不足"
+		d.Remedy = "模组或插件过多时可在额外参数中增加 -XX:MaxMetaspaceSize，或移除不需要的模组/插件"
+	default:
+		d.Summary = "Java堆内存不足"
+		if cause != "" {
+			d.Summary = fmt.Sprintf("Java堆内存不足 (%s)", cause)
+		}
+		d.Remedy = fmt.Sprintf("增加实例的最大内存 (当前 %s)，或减少视距、已加载区块和实体数量", displayMemory(inst.MaxMemory))
+	}
+	return d
+}
+
+// classVersionDiagnosis 把class文件版本换算为需要的Java主版本
+func classVersionDiagnosis(inst *Instance, required, supported, line string) Diagnosis {
+	requiredMajor := classFileJavaMajor(required)
+	summary := fmt.Sprintf("Java版本过低: 服务端需要Java %d", requiredMajor)
+	if supportedMajor := classFileJavaMajor(supported); supportedMajor > 0 {
+		summary += fmt.Sprintf("，当前为Java %d", supportedMajor)
+	}
+	return Diagnosis{
+		Kind:    DiagnosisJavaTooOld,
+		Summary: summary,
+		Detail:  line,
+		Remedy: fmt.Sprintf("运行 'instance java %s %d' 让实例使用Java %d；没有安装时可运行 'java install %d'",
+			inst.Name, requiredMajor, requiredMajor, requiredMajor),
+	}
+}
+
+// classFileJavaMajor class文件版本对应的Java主版本（52对应Java 8）
+func classFileJavaMajor(classVersion string) int {
+	v, err := strconv.Atoi(classVersion)
+	if err != nil || v <= 44 {
+		return 0
+	}
+	return v - 44
+}
+
+// portInUseDiagnosis 端口被占用
+func portInUseDiagnosis(inst *Instance, line string) Diagnosis {
+	summary := "服务端端口已被占用"
+	if inst.Port > 0 {
+		summary = fmt.Sprintf("端口 %d 已被占用", inst.Port)
+	}
+	return Diagnosis{
+		Kind:    DiagnosisPortInUse,
+		Summary: summary,
+		Detail:  line,
+		Remedy:  "停止占用该端口的程序 (可能是同一实例的旧进程或其他实例)，或修改 server.properties 中的 server-port",
+	}
+}
+
+// displayMemory 显示内存设置
+func displayMemory(memory string) string {
+	if memory == "" {
+		return "未设置"
+	}
+	return memory
+}
+
+// readLogTail 读取日志文件从offset开始的最后n行
+func readLogTail(path string, offset int64, n int) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines
+}
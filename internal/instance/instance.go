@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"easilypanel/internal/java"
 )
 
 // InstanceType 实例类型
@@ -40,17 +42,27 @@ type Instance struct {
 	// 路径信息
 	WorkDir     string `json:"work_dir"`
 	ServerJar   string `json:"server_jar,omitempty"`
+	ArgsFile    string `json:"args_file,omitempty"` // JVM参数文件（现代Forge/NeoForge），设置时以 @ArgsFile 启动
 	
 	// 启动配置
 	JavaPath    string   `json:"java_path"`
-	JavaArgs    []string `json:"java_args"`
+	JavaSelector *java.Selector `json:"java_selector,omitempty"` // 设置时忽略JavaPath，启动时按主版本解析Java
+	JavaArgs    []string `json:"java_args"`                    // 设置了JVMPreset时只包含追加在预设之后的额外参数
+	JVMPreset   string   `json:"jvm_preset,omitempty"`         // JVM参数预设名称，如 aikar；为空时JavaArgs为完整参数（旧实例）
 	ServerArgs  []string `json:"server_args"`
 	StartCmd    string   `json:"start_cmd,omitempty"` // 自定义启动命令（覆盖默认启动方式）
 	UseCustomCmd bool    `json:"use_custom_cmd"`     // 是否使用自定义启动命令
 	
 	// Minecraft特定配置
-	MCVersion   string `json:"mc_version,omitempty"`
-	ServerType  string `json:"server_type,omitempty"` // paper, fabric, forge等
+	MCVersion         string     `json:"mc_version,omitempty"`
+	ServerType        string     `json:"server_type,omitempty"`         // paper, fabric, forge等
+	CoreVersion       string     `json:"core_version,omitempty"`        // 服务端核心构建版本，如 build196
+	CoreBuildTime     string     `json:"core_build_time,omitempty"`     // 构建发布时间（下载源提供）
+	CoreSHA1          string     `json:"core_sha1,omitempty"`           // 核心jar的SHA1，用于校验
+	CoreSource        string     `json:"core_source,omitempty"`         // 下载源名称，如 fastmirror
+	CoreSourceURL     string     `json:"core_source_url,omitempty"`     // 核心的下载地址
+	LatestCoreVersion string     `json:"latest_core_version,omitempty"` // 后台检查到的最新构建
+	UpdateCheckedAt   *time.Time `json:"update_checked_at,omitempty"`
 	
 	// 运行时信息
 	Status      InstanceStatus `json:"status"`
@@ -66,12 +78,39 @@ type Instance struct {
 	// 自动化设置
 	AutoStart   bool `json:"auto_start"`
 	AutoRestart bool `json:"auto_restart"`
+	
+	// 运行事件（异常退出及诊断），只保留最近的若干条
+	Events      []Event `json:"events,omitempty"`
+	
+	resolvedJava *java.Java // 本次启动使用的Java，由JavaSelector解析或读取JavaPath得到
+}
+
+// defaultJavaArgs 新实例追加在JVM参数预设之后的额外参数（instance.default_java_args）
+var defaultJavaArgs []string
+
+// SetDefaultJavaArgs 设置新实例的额外JVM参数，应在创建实例之前调用
+//
+// 旧配置中的完整参数列表同样可用：-Xmx/-Xms由内存设置生成，默认预设已包含的参数不再重复添加。
+func SetDefaultJavaArgs(args []string) {
+	known := make(map[string]bool)
+	if preset, err := java.FindFlagPreset(java.DefaultFlagPreset); err == nil {
+		for _, flag := range preset.Flags {
+			known[flag] = true
+		}
+	}
+	defaultJavaArgs = nil
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-Xmx") || strings.HasPrefix(arg, "-Xms") || known[arg] {
+			continue
+		}
+		defaultJavaArgs = append(defaultJavaArgs, arg)
+	}
 }
 
-// NewMinecraftInstance 创建新的Minecraft实例
+// NewMinecraftInstance 创建新的Minecraft实例，javaPath也可以是Java选择器（如 "21"）
 func NewMinecraftInstance(name, mcVersion, serverType, javaPath string) *Instance {
 	now := time.Now()
-	return &Instance{
+	inst := &Instance{
 		Name:        name,
 		Type:        TypeMinecraft,
 		Description: fmt.Sprintf("Minecraft %s 服务器 (%s)", mcVersion, serverType),
@@ -79,37 +118,18 @@ func NewMinecraftInstance(name, mcVersion, serverType, javaPath string) *Instanc
 		UpdatedAt:   now,
 		MCVersion:   mcVersion,
 		ServerType:  serverType,
-		JavaPath:    javaPath,
 		Status:      StatusStopped,
 		AutoStart:   false,
 		AutoRestart: false,
 		Port:        25565, // 默认Minecraft端口
 		MaxMemory:   "2G",
 		MinMemory:   "1G",
-		JavaArgs: []string{
-			"-Xmx2G",
-			"-Xms1G",
-			"-XX:+UseG1GC",
-			"-XX:+ParallelRefProcEnabled",
-			"-XX:MaxGCPauseMillis=200",
-			"-XX:+UnlockExperimentalVMOptions",
-			"-XX:+DisableExplicitGC",
-			"-XX:+AlwaysPreTouch",
-			"-XX:G1NewSizePercent=30",
-			"-XX:G1MaxNewSizePercent=40",
-			"-XX:G1HeapRegionSize=8M",
-			"-XX:G1ReservePercent=20",
-			"-XX:G1HeapWastePercent=5",
-			"-XX:G1MixedGCCountTarget=4",
-			"-XX:InitiatingHeapOccupancyPercent=15",
-			"-XX:G1MixedGCLiveThresholdPercent=90",
-			"-XX:G1RSetUpdatingPauseTimePercent=5",
-			"-XX:SurvivorRatio=32",
-			"-XX:+PerfDisableSharedMem",
-			"-XX:MaxTenuringThreshold=1",
-		},
-		ServerArgs: []string{"nogui"},
+		JVMPreset:   java.DefaultFlagPreset,
+		JavaArgs:    append([]string{}, defaultJavaArgs...),
+		ServerArgs:  []string{"nogui"},
 	}
+	inst.SetJava(javaPath)
+	return inst
 }
 
 // NewBlankInstance 创建新的空白实例
@@ -151,6 +171,12 @@ func (i *Instance) SetWorkDir(dataDir string) {
 func (i *Instance) UpdateMemorySettings(minMem, maxMem string) {
 	i.MinMemory = minMem
 	i.MaxMemory = maxMem
+	i.UpdatedAt = time.Now()
+	
+	// 使用预设时启动参数由内存设置生成
+	if i.JVMPreset != "" {
+		return
+	}
 	
 	// 更新Java参数中的内存设置
 	var newJavaArgs []string
@@ -192,20 +218,81 @@ func (i *Instance) GetStartCommand() (string, []string, error) {
 	}
 
 	// Minecraft实例使用默认Java启动方式
-	if i.JavaPath == "" {
+	javaPath := i.JavaPath
+	if i.JavaSelector != nil {
+		if i.resolvedJava == nil {
+			return "", nil, fmt.Errorf("Java选择器 %s 尚未解析", i.JavaSelector)
+		}
+		javaPath = i.resolvedJava.Path
+	}
+	if javaPath == "" {
 		return "", nil, fmt.Errorf("未设置Java路径")
 	}
 
-	if i.ServerJar == "" {
+	if i.ServerJar == "" && i.ArgsFile == "" {
 		return "", nil, fmt.Errorf("未设置服务器JAR文件")
 	}
 
+	javaArgs, _, err := i.BuildJavaArgs()
+	if err != nil {
+		return "", nil, err
+	}
+
 	var args []string
-	args = append(args, i.JavaArgs...)
-	args = append(args, "-jar", i.ServerJar)
+	args = append(args, javaArgs...)
+	if i.ArgsFile != "" {
+		args = append(args, "@"+i.ArgsFile)
+	} else {
+		args = append(args, "-jar", i.ServerJar)
+	}
 	args = append(args, i.ServerArgs...)
 
-	return i.JavaPath, args, nil
+	return javaPath, args, nil
+}
+
+// SetJava 根据文本设置Java：主版本选择器（如 "21"、"21:temurin"）或java可执行文件路径
+func (i *Instance) SetJava(value string) {
+	if selector, err := java.ParseSelector(value); err == nil {
+		i.JavaSelector = selector
+	} else {
+		i.JavaSelector = nil
+		i.JavaPath = value
+	}
+	i.UpdatedAt = time.Now()
+}
+
+// JavaBinding 返回实例Java设置的描述
+func (i *Instance) JavaBinding() string {
+	if i.JavaSelector != nil {
+		return fmt.Sprintf("Java %s (启动时选择)", i.JavaSelector)
+	}
+	return i.JavaPath
+}
+
+// SetResolvedJava 设置本次启动使用的Java；设置了JavaSelector时使用其路径，否则只用于检查JVM参数与Java版本是否兼容
+func (i *Instance) SetResolvedJava(resolved *java.Java) {
+	i.resolvedJava = resolved
+}
+
+// BuildJavaArgs 生成JVM参数，返回参数和警告
+//
+// 使用预设时由预设、内存设置和额外参数生成，并按已解析的Java版本检查兼容性；旧实例直接使用JavaArgs。
+func (i *Instance) BuildJavaArgs() ([]string, []string, error) {
+	if i.JVMPreset == "" {
+		return i.JavaArgs, nil, nil
+	}
+
+	major := 0
+	if i.resolvedJava != nil {
+		major = java.MajorVersion(i.resolvedJava.Version)
+	}
+	return java.BuildJVMArgs(java.JVMArgsOptions{
+		Preset:    i.JVMPreset,
+		MaxMemory: i.MaxMemory,
+		MinMemory: i.MinMemory,
+		JavaMajor: major,
+		ExtraArgs: i.JavaArgs,
+	})
 }
 
 // UpdateStatus 更新实例状态
@@ -229,6 +316,11 @@ func (i *Instance) SetPID(pid int) {
 	i.UpdatedAt = time.Now()
 }
 
+// CoreOutdated 后台检查发现有更新的服务端核心构建
+func (i *Instance) CoreOutdated() bool {
+	return i.CoreVersion != "" && i.LatestCoreVersion != "" && i.LatestCoreVersion != i.CoreVersion
+}
+
 // IsRunning 检查实例是否正在运行
 func (i *Instance) IsRunning() bool {
 	return i.Status == StatusRunning || i.Status == StatusStarting
@@ -241,8 +333,8 @@ func (i *Instance) Validate() error {
 	}
 	
 	if i.Type == TypeMinecraft {
-		if i.JavaPath == "" {
-			return fmt.Errorf("Minecraft实例必须设置Java路径")
+		if i.JavaPath == "" && i.JavaSelector == nil {
+			return fmt.Errorf("Minecraft实例必须设置Java路径或Java选择器")
 		}
 		if i.MCVersion == "" {
 			return fmt.Errorf("Minecraft实例必须设置MC版本")
@@ -283,8 +375,23 @@ func (i *Instance) Save(dataDir string) error {
 		return fmt.Errorf("序列化实例配置失败: %w", err)
 	}
 	
-	// 写入文件
-	if err := os.WriteFile(configFile, data, 0644); err != nil {
+	// 先写入临时文件再重命名，进程监控和停止同时保存时不会写坏配置文件
+	temp, err := os.CreateTemp(filepath.Dir(configFile), filepath.Base(configFile)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), configFile)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
 		return fmt.Errorf("写入配置文件失败: %w", err)
 	}
 	
@@ -1,26 +1,47 @@
 package instance
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"easilypanel/internal/audit"
 )
 
 // Manager 实例管理器
 type Manager struct {
 	dataDir string
+	actor   audit.Actor
 }
 
 // NewManager 创建新的实例管理器
 func NewManager(dataDir string) *Manager {
 	return &Manager{
 		dataDir: dataDir,
+		actor:   audit.DefaultActor(),
 	}
 }
 
+// SetActor 设置审计日志中记录的操作者
+func (m *Manager) SetActor(actor audit.Actor) {
+	m.actor = actor
+}
+
 // CreateMinecraftInstance 创建Minecraft实例
 func (m *Manager) CreateMinecraftInstance(name, mcVersion, serverType, javaPath string) (*Instance, error) {
+	instance, err := m.createMinecraftInstance(name, mcVersion, serverType, javaPath)
+	audit.Record(m.actor, "instance.CreateMinecraft", name, map[string]interface{}{
+		"mc_version":  mcVersion,
+		"server_type": serverType,
+		"java_path":   javaPath,
+	}, err)
+	return instance, err
+}
+
+// createMinecraftInstance 创建Minecraft实例（不记录审计日志）
+func (m *Manager) createMinecraftInstance(name, mcVersion, serverType, javaPath string) (*Instance, error) {
 	// 检查实例是否已存在
 	if m.InstanceExists(name) {
 		return nil, fmt.Errorf("实例 '%s' 已存在", name)
@@ -45,6 +66,16 @@ func (m *Manager) CreateMinecraftInstance(name, mcVersion, serverType, javaPath
 
 // CreateBlankInstance 创建空白实例
 func (m *Manager) CreateBlankInstance(name, description, startCmd string) (*Instance, error) {
+	instance, err := m.createBlankInstance(name, description, startCmd)
+	audit.Record(m.actor, "instance.CreateBlank", name, map[string]interface{}{
+		"description": description,
+		"start_cmd":   startCmd,
+	}, err)
+	return instance, err
+}
+
+// createBlankInstance 创建空白实例（不记录审计日志）
+func (m *Manager) createBlankInstance(name, description, startCmd string) (*Instance, error) {
 	// 检查实例是否已存在
 	if m.InstanceExists(name) {
 		return nil, fmt.Errorf("实例 '%s' 已存在", name)
@@ -116,6 +147,15 @@ func (m *Manager) ListInstances() ([]*Instance, error) {
 
 // DeleteInstance 删除实例
 func (m *Manager) DeleteInstance(name string, deleteFiles bool) error {
+	err := m.deleteInstance(name, deleteFiles)
+	audit.Record(m.actor, "instance.Delete", name, map[string]interface{}{
+		"delete_files": deleteFiles,
+	}, err)
+	return err
+}
+
+// deleteInstance 删除实例（不记录审计日志）
+func (m *Manager) deleteInstance(name string, deleteFiles bool) error {
 	// 检查实例是否存在
 	instance, err := m.GetInstance(name)
 	if err != nil {
@@ -152,9 +192,56 @@ func (m *Manager) InstanceExists(name string) bool {
 
 // UpdateInstance 更新实例配置
 func (m *Manager) UpdateInstance(instance *Instance) error {
+	// 与磁盘上的旧配置比较，只记录发生变化的字段
+	var changes map[string]interface{}
+	if old, err := m.GetInstance(instance.Name); err == nil {
+		changes = diffInstances(old, instance)
+	}
+
+	err := m.saveInstance(instance)
+	audit.Record(m.actor, "instance.Update", instance.Name, changes, err)
+	return err
+}
+
+// saveInstance 保存实例配置（运行状态变化等内部更新，不记录审计日志）
+func (m *Manager) saveInstance(instance *Instance) error {
 	return instance.Save(m.dataDir)
 }
 
+// diffInstances 比较两个实例配置，返回发生变化的字段及新值
+func diffInstances(old, updated *Instance) map[string]interface{} {
+	oldFields := audit.StructParams(old)
+	newFields := audit.StructParams(updated)
+
+	// 运行时字段不属于配置变更
+	ignored := map[string]bool{
+		"updated_at": true, "status": true, "pid": true,
+		"last_started": true, "last_stopped": true, "events": true,
+	}
+
+	changes := make(map[string]interface{})
+	for key, value := range newFields {
+		if ignored[key] {
+			continue
+		}
+		oldJSON, _ := json.Marshal(oldFields[key])
+		newJSON, _ := json.Marshal(value)
+		if string(oldJSON) != string(newJSON) {
+			changes[key] = value
+		}
+	}
+	for key := range oldFields {
+		if _, exists := newFields[key]; !exists && !ignored[key] {
+			changes[key] = nil
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
 // GetInstancesByType 根据类型获取实例列表
 func (m *Manager) GetInstancesByType(instanceType InstanceType) ([]*Instance, error) {
 	allInstances, err := m.ListInstances()
@@ -283,7 +370,13 @@ func (m *Manager) GetInstanceInfo(name string) (map[string]interface{}, error) {
 		info["mc_version"] = instance.MCVersion
 		info["server_type"] = instance.ServerType
 		info["server_jar"] = instance.ServerJar
+		if instance.ArgsFile != "" {
+			info["args_file"] = instance.ArgsFile
+		}
 		info["java_path"] = instance.JavaPath
+		if instance.JavaSelector != nil {
+			info["java_selector"] = instance.JavaSelector.String()
+		}
 		info["port"] = instance.Port
 		info["max_memory"] = instance.MaxMemory
 		info["min_memory"] = instance.MinMemory
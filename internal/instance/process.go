@@ -2,6 +2,7 @@ package instance
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,12 +13,17 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"easilypanel/internal/audit"
+	"easilypanel/internal/java"
 )
 
 // ProcessManager 进程管理器
 type ProcessManager struct {
-	dataDir string
-	manager *Manager
+	dataDir     string
+	manager     *Manager
+	actor       audit.Actor
+	javaManager *java.Manager // 解析实例的Java选择器
 }
 
 // NewProcessManager 创建新的进程管理器
@@ -25,11 +31,35 @@ func NewProcessManager(dataDir string) *ProcessManager {
 	return &ProcessManager{
 		dataDir: dataDir,
 		manager: NewManager(dataDir),
+		actor:   audit.DefaultActor(),
 	}
 }
 
+// SetActor 设置审计日志中记录的操作者
+func (pm *ProcessManager) SetActor(actor audit.Actor) {
+	pm.actor = actor
+	pm.manager.SetActor(actor)
+}
+
+// SetJavaManager 设置解析实例Java选择器使用的Java管理器
+func (pm *ProcessManager) SetJavaManager(javaManager *java.Manager) {
+	pm.javaManager = javaManager
+}
+
 // StartInstance 启动实例
 func (pm *ProcessManager) StartInstance(name string) error {
+	return pm.startInstance(name, pm.actor)
+}
+
+// startInstance 以指定操作者身份启动实例
+func (pm *ProcessManager) startInstance(name string, actor audit.Actor) error {
+	err := pm.doStartInstance(name)
+	audit.Record(actor, "instance.Start", name, nil, err)
+	return err
+}
+
+// doStartInstance 启动实例进程
+func (pm *ProcessManager) doStartInstance(name string) error {
 	// 获取实例
 	instance, err := pm.manager.GetInstance(name)
 	if err != nil {
@@ -43,15 +73,29 @@ func (pm *ProcessManager) StartInstance(name string) error {
 	
 	// 更新状态为启动中
 	instance.UpdateStatus(StatusStarting)
-	if err := pm.manager.UpdateInstance(instance); err != nil {
+	if err := pm.manager.saveInstance(instance); err != nil {
 		return fmt.Errorf("更新实例状态失败: %w", err)
 	}
 	
+	// 解析本次启动使用的Java并检查JVM参数
+	if instance.Type == TypeMinecraft && !(instance.UseCustomCmd && instance.StartCmd != "") {
+		if err := pm.resolveJava(instance); err != nil {
+			instance.UpdateStatus(StatusError)
+			pm.manager.saveInstance(instance)
+			return err
+		}
+		if _, warnings, err := instance.BuildJavaArgs(); err == nil {
+			for _, warning := range warnings {
+				fmt.Printf("⚠️  %s\n", warning)
+			}
+		}
+	}
+	
 	// 获取启动命令
 	command, args, err := instance.GetStartCommand()
 	if err != nil {
 		instance.UpdateStatus(StatusError)
-		pm.manager.UpdateInstance(instance)
+		pm.manager.saveInstance(instance)
 		return fmt.Errorf("获取启动命令失败: %w", err)
 	}
 	
@@ -67,10 +111,14 @@ func (pm *ProcessManager) StartInstance(name string) error {
 	
 	// 创建日志文件
 	logFile := filepath.Join(workDir, "server.log")
+	var logOffset int64
+	if info, err := os.Stat(logFile); err == nil {
+		logOffset = info.Size()
+	}
 	logWriter, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		instance.UpdateStatus(StatusError)
-		pm.manager.UpdateInstance(instance)
+		pm.manager.saveInstance(instance)
 		return fmt.Errorf("创建日志文件失败: %w", err)
 	}
 	
@@ -79,17 +127,18 @@ func (pm *ProcessManager) StartInstance(name string) error {
 	cmd.Stderr = logWriter
 	
 	// 启动进程
+	startedAt := time.Now()
 	if err := cmd.Start(); err != nil {
 		logWriter.Close()
 		instance.UpdateStatus(StatusError)
-		pm.manager.UpdateInstance(instance)
+		pm.manager.saveInstance(instance)
 		return fmt.Errorf("启动进程失败: %w", err)
 	}
 	
 	// 更新实例信息
 	instance.SetPID(cmd.Process.Pid)
 	instance.UpdateStatus(StatusRunning)
-	if err := pm.manager.UpdateInstance(instance); err != nil {
+	if err := pm.manager.saveInstance(instance); err != nil {
 		// 如果更新失败，尝试停止进程
 		cmd.Process.Kill()
 		logWriter.Close()
@@ -97,14 +146,41 @@ func (pm *ProcessManager) StartInstance(name string) error {
 	}
 	
 	// 启动监控协程
-	go pm.monitorProcess(instance, cmd, logWriter)
+	go pm.monitorProcess(instance, cmd, logWriter, crashContext{pid: cmd.Process.Pid, startedAt: startedAt, logOffset: logOffset})
 	
 	fmt.Printf("实例 '%s' 启动成功 (PID: %d)\n", name, cmd.Process.Pid)
 	return nil
 }
 
+// resolveJava 把实例的Java选择器解析为具体路径；使用固定路径时读取其版本，读取失败不影响启动
+func (pm *ProcessManager) resolveJava(instance *Instance) error {
+	if instance.JavaSelector == nil {
+		if pm.javaManager != nil {
+			instance.SetResolvedJava(pm.javaManager.Inspect(instance.JavaPath))
+		}
+		return nil
+	}
+	if pm.javaManager == nil {
+		return fmt.Errorf("无法解析Java选择器 %s: 未设置Java管理器", instance.JavaSelector)
+	}
+	resolved, err := pm.javaManager.Resolve(instance.JavaSelector)
+	if err != nil {
+		return fmt.Errorf("实例 '%s' 无法启动: %w", instance.Name, err)
+	}
+	fmt.Printf("使用Java %s (选择器 %s): %s\n", resolved.Description(), instance.JavaSelector, resolved.Path)
+	instance.SetResolvedJava(resolved)
+	return nil
+}
+
 // StopInstance 停止实例
 func (pm *ProcessManager) StopInstance(name string) error {
+	err := pm.stopInstance(name)
+	audit.Record(pm.actor, "instance.Stop", name, nil, err)
+	return err
+}
+
+// stopInstance 停止实例进程
+func (pm *ProcessManager) stopInstance(name string) error {
 	// 获取实例
 	instance, err := pm.manager.GetInstance(name)
 	if err != nil {
@@ -118,14 +194,14 @@ func (pm *ProcessManager) StopInstance(name string) error {
 	
 	// 更新状态为停止中
 	instance.UpdateStatus(StatusStopping)
-	if err := pm.manager.UpdateInstance(instance); err != nil {
+	if err := pm.manager.saveInstance(instance); err != nil {
 		return fmt.Errorf("更新实例状态失败: %w", err)
 	}
 	
 	// 查找进程
 	if instance.PID <= 0 {
 		instance.UpdateStatus(StatusStopped)
-		pm.manager.UpdateInstance(instance)
+		pm.manager.saveInstance(instance)
 		return fmt.Errorf("实例 '%s' 的PID无效", name)
 	}
 	
@@ -134,14 +210,14 @@ func (pm *ProcessManager) StopInstance(name string) error {
 		// 如果优雅停止失败，强制停止
 		if err := pm.forceStop(instance.PID); err != nil {
 			instance.UpdateStatus(StatusError)
-			pm.manager.UpdateInstance(instance)
+			pm.manager.saveInstance(instance)
 			return fmt.Errorf("停止进程失败: %w", err)
 		}
 	}
 	
 	// 更新实例状态
 	instance.UpdateStatus(StatusStopped)
-	if err := pm.manager.UpdateInstance(instance); err != nil {
+	if err := pm.manager.saveInstance(instance); err != nil {
 		return fmt.Errorf("更新实例状态失败: %w", err)
 	}
 	
@@ -214,13 +290,16 @@ func (pm *ProcessManager) forceStop(pid int) error {
 		cmd := exec.Command("taskkill", "/F", "/PID", strconv.Itoa(pid))
 		return cmd.Run()
 	} else {
-		// Unix系统使用SIGKILL
-		return process.Signal(syscall.SIGKILL)
+		// Unix系统使用SIGKILL，进程已被监控协程回收时视为已停止
+		if err := process.Signal(syscall.SIGKILL); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+		return nil
 	}
 }
 
 // monitorProcess 监控进程状态
-func (pm *ProcessManager) monitorProcess(instance *Instance, cmd *exec.Cmd, logWriter io.WriteCloser) {
+func (pm *ProcessManager) monitorProcess(instance *Instance, cmd *exec.Cmd, logWriter io.WriteCloser, run crashContext) {
 	defer logWriter.Close()
 	
 	// 等待进程结束
@@ -238,6 +317,7 @@ func (pm *ProcessManager) monitorProcess(instance *Instance, cmd *exec.Cmd, logW
 		if err != nil {
 			fmt.Printf("实例 '%s' 异常退出: %v\n", instance.Name, err)
 			currentInstance.UpdateStatus(StatusError)
+			pm.recordCrash(currentInstance, err, run)
 		} else {
 			fmt.Printf("实例 '%s' 正常退出\n", instance.Name)
 			currentInstance.UpdateStatus(StatusStopped)
@@ -248,7 +328,7 @@ func (pm *ProcessManager) monitorProcess(instance *Instance, cmd *exec.Cmd, logW
 			fmt.Printf("实例 '%s' 启用了自动重启，5秒后重新启动...\n", instance.Name)
 			time.Sleep(5 * time.Second)
 			
-			if restartErr := pm.StartInstance(instance.Name); restartErr != nil {
+			if restartErr := pm.startInstance(instance.Name, audit.SchedulerActor("auto-restart")); restartErr != nil {
 				fmt.Printf("自动重启实例 '%s' 失败: %v\n", instance.Name, restartErr)
 			}
 			return
@@ -259,7 +339,31 @@ func (pm *ProcessManager) monitorProcess(instance *Instance, cmd *exec.Cmd, logW
 	}
 	
 	// 保存状态
-	pm.manager.UpdateInstance(currentInstance)
+	pm.manager.saveInstance(currentInstance)
+}
+
+// recordCrash 诊断异常退出的原因，输出建议并记录到实例的事件历史
+func (pm *ProcessManager) recordCrash(instance *Instance, exitErr error, run crashContext) {
+	workDir := instance.GetWorkDir(pm.dataDir)
+	logTail := readLogTail(filepath.Join(workDir, "server.log"), run.logOffset, diagnoseTailLines)
+	diagnoses := Diagnose(instance, workDir, logTail, run.pid, run.startedAt)
+	
+	for _, d := range diagnoses {
+		fmt.Printf("  诊断: %s\n", d.Summary)
+		if d.File != "" {
+			fmt.Printf("    崩溃日志: %s\n", d.File)
+		}
+		fmt.Printf("    建议: %s\n", d.Remedy)
+	}
+	
+	instance.AddEvent(Event{
+		Time:      time.Now(),
+		Message:   fmt.Sprintf("异常退出: %v", exitErr),
+		Diagnoses: diagnoses,
+	})
+	if err := pm.manager.saveInstance(instance); err != nil {
+		fmt.Printf("警告: 保存实例 '%s' 的事件失败: %v\n", instance.Name, err)
+	}
 }
 
 // IsProcessRunning 检查进程是否正在运行
@@ -290,6 +394,15 @@ func (pm *ProcessManager) IsProcessRunning(pid int) bool {
 
 // SendCommand 向实例发送命令
 func (pm *ProcessManager) SendCommand(name, command string) error {
+	err := pm.sendCommand(name, command)
+	audit.Record(pm.actor, "instance.SendCommand", name, map[string]interface{}{
+		"command": command,
+	}, err)
+	return err
+}
+
+// sendCommand 向实例进程发送命令
+func (pm *ProcessManager) sendCommand(name, command string) error {
 	instance, err := pm.manager.GetInstance(name)
 	if err != nil {
 		return err
@@ -341,3 +454,61 @@ func (pm *ProcessManager) GetInstanceLogs(name string, lines int) ([]string, err
 	
 	return logLines, nil
 }
+
+// readyMarker 服务端启动完成时日志中出现的标记（如 "Done (12.345s)! For help, type "help""）
+const readyMarker = "Done ("
+
+// LogOffset 获取实例日志文件当前大小，配合 WaitForReady 只检查之后产生的日志
+func (pm *ProcessManager) LogOffset(name string) int64 {
+	instance, err := pm.manager.GetInstance(name)
+	if err != nil {
+		return 0
+	}
+
+	info, err := os.Stat(filepath.Join(instance.GetWorkDir(pm.dataDir), "server.log"))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// WaitForReady 等待实例启动完成，进程退出或超时返回错误
+func (pm *ProcessManager) WaitForReady(name string, offset int64, timeout time.Duration) error {
+	instance, err := pm.manager.GetInstance(name)
+	if err != nil {
+		return err
+	}
+
+	logFile := filepath.Join(instance.GetWorkDir(pm.dataDir), "server.log")
+	deadline := time.Now().Add(timeout)
+	var pending string
+
+	for {
+		if file, err := os.Open(logFile); err == nil {
+			if _, err := file.Seek(offset, io.SeekStart); err == nil {
+				data, _ := io.ReadAll(file)
+				offset += int64(len(data))
+				pending += string(data)
+			}
+			file.Close()
+		}
+
+		if strings.Contains(pending, readyMarker) {
+			return nil
+		}
+		// 只保留最后一行不完整的内容，避免占用过多内存
+		if idx := strings.LastIndex(pending, "\n"); idx >= 0 {
+			pending = pending[idx+1:]
+		}
+
+		current, err := pm.manager.GetInstance(name)
+		if err == nil && !pm.IsProcessRunning(current.PID) {
+			return fmt.Errorf("实例 '%s' 进程已退出", name)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待实例 '%s' 启动超时 (%s)", name, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,120 @@
+package instance
+
+import (
+	"fmt"
+	"time"
+
+	"easilypanel/internal/download"
+)
+
+// CoreUpdateChecker 检查实例的服务端核心是否有新构建
+type CoreUpdateChecker struct {
+	manager *Manager
+	source  download.MirrorSource
+}
+
+// NewCoreUpdateChecker 创建核心更新检查器，只检查从source安装核心的实例
+func NewCoreUpdateChecker(manager *Manager, source download.MirrorSource) *CoreUpdateChecker {
+	return &CoreUpdateChecker{
+		manager: manager,
+		source:  source,
+	}
+}
+
+// CheckAll 检查所有记录了核心版本的Minecraft实例，返回有可用更新的实例
+func (c *CoreUpdateChecker) CheckAll() ([]*Instance, []error) {
+	instances, err := c.manager.ListInstances()
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	// 相同服务端和MC版本的实例只查询一次
+	latest := make(map[string]string)
+	var outdated []*Instance
+	var errs []error
+
+	for _, inst := range instances {
+		if inst.Type != TypeMinecraft || inst.ServerType == "" || inst.MCVersion == "" || inst.CoreVersion == "" {
+			continue
+		}
+		// 只有同一下载源的构建可以互相比较（未记录来源的旧实例来自FastMirror）
+		coreSource := inst.CoreSource
+		if coreSource == "" {
+			coreSource = "fastmirror"
+		}
+		if coreSource != c.source.Name() {
+			continue
+		}
+
+		key := inst.ServerType + "/" + inst.MCVersion
+		build, ok := latest[key]
+		if !ok {
+			info, err := c.source.GetLatestBuild(inst.ServerType, inst.MCVersion)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", inst.Name, err))
+				continue
+			}
+			build = info.CoreVersion
+			latest[key] = build
+		}
+
+		updated, err := c.record(inst.Name, build)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", inst.Name, err))
+			continue
+		}
+		if updated.CoreOutdated() {
+			outdated = append(outdated, updated)
+		}
+	}
+
+	return outdated, errs
+}
+
+// record 保存检查结果（重新读取实例，避免覆盖期间的状态变化）
+func (c *CoreUpdateChecker) record(name, build string) (*Instance, error) {
+	inst, err := c.manager.GetInstance(name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	inst.LatestCoreVersion = build
+	inst.UpdateCheckedAt = &now
+
+	// 检查结果不是用户操作，直接保存而不记录审计日志
+	if err := c.manager.saveInstance(inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// Run 立即检查一次，之后按间隔定期检查，直到stop被关闭
+func (c *CoreUpdateChecker) Run(interval time.Duration, stop <-chan struct{}, report func([]*Instance, []error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		outdated, errs := c.CheckAll()
+		if report != nil {
+			report(outdated, errs)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CountOutdated 统计有可用核心更新的实例数量
+func CountOutdated(instances []*Instance) int {
+	count := 0
+	for _, inst := range instances {
+		if inst.CoreOutdated() {
+			count++
+		}
+	}
+	return count
+}
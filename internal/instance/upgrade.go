@@ -0,0 +1,252 @@
+package instance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"easilypanel/internal/audit"
+	"easilypanel/internal/download"
+)
+
+// UpgradeOptions 服务端核心升级选项
+type UpgradeOptions struct {
+	Build   string        // 目标构建版本，为空时使用最新构建
+	Start   bool          // 升级后启动实例并等待就绪，失败则回滚
+	Timeout time.Duration // 等待就绪的超时时间
+}
+
+// CoreUpgrade 一次核心升级的结果
+type CoreUpgrade struct {
+	Instance   string `json:"instance"`
+	ServerType string `json:"server_type"`
+	MCVersion  string `json:"mc_version"`
+	OldBuild   string `json:"old_build"`
+	NewBuild   string `json:"new_build"`
+	OldJar     string `json:"old_jar"`
+	NewJar     string `json:"new_jar"`
+	BackupFile string `json:"backup_file,omitempty"` // 旧核心的备份路径
+	RolledBack bool   `json:"rolled_back"`
+}
+
+// CoreUpgrader 原地升级实例的服务端核心
+type CoreUpgrader struct {
+	processManager *ProcessManager
+	downloads      *download.DownloadManager
+	actor          audit.Actor
+}
+
+// NewCoreUpgrader 创建核心升级器
+func NewCoreUpgrader(processManager *ProcessManager, downloads *download.DownloadManager) *CoreUpgrader {
+	return &CoreUpgrader{
+		processManager: processManager,
+		downloads:      downloads,
+		actor:          audit.DefaultActor(),
+	}
+}
+
+// SetActor 设置审计日志中记录的操作者
+func (u *CoreUpgrader) SetActor(actor audit.Actor) {
+	u.actor = actor
+	u.processManager.SetActor(actor)
+}
+
+// ResolveBuild 获取升级的目标构建，build为空时返回最新构建
+func (u *CoreUpgrader) ResolveBuild(inst *Instance, build string) (*download.CoreInfo, error) {
+	if inst.Type != TypeMinecraft || inst.ServerType == "" || inst.MCVersion == "" {
+		return nil, fmt.Errorf("实例 '%s' 不是通过下载源创建的Minecraft实例，无法升级核心", inst.Name)
+	}
+	// 不同下载源的构建号不能互相比较，始终使用安装核心的下载源
+	mirror, err := u.downloads.Mirror(inst.CoreSource)
+	if err != nil {
+		return nil, fmt.Errorf("实例 '%s' 的核心来自 %s，请使用 'instance install-core' 重新安装", inst.Name, inst.CoreSource)
+	}
+
+	if build == "" {
+		latest, err := mirror.GetLatestBuild(inst.ServerType, inst.MCVersion)
+		if err != nil {
+			return nil, err
+		}
+		build = latest.CoreVersion
+	}
+
+	return mirror.GetCoreInfo(inst.ServerType, inst.MCVersion, build)
+}
+
+// Upgrade 下载并校验新核心，备份旧核心后替换，失败时恢复旧核心
+func (u *CoreUpgrader) Upgrade(name string, opts UpgradeOptions) (*CoreUpgrade, error) {
+	result, err := u.upgrade(name, opts)
+
+	params := map[string]interface{}{
+		"build": opts.Build,
+		"start": opts.Start,
+	}
+	if result != nil {
+		params["old_build"] = result.OldBuild
+		params["new_build"] = result.NewBuild
+		params["rolled_back"] = result.RolledBack
+	}
+	audit.Record(u.actor, "instance.UpgradeCore", name, params, err)
+
+	return result, err
+}
+
+// upgrade 执行核心升级
+func (u *CoreUpgrader) upgrade(name string, opts UpgradeOptions) (*CoreUpgrade, error) {
+	manager := u.processManager.manager
+	inst, err := manager.GetInstance(name)
+	if err != nil {
+		return nil, err
+	}
+	if inst.IsRunning() && u.processManager.IsProcessRunning(inst.PID) {
+		return nil, fmt.Errorf("实例 '%s' 正在运行，请先停止", name)
+	}
+
+	core, err := u.ResolveBuild(inst, opts.Build)
+	if err != nil {
+		return nil, err
+	}
+	if core.CoreVersion == inst.CoreVersion {
+		return nil, fmt.Errorf("实例 '%s' 已是 %s %s", name, inst.ServerType, core.CoreVersion)
+	}
+	if core.SHA1 == "" {
+		return nil, fmt.Errorf("下载源未提供 %s 的SHA1，无法校验", core.CoreVersion)
+	}
+
+	downloaded, err := u.downloads.DownloadCoreFrom(inst.CoreSource, inst.ServerType, inst.MCVersion, core.CoreVersion, true)
+	if err != nil {
+		return nil, err
+	}
+	old := *inst
+
+	result := &CoreUpgrade{
+		Instance:   name,
+		ServerType: inst.ServerType,
+		MCVersion:  inst.MCVersion,
+		OldBuild:   inst.CoreVersion,
+		NewBuild:   core.CoreVersion,
+		OldJar:     inst.ServerJar,
+		NewJar:     filepath.Base(downloaded.Path),
+	}
+
+	workDir := inst.GetWorkDir(u.processManager.dataDir)
+	if err := u.swapJar(workDir, downloaded.Path, core.SHA1, result); err != nil {
+		return nil, err
+	}
+
+	inst.ServerJar = result.NewJar
+	inst.SetCore(downloaded)
+	if err := manager.UpdateInstance(inst); err != nil {
+		u.restore(workDir, result)
+		return nil, err
+	}
+
+	if !opts.Start {
+		return result, nil
+	}
+
+	if err := u.startAndWait(name, opts.Timeout); err != nil {
+		if rollbackErr := u.rollback(name, workDir, result, &old); rollbackErr != nil {
+			return result, fmt.Errorf("启动失败: %v，且回滚失败: %w", err, rollbackErr)
+		}
+		result.RolledBack = true
+		return result, fmt.Errorf("新核心启动失败，已恢复旧核心: %w", err)
+	}
+
+	return result, nil
+}
+
+// swapJar 备份旧核心并把新核心复制到工作目录
+func (u *CoreUpgrader) swapJar(workDir, downloaded, sha1 string, result *CoreUpgrade) error {
+	if result.OldJar != "" {
+		oldPath := jarPath(workDir, result.OldJar)
+		if _, err := os.Stat(oldPath); err == nil {
+			backupDir := filepath.Join(workDir, "backups", "core-"+time.Now().Format("20060102-150405"))
+			if err := os.MkdirAll(backupDir, 0755); err != nil {
+				return fmt.Errorf("创建备份目录失败: %w", err)
+			}
+			result.BackupFile = filepath.Join(backupDir, filepath.Base(oldPath))
+			if err := os.Rename(oldPath, result.BackupFile); err != nil {
+				return fmt.Errorf("备份旧核心失败: %w", err)
+			}
+		}
+	}
+
+	newPath := filepath.Join(workDir, result.NewJar)
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		u.restore(workDir, result)
+		return fmt.Errorf("创建工作目录失败: %w", err)
+	}
+	if err := u.downloads.LinkToWorkDir(downloaded, sha1, workDir, result.NewJar); err != nil {
+		u.restore(workDir, result)
+		return err
+	}
+	if err := u.downloads.VerifyFile(newPath, sha1); err != nil {
+		u.restore(workDir, result)
+		return err
+	}
+
+	return nil
+}
+
+// startAndWait 启动实例并等待就绪，失败时停止实例
+func (u *CoreUpgrader) startAndWait(name string, timeout time.Duration) error {
+	offset := u.processManager.LogOffset(name)
+	if err := u.processManager.StartInstance(name); err != nil {
+		return err
+	}
+
+	if err := u.processManager.WaitForReady(name, offset, timeout); err != nil {
+		if inst, getErr := u.processManager.manager.GetInstance(name); getErr == nil && u.processManager.IsProcessRunning(inst.PID) {
+			u.processManager.StopInstance(name)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// rollback 恢复旧核心和实例配置
+func (u *CoreUpgrader) rollback(name, workDir string, result *CoreUpgrade, old *Instance) error {
+	if err := u.restore(workDir, result); err != nil {
+		return err
+	}
+
+	manager := u.processManager.manager
+	inst, err := manager.GetInstance(name)
+	if err != nil {
+		return err
+	}
+	inst.restoreCore(old)
+	return manager.UpdateInstance(inst)
+}
+
+// restore 删除新核心并把备份移回原位置
+func (u *CoreUpgrader) restore(workDir string, result *CoreUpgrade) error {
+	newPath := filepath.Join(workDir, result.NewJar)
+	if result.BackupFile == "" {
+		os.Remove(newPath)
+		return nil
+	}
+
+	oldPath := jarPath(workDir, result.OldJar)
+	if newPath != oldPath {
+		os.Remove(newPath)
+	}
+	if err := os.Rename(result.BackupFile, oldPath); err != nil {
+		return fmt.Errorf("恢复旧核心失败: %w", err)
+	}
+
+	// 备份目录只包含这一个文件，恢复后删除
+	os.Remove(filepath.Dir(result.BackupFile))
+	return nil
+}
+
+// jarPath 获取核心文件的完整路径（ServerJar可能是相对工作目录的路径）
+func jarPath(workDir, serverJar string) string {
+	if filepath.IsAbs(serverJar) {
+		return serverJar
+	}
+	return filepath.Join(workDir, serverJar)
+}
@@ -4,18 +4,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 )
 
 // Java Java安装信息结构
 type Java struct {
-	Path    string `json:"path"`
-	Version string `json:"version"`
+	Path        string `json:"path"`
+	Version     string `json:"version"`
+	FullVersion string `json:"full_version,omitempty"` // 完整版本，如 21.0.2+13-LTS
+	Vendor      string `json:"vendor,omitempty"`
+	Arch        string `json:"arch,omitempty"`
+	IsJDK       bool   `json:"is_jdk"`
+	Home        string `json:"home,omitempty"`
 }
 
 // String 返回Java信息的字符串表示
@@ -29,11 +34,39 @@ func (j *Java) Equal(other *Java) bool {
 	return j.Path == other.Path && j.Version == other.Version
 }
 
+// Description 返回版本、供应商、类型和架构的简短描述，如 "21.0.2+13-LTS (Eclipse Adoptium, JDK, x86_64)"
+func (j *Java) Description() string {
+	version := j.FullVersion
+	if version == "" {
+		version = j.Version
+	}
+	details := []string{}
+	if j.Vendor != "" {
+		details = append(details, j.Vendor)
+	}
+	details = append(details, j.Kind())
+	if j.Arch != "" {
+		details = append(details, j.Arch)
+	}
+	return fmt.Sprintf("%s (%s)", version, strings.Join(details, ", "))
+}
+
+// Kind 返回 JDK 或 JRE
+func (j *Java) Kind() string {
+	if j.IsJDK {
+		return "JDK"
+	}
+	return "JRE"
+}
+
 // Detector Java检测器
 type Detector struct {
 	matchKeywords   []string
 	excludeKeywords []string
 	foundJava       []*Java
+	searchPaths     []string   // 配置的额外搜索目录，递归扫描
+	excludePaths    []string   // 配置的排除目录
+	cache           *ScanCache // 按文件指纹缓存检测结果，为nil时不缓存
 }
 
 // NewDetector 创建新的Java检测器
@@ -65,15 +98,16 @@ func NewDetector() *Detector {
 
 // GetJavaVersion 获取Java版本信息
 func (d *Detector) GetJavaVersion(javaPath string) string {
-	cmd := exec.Command(javaPath, "-version")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return ""
+	if java := d.Inspect(javaPath); java != nil {
+		return java.Version
 	}
-	
-	// 从输出中提取版本信息
+	return ""
+}
+
+// parseVersionOutput 从 java -version 的输出中提取版本号
+func parseVersionOutput(output string) string {
 	versionPattern := regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:[._](\d+))?(?:-(.+))?`)
-	matches := versionPattern.FindStringSubmatch(string(output))
+	matches := versionPattern.FindStringSubmatch(output)
 	
 	if len(matches) > 1 {
 		// 过滤空字符串并连接版本号
@@ -110,57 +144,6 @@ func (d *Detector) findStr(s string) bool {
 	return false
 }
 
-// searchInDirectory 在目录中搜索Java可执行文件
-func (d *Detector) searchInDirectory(dir string, fullSearch bool) []*Java {
-	var javaList []*Java
-	
-	if !fullSearch {
-		return javaList
-	}
-	
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 忽略错误，继续搜索
-		}
-		
-		// 检查是否是Java可执行文件
-		if d.isJavaExecutable(path) {
-			// 检查路径是否匹配关键词
-			if d.findStr(filepath.Dir(path)) {
-				version := d.GetJavaVersion(path)
-				if version != "" {
-					java := &Java{
-						Path:    path,
-						Version: version,
-					}
-					
-					// 检查是否已存在
-					exists := false
-					for _, existing := range javaList {
-						if existing.Equal(java) {
-							exists = true
-							break
-						}
-					}
-					
-					if !exists {
-						javaList = append(javaList, java)
-					}
-				}
-			}
-		}
-		
-		return nil
-	})
-	
-	if err != nil {
-		// 记录错误但继续执行
-		fmt.Printf("搜索目录 %s 时出错: %v\n", dir, err)
-	}
-	
-	return javaList
-}
-
 // isJavaExecutable 检查文件是否是Java可执行文件
 func (d *Detector) isJavaExecutable(path string) bool {
 	if runtime.GOOS == "windows" {
@@ -169,225 +152,19 @@ func (d *Detector) isJavaExecutable(path string) bool {
 	return strings.HasSuffix(path, "bin/java")
 }
 
-// DetectJava 检测系统中的Java安装
-func (d *Detector) DetectJava(fullSearch bool) ([]*Java, error) {
-	d.foundJava = make([]*Java, 0)
-
-	switch runtime.GOOS {
-	case "windows":
-		return d.detectWindowsJava(fullSearch)
-	case "darwin":
-		return d.detectMacOSJava(fullSearch)
-	case "linux":
-		return d.detectLinuxJava(fullSearch)
-	default:
-		return d.detectLinuxJava(fullSearch) // 默认使用Linux逻辑
-	}
-}
-
-// detectWindowsJava 检测Windows系统中的Java
-func (d *Detector) detectWindowsJava(fullSearch bool) ([]*Java, error) {
-	var javaList []*Java
-	
-	// 搜索所有驱动器
-	for drive := 'C'; drive <= 'Z'; drive++ {
-		drivePath := fmt.Sprintf("%c:\\", drive)
-		if _, err := os.Stat(drivePath); err == nil {
-			found := d.searchInDirectory(drivePath, fullSearch)
-			javaList = append(javaList, found...)
-		}
-	}
-	
-	return javaList, nil
-}
-
-// detectMacOSJava 检测macOS系统中的Java
-func (d *Detector) detectMacOSJava(fullSearch bool) ([]*Java, error) {
-	var javaList []*Java
-	
-	// 检查环境变量PATH
-	javaList = append(javaList, d.checkPathEnvironment()...)
-	
-	// 检查macOS特定路径
-	macOSPaths := []string{
-		"/Applications/Xcode.app/Contents/Applications/Application Loader.app/Contents/MacOS/itms/java",
-		"/Library/Internet Plug-Ins/JavaAppletPlugin.plugin/Contents/Home/bin/java",
-		"/System/Library/Frameworks/JavaVM.framework/Versions/Current/Commands/java",
-	}
-	
-	for _, path := range macOSPaths {
-		if _, err := os.Stat(path); err == nil {
-			version := d.GetJavaVersion(path)
-			if version != "" {
-				java := &Java{Path: path, Version: version}
-				if !d.containsJava(javaList, java) {
-					javaList = append(javaList, java)
-				}
-			}
-		}
-	}
-	
-	// 检查默认安装路径
-	basePath := "/Library/Java/JavaVirtualMachines/"
-	if entries, err := os.ReadDir(basePath); err == nil {
-		for _, entry := range entries {
-			if entry.IsDir() {
-				javaPath := filepath.Join(basePath, entry.Name(), "Contents/Home/bin/java")
-				if _, err := os.Stat(javaPath); err == nil {
-					version := d.GetJavaVersion(javaPath)
-					if version != "" {
-						java := &Java{Path: javaPath, Version: version}
-						if !d.containsJava(javaList, java) {
-							javaList = append(javaList, java)
-						}
-					}
-				}
-			}
-		}
-	}
-	
-	return javaList, nil
-}
-
-// detectLinuxJava 检测Linux系统中的Java
-func (d *Detector) detectLinuxJava(fullSearch bool) ([]*Java, error) {
-	var javaList []*Java
-	
-	// 检查环境变量PATH
-	javaList = append(javaList, d.checkPathEnvironment()...)
-	
-	// 检查Linux默认安装路径
-	linuxPaths := []string{
-		"/usr",
-		"/usr/java",
-		"/usr/lib/jvm",
-		"/usr/lib64/jvm",
-		"/opt/jdk",
-		"/opt/jdks",
-	}
-	
-	for _, basePath := range linuxPaths {
-		// 检查直接路径
-		javaPath := filepath.Join(basePath, "bin/java")
-		if _, err := os.Stat(javaPath); err == nil {
-			version := d.GetJavaVersion(javaPath)
-			if version != "" {
-				java := &Java{Path: javaPath, Version: version}
-				if !d.containsJava(javaList, java) {
-					javaList = append(javaList, java)
-				}
-			}
-		}
-		
-		// 检查JRE路径
-		jrePath := filepath.Join(basePath, "jre/bin/java")
-		if _, err := os.Stat(jrePath); err == nil {
-			version := d.GetJavaVersion(jrePath)
-			if version != "" {
-				java := &Java{Path: jrePath, Version: version}
-				if !d.containsJava(javaList, java) {
-					javaList = append(javaList, java)
-				}
-			}
-		}
-		
-		// 遍历子目录
-		if entries, err := os.ReadDir(basePath); err == nil {
-			for _, entry := range entries {
-				if entry.IsDir() {
-					subPath := filepath.Join(basePath, entry.Name())
-					
-					// 检查子目录的bin/java
-					subJavaPath := filepath.Join(subPath, "bin/java")
-					if _, err := os.Stat(subJavaPath); err == nil {
-						version := d.GetJavaVersion(subJavaPath)
-						if version != "" {
-							java := &Java{Path: subJavaPath, Version: version}
-							if !d.containsJava(javaList, java) {
-								javaList = append(javaList, java)
-							}
-						}
-					}
-					
-					// 检查子目录的jre/bin/java
-					subJrePath := filepath.Join(subPath, "jre/bin/java")
-					if _, err := os.Stat(subJrePath); err == nil {
-						version := d.GetJavaVersion(subJrePath)
-						if version != "" {
-							java := &Java{Path: subJrePath, Version: version}
-							if !d.containsJava(javaList, java) {
-								javaList = append(javaList, java)
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-	
-	return javaList, nil
-}
-
-// checkPathEnvironment 检查环境变量PATH中的Java
-func (d *Detector) checkPathEnvironment() []*Java {
-	var javaList []*Java
-
-	pathEnv := os.Getenv("PATH")
-	if pathEnv == "" {
-		return javaList
-	}
-
-	separator := ":"
-	if runtime.GOOS == "windows" {
-		separator = ";"
-	}
-
-	paths := strings.Split(pathEnv, separator)
-	for _, path := range paths {
-		path = strings.TrimSpace(path)
-		if path == "" {
-			continue
-		}
-
-		var javaPath string
-		if runtime.GOOS == "windows" {
-			javaPath = filepath.Join(path, "java.exe")
-		} else {
-			javaPath = filepath.Join(path, "java")
-		}
-
-		if _, err := os.Stat(javaPath); err == nil {
-			version := d.GetJavaVersion(javaPath)
-			if version != "" {
-				java := &Java{Path: javaPath, Version: version}
-				if !d.containsJava(javaList, java) {
-					javaList = append(javaList, java)
-				}
-			}
-		}
-	}
-
-	return javaList
-}
-
-// containsJava 检查Java列表中是否包含指定的Java
-func (d *Detector) containsJava(javaList []*Java, target *Java) bool {
-	for _, java := range javaList {
-		if java.Equal(target) {
-			return true
-		}
-	}
-	return false
-}
-
 // CheckJavaAvailability 检查Java是否可用
 func (d *Detector) CheckJavaAvailability(java *Java) bool {
 	if _, err := os.Stat(java.Path); err != nil {
 		return false
 	}
 
-	version := d.GetJavaVersion(java.Path)
-	return version == java.Version
+	// 旧列表中的版本格式可能不同（1.8.0.392 与 1.8.0_392），按解析后的版本比较
+	current, err := ParseJavaVersion(d.GetJavaVersion(java.Path))
+	if err != nil {
+		return false
+	}
+	saved, err := ParseJavaVersion(java.Version)
+	return err == nil && current.Compare(saved) == 0
 }
 
 // SaveJavaList 保存Java列表到文件
@@ -438,21 +215,17 @@ func LoadJavaList(filePath string) ([]*Java, error) {
 	return javaList, nil
 }
 
-// SortJavaList 对Java列表按版本排序
+// SortJavaList 对Java列表按版本排序，无法解析的版本排在最后
 func SortJavaList(javaList []*Java, reverse bool) {
-	// 简单的字符串排序，可以根据需要改进为版本号排序
-	for i := 0; i < len(javaList)-1; i++ {
-		for j := i + 1; j < len(javaList); j++ {
-			var shouldSwap bool
-			if reverse {
-				shouldSwap = javaList[i].Version < javaList[j].Version
-			} else {
-				shouldSwap = javaList[i].Version > javaList[j].Version
-			}
-
-			if shouldSwap {
-				javaList[i], javaList[j] = javaList[j], javaList[i]
-			}
+	sort.SliceStable(javaList, func(i, j int) bool {
+		a, errA := ParseJavaVersion(javaList[i].Version)
+		b, errB := ParseJavaVersion(javaList[j].Version)
+		if errA != nil || errB != nil {
+			return errA == nil && errB != nil
 		}
-	}
+		if reverse {
+			return a.Compare(b) > 0
+		}
+		return a.Compare(b) < 0
+	})
 }
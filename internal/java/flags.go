@@ -0,0 +1,255 @@
+package java
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// 内置的JVM参数预设名称
+const (
+	PresetAikar   = "aikar"
+	PresetZGC     = "zgc"
+	PresetMinimal = "minimal"
+)
+
+// DefaultFlagPreset 新实例默认使用的预设
+const DefaultFlagPreset = PresetAikar
+
+// DefaultMaxMemoryPercent 默认允许-Xmx占用本机内存的最大百分比
+const DefaultMaxMemoryPercent = 80
+
+// FlagPreset 命名的JVM参数预设，不含内存参数
+type FlagPreset struct {
+	Name        string
+	Description string
+	MinJava     int      // 需要的最低Java主版本
+	Flags       []string // 参数列表
+	flagsFor    func(major int) []string
+}
+
+// FlagsFor 返回在指定Java主版本上使用的参数，major为0表示未知
+func (p *FlagPreset) FlagsFor(major int) []string {
+	if p.flagsFor != nil {
+		return p.flagsFor(major)
+	}
+	return p.Flags
+}
+
+// aikarFlags Aikar的G1调优参数，https://docs.papermc.io/paper/aikars-flags
+var aikarFlags = []string{
+	"-XX:+UseG1GC",
+	"-XX:+ParallelRefProcEnabled",
+	"-XX:MaxGCPauseMillis=200",
+	"-XX:+UnlockExperimentalVMOptions",
+	"-XX:+DisableExplicitGC",
+	"-XX:+AlwaysPreTouch",
+	"-XX:G1NewSizePercent=30",
+	"-XX:G1MaxNewSizePercent=40",
+	"-XX:G1HeapRegionSize=8M",
+	"-XX:G1ReservePercent=20",
+	"-XX:G1HeapWastePercent=5",
+	"-XX:G1MixedGCCountTarget=4",
+	"-XX:InitiatingHeapOccupancyPercent=15",
+	"-XX:G1MixedGCLiveThresholdPercent=90",
+	"-XX:G1RSetUpdatingPauseTimePercent=5",
+	"-XX:SurvivorRatio=32",
+	"-XX:+PerfDisableSharedMem",
+	"-XX:MaxTenuringThreshold=1",
+}
+
+// zgcFlags 分代ZGC参数；Java 23起分代模式为默认值，不再需要 -XX:+ZGenerational
+var zgcFlags = []string{
+	"-XX:+UseZGC",
+	"-XX:+ZGenerational",
+	"-XX:+AlwaysPreTouch",
+	"-XX:+DisableExplicitGC",
+	"-XX:+PerfDisableSharedMem",
+}
+
+// flagMinJava 需要较新Java的参数，在旧版本上JVM会因无法识别而拒绝启动
+var flagMinJava = map[string]int{
+	"-XX:+ZGenerational":           21,
+	"-XX:+UseZGC":                  15,
+	"-XX:+UseShenandoahGC":         12,
+	"-XX:+UseCompactObjectHeaders": 24,
+}
+
+// FlagPresets 内置的JVM参数预设
+var FlagPresets = []*FlagPreset{
+	{
+		Name:        PresetAikar,
+		Description: "Aikar的G1调优参数，适合大多数服务端",
+		MinJava:     8,
+		Flags:       aikarFlags,
+	},
+	{
+		Name:        PresetZGC,
+		Description: "分代ZGC，停顿更短，适合大内存服务端",
+		MinJava:     21,
+		Flags:       zgcFlags,
+		flagsFor: func(major int) []string {
+			if major >= 23 {
+				return removeFlag(zgcFlags, "-XX:+ZGenerational")
+			}
+			return zgcFlags
+		},
+	},
+	{
+		Name:        PresetMinimal,
+		Description: "只设置内存，使用JVM的默认参数",
+		MinJava:     8,
+	},
+}
+
+// FindFlagPreset 按名称查找预设
+func FindFlagPreset(name string) (*FlagPreset, error) {
+	for _, preset := range FlagPresets {
+		if strings.EqualFold(preset.Name, name) {
+			return preset, nil
+		}
+	}
+	names := make([]string, 0, len(FlagPresets))
+	for _, preset := range FlagPresets {
+		names = append(names, preset.Name)
+	}
+	return nil, fmt.Errorf("未知的JVM参数预设: %s (可用: %s)", name, strings.Join(names, ", "))
+}
+
+// maxMemoryPercent -Xmx占用本机内存的上限百分比，0表示不限制
+var maxMemoryPercent atomic.Int32
+
+func init() {
+	maxMemoryPercent.Store(DefaultMaxMemoryPercent)
+}
+
+// SetMaxMemoryPercent 设置-Xmx占用本机内存的上限百分比，0表示不限制
+func SetMaxMemoryPercent(percent int) {
+	if percent < 0 || percent > 100 {
+		percent = DefaultMaxMemoryPercent
+	}
+	maxMemoryPercent.Store(int32(percent))
+}
+
+// JVMArgsOptions 构建JVM参数的选项
+type JVMArgsOptions struct {
+	Preset    string   // 预设名称
+	MaxMemory string   // 如 "4G"、"4096M"
+	MinMemory string   // 如 "1G"
+	JavaMajor int      // 使用的Java主版本，0表示未知（跳过版本检查）
+	ExtraArgs []string // 追加在预设之后的参数，其中的-Xmx/-Xms会被忽略
+}
+
+// BuildJVMArgs 根据预设和内存设置构建JVM参数，返回参数和警告
+//
+// 预设需要的Java版本高于实际版本时返回错误；-Xmx超过本机内存的上限百分比时会被降低并给出警告。
+func BuildJVMArgs(opts JVMArgsOptions) ([]string, []string, error) {
+	preset, err := FindFlagPreset(opts.Preset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.JavaMajor > 0 && opts.JavaMajor < preset.MinJava {
+		return nil, nil, fmt.Errorf("JVM参数预设 %s 需要Java %d或更高版本，当前为Java %d", preset.Name, preset.MinJava, opts.JavaMajor)
+	}
+
+	var warnings []string
+	var args []string
+
+	maxBytes, err := ParseMemory(opts.MaxMemory)
+	if err != nil {
+		return nil, nil, err
+	}
+	minBytes, err := ParseMemory(opts.MinMemory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if percent := int64(maxMemoryPercent.Load()); maxBytes > 0 && percent > 0 {
+		if total, err := HostMemory(); err == nil && total > 0 {
+			limit := total * percent / 100
+			if maxBytes > limit {
+				warnings = append(warnings, fmt.Sprintf("最大内存 %s 超过本机内存 %s 的%d%%，已限制为 %s",
+					opts.MaxMemory, FormatMemory(total), percent, FormatMemory(limit)))
+				maxBytes = limit
+			}
+		}
+	}
+	if minBytes > 0 && maxBytes > 0 && minBytes > maxBytes {
+		warnings = append(warnings, fmt.Sprintf("最小内存 %s 大于最大内存，已调整为 %s", opts.MinMemory, FormatMemory(maxBytes)))
+		minBytes = maxBytes
+	}
+
+	if maxBytes > 0 {
+		args = append(args, "-Xmx"+FormatMemory(maxBytes))
+	}
+	if minBytes > 0 {
+		args = append(args, "-Xms"+FormatMemory(minBytes))
+	}
+	args = append(args, preset.FlagsFor(opts.JavaMajor)...)
+
+	for _, arg := range opts.ExtraArgs {
+		if strings.HasPrefix(arg, "-Xmx") || strings.HasPrefix(arg, "-Xms") {
+			warnings = append(warnings, fmt.Sprintf("忽略额外参数中的 %s，请使用内存设置", arg))
+			continue
+		}
+		if minJava := flagMinJava[arg]; opts.JavaMajor > 0 && opts.JavaMajor < minJava {
+			return nil, nil, fmt.Errorf("参数 %s 需要Java %d或更高版本，当前为Java %d", arg, minJava, opts.JavaMajor)
+		}
+		args = append(args, arg)
+	}
+	return args, warnings, nil
+}
+
+// ParseMemory 解析JVM内存大小，如 "2G"、"1024M"、"512k"，空字符串返回0
+func ParseMemory(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "B")
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	switch s[len(s)-1] {
+	case 'K':
+		multiplier = 1024
+	case 'M':
+		multiplier = 1024 * 1024
+	case 'G':
+		multiplier = 1024 * 1024 * 1024
+	case 'T':
+		multiplier = 1024 * 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("无效的内存大小: %s", value)
+	}
+	return n * multiplier, nil
+}
+
+// FormatMemory 格式化为JVM接受的内存大小，能整除时使用G，否则使用M
+func FormatMemory(bytes int64) string {
+	const mb = 1024 * 1024
+	if bytes%(1024*mb) == 0 {
+		return fmt.Sprintf("%dG", bytes/(1024*mb))
+	}
+	if bytes < mb {
+		return "1M"
+	}
+	return fmt.Sprintf("%dM", bytes/mb)
+}
+
+// removeFlag 返回去掉指定参数的副本
+func removeFlag(flags []string, flag string) []string {
+	result := make([]string, 0, len(flags))
+	for _, f := range flags {
+		if f != flag {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -1,9 +1,13 @@
 package java
 
 import (
+	"context"
 	"fmt"
+	"os/exec"
 	"path/filepath"
 	"time"
+
+	"easilypanel/internal/audit"
 )
 
 // Manager Java管理器
@@ -17,23 +21,50 @@ type Manager struct {
 // NewManager 创建新的Java管理器
 func NewManager(configDir string) *Manager {
 	javaListFile := filepath.Join(configDir, "detected_java.json")
+	detector := NewDetector()
+	detector.SetCache(LoadScanCache(filepath.Join(configDir, "java_scan_cache.json")))
 	
 	return &Manager{
-		detector:     NewDetector(),
+		detector:     detector,
 		configDir:    configDir,
 		javaListFile: javaListFile,
 		javaList:     make([]*Java, 0),
 	}
 }
 
+// SetSearchPaths 设置检测时额外的搜索目录和排除目录
+func (m *Manager) SetSearchPaths(searchPaths, excludePaths []string) {
+	m.detector.SetSearchPaths(searchPaths, excludePaths)
+}
+
+// Inspect 读取Java可执行文件的信息，path也可以是PATH中的命令名（如 "java"），无法识别时返回nil
+func (m *Manager) Inspect(path string) *Java {
+	if resolved, err := exec.LookPath(path); err == nil {
+		path = resolved
+	}
+	return m.detector.inspectCached(context.Background(), path)
+}
+
+// Detect 扫描系统中的Java但不保存，每找到一个就调用onFound（可为nil）
+func (m *Manager) Detect(ctx context.Context, fullSearch bool, onFound func(*Java)) ([]*Java, error) {
+	return m.detector.Scan(ctx, fullSearch, onFound)
+}
+
 // DetectAndSave 检测Java并保存到文件
 func (m *Manager) DetectAndSave(fullSearch bool) ([]*Java, error) {
+	return m.DetectAndSaveContext(context.Background(), fullSearch, nil)
+}
+
+// DetectAndSaveContext 检测Java并保存到文件，每找到一个就调用onFound（可为nil）
+//
+// ctx取消时返回已找到的Java和ctx的错误，不覆盖已保存的列表。
+func (m *Manager) DetectAndSaveContext(ctx context.Context, fullSearch bool, onFound func(*Java)) ([]*Java, error) {
 	fmt.Println("正在检测Java环境...")
 	start := time.Now()
 	
-	javaList, err := m.detector.DetectJava(fullSearch)
+	javaList, err := m.detector.Scan(ctx, fullSearch, onFound)
 	if err != nil {
-		return nil, fmt.Errorf("Java检测失败: %w", err)
+		return javaList, fmt.Errorf("Java检测失败: %w", err)
 	}
 	
 	// 排序Java列表
@@ -102,10 +133,10 @@ func (m *Manager) FindJavaByVersion(version string) *Java {
 	return nil
 }
 
-// FindJavaByPath 根据路径查找Java
+// FindJavaByPath 根据路径查找Java，符号链接指向同一文件的路径视为相同
 func (m *Manager) FindJavaByPath(path string) *Java {
 	for _, java := range m.javaList {
-		if java.Path == path {
+		if samePath(java.Path, path) {
 			return java
 		}
 	}
@@ -114,56 +145,61 @@ func (m *Manager) FindJavaByPath(path string) *Java {
 
 // GetBestJava 获取最佳Java版本（最新版本）
 func (m *Manager) GetBestJava() *Java {
-	if len(m.javaList) == 0 {
-		return nil
+	var best *Java
+	var bestVersion JavaVersion
+	for _, java := range m.javaList {
+		v, err := ParseJavaVersion(java.Version)
+		if err != nil {
+			continue
+		}
+		if best == nil || v.Compare(bestVersion) > 0 {
+			best, bestVersion = java, v
+		}
 	}
-	
-	// 假设列表已经按版本排序，返回第一个
-	return m.javaList[0]
+	return best
 }
 
-// GetJavaForMinecraft 获取适合Minecraft的Java版本
+// GetJavaForMinecraft 获取适合Minecraft的Java版本，没有满足要求的Java时返回nil
 func (m *Manager) GetJavaForMinecraft(mcVersion string) *Java {
-	// 根据Minecraft版本推荐Java版本
-	// 这里可以根据实际需求实现更复杂的逻辑
-	
-	// 简单的版本映射
-	var recommendedJavaVersion string
-	switch {
-	case mcVersion >= "1.17":
-		recommendedJavaVersion = "17" // Java 17+
-	case mcVersion >= "1.12":
-		recommendedJavaVersion = "8" // Java 8+
-	default:
-		recommendedJavaVersion = "8" // Java 8
-	}
-	
-	// 查找匹配的Java版本
-	for _, java := range m.javaList {
-		if java.Version >= recommendedJavaVersion {
-			return java
-		}
+	return m.SelectForMinecraft(mcVersion).Java
+}
+
+// SelectForMinecraft 按兼容表为Minecraft版本选择Java，并给出选择理由
+func (m *Manager) SelectForMinecraft(mcVersion string) *Selection {
+	return SelectJavaForMinecraft(m.javaList, mcVersion)
+}
+
+// MajorVersion 从版本字符串中解析主版本号，如 "17.0.2" 为17，"1.8.0_292" 为8，无法解析时返回0
+func MajorVersion(version string) int {
+	v, err := ParseJavaVersion(version)
+	if err != nil {
+		return 0
 	}
-	
-	// 如果没有找到推荐版本，返回最新版本
-	return m.GetBestJava()
+	return v.Major
+}
+
+// RequiredJavaMajor Minecraft版本要求的最低Java主版本
+func RequiredJavaMajor(mcVersion string) int {
+	return CompatRuleFor(mcVersion).MinJava
 }
 
 // AddJava 手动添加Java
 func (m *Manager) AddJava(javaPath string) (*Java, error) {
-	version := m.detector.GetJavaVersion(javaPath)
-	if version == "" {
+	java, err := m.addJava(javaPath)
+	audit.Record(audit.DefaultActor(), "java.AddJava", javaPath, nil, err)
+	return java, err
+}
+
+// addJava 将Java加入列表并保存
+func (m *Manager) addJava(javaPath string) (*Java, error) {
+	java := m.detector.Inspect(javaPath)
+	if java == nil {
 		return nil, fmt.Errorf("无法获取Java版本: %s", javaPath)
 	}
 	
-	java := &Java{
-		Path:    javaPath,
-		Version: version,
-	}
-	
-	// 检查是否已存在
+	// 检查是否已存在（包括通过符号链接指向同一个Java的路径）
 	for _, existing := range m.javaList {
-		if existing.Equal(java) {
+		if samePath(existing.Path, java.Path) {
 			return existing, fmt.Errorf("Java已存在: %s", javaPath)
 		}
 	}
@@ -184,11 +220,18 @@ func (m *Manager) AddJava(javaPath string) (*Java, error) {
 
 // RemoveJava 移除Java
 func (m *Manager) RemoveJava(javaPath string) error {
+	err := m.removeJava(javaPath)
+	audit.Record(audit.DefaultActor(), "java.RemoveJava", javaPath, nil, err)
+	return err
+}
+
+// removeJava 从列表中移除Java并保存
+func (m *Manager) removeJava(javaPath string) error {
 	var newList []*Java
 	found := false
 	
 	for _, java := range m.javaList {
-		if java.Path != javaPath {
+		if !samePath(java.Path, javaPath) {
 			newList = append(newList, java)
 		} else {
 			found = true
@@ -217,15 +260,13 @@ func (m *Manager) PrintJavaList() {
 	}
 	
 	fmt.Printf("找到 %d 个Java版本:\n", len(m.javaList))
-	fmt.Println("序号 | 版本    | 路径")
-	fmt.Println("-----|---------|----")
-	
 	for i, java := range m.javaList {
 		status := "✓"
 		if !m.detector.CheckJavaAvailability(java) {
 			status = "✗"
 		}
-		fmt.Printf("%-4d | %-7s | %s %s\n", i+1, java.Version, java.Path, status)
+		fmt.Printf("%d. Java %s %s\n", i+1, java.Description(), status)
+		fmt.Printf("   路径: %s\n", java.Path)
 	}
 	
 	fmt.Println("\n✓ = 可用, ✗ = 不可用")
@@ -234,11 +275,15 @@ func (m *Manager) PrintJavaList() {
 // GetJavaInfo 获取Java详细信息
 func (m *Manager) GetJavaInfo(java *Java) map[string]interface{} {
 	info := map[string]interface{}{
-		"path":      java.Path,
-		"version":   java.Version,
-		"available": m.detector.CheckJavaAvailability(java),
+		"path":         java.Path,
+		"version":      java.Version,
+		"full_version": java.FullVersion,
+		"vendor":       java.Vendor,
+		"arch":         java.Arch,
+		"is_jdk":       java.IsJDK,
+		"home":         java.Home,
+		"available":    m.detector.CheckJavaAvailability(java),
 	}
 	
-	// 可以添加更多信息，如架构、供应商等
 	return info
 }
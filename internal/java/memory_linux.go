@@ -0,0 +1,14 @@
+//go:build linux
+
+package java
+
+import "syscall"
+
+// HostMemory 本机物理内存总量（字节）
+func HostMemory() (int64, error) {
+	var info syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&info); err != nil {
+		return 0, err
+	}
+	return int64(info.Totalram) * int64(info.Unit), nil
+}
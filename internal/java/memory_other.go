@@ -0,0 +1,23 @@
+//go:build !linux && !windows
+
+package java
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// HostMemory 本机物理内存总量（字节），macOS通过sysctl读取，其他平台不支持
+func HostMemory() (int64, error) {
+	if runtime.GOOS != "darwin" {
+		return 0, errors.New("当前平台不支持读取内存大小")
+	}
+	output, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+}
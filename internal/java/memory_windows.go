@@ -0,0 +1,34 @@
+//go:build windows
+
+package java
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGlobalMemoryStatusEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GlobalMemoryStatusEx")
+
+// memoryStatusEx 对应Windows的MEMORYSTATUSEX结构
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// HostMemory 本机物理内存总量（字节），通过GlobalMemoryStatusEx读取
+func HostMemory() (int64, error) {
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	ret, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return 0, err
+	}
+	return int64(status.TotalPhys), nil
+}
@@ -0,0 +1,144 @@
+package java
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// inspectTimeout 运行单个java读取属性的最长时间，超时视为无法识别
+const inspectTimeout = 10 * time.Second
+
+// Inspect 读取Java可执行文件的元数据，无法识别时返回nil
+func (d *Detector) Inspect(javaPath string) *Java {
+	return d.InspectContext(context.Background(), javaPath)
+}
+
+// InspectContext 读取Java可执行文件的元数据，无法识别、超时或ctx取消时返回nil
+//
+// 路径会先解析符号链接（如 /usr/bin/java → /usr/lib/jvm/...），因此通过不同链接找到的同一个Java路径相同。
+// 优先读取JDK根目录的 release 文件，没有时运行 java -XshowSettings:properties -version。
+func (d *Detector) InspectContext(ctx context.Context, javaPath string) *Java {
+	realPath, err := filepath.EvalSymlinks(javaPath)
+	if err != nil {
+		return nil
+	}
+	if abs, err := filepath.Abs(realPath); err == nil {
+		realPath = abs
+	}
+
+	java := &Java{Path: realPath}
+	home := javaHome(realPath)
+	if props := readReleaseFile(home); props["JAVA_VERSION"] != "" {
+		java.Version = props["JAVA_VERSION"]
+		java.FullVersion = props["JAVA_RUNTIME_VERSION"]
+		java.Vendor = props["IMPLEMENTOR"]
+		java.Arch = props["OS_ARCH"]
+		java.Home = home
+	} else if !d.inspectProperties(ctx, java) {
+		return nil
+	}
+
+	if java.Home == "" {
+		java.Home = home
+	}
+	if java.FullVersion == "" {
+		java.FullVersion = java.Version
+	}
+	java.IsJDK = hasJavac(java.Home)
+	return java
+}
+
+// javaHome 根据java可执行文件推断Java主目录；JDK 8 的 jre/bin/java 返回JDK根目录
+func javaHome(javaPath string) string {
+	home := filepath.Dir(filepath.Dir(javaPath))
+	if filepath.Base(home) == "jre" {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(home), "release")); err == nil {
+			return filepath.Dir(home)
+		}
+	}
+	return home
+}
+
+// readReleaseFile 解析Java主目录下的 release 文件（KEY="value" 格式），不存在时返回空表
+func readReleaseFile(home string) map[string]string {
+	props := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(home, "release"))
+	if err != nil {
+		return props
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		props[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return props
+}
+
+// inspectProperties 运行 java -XshowSettings:properties -version 读取系统属性
+//
+// 不支持该参数的Java仍会输出 -version 的版本信息，此时只能得到版本号。
+// 每次运行最多等待inspectTimeout，避免损坏的JDK或网络挂载上的java阻塞扫描。
+func (d *Detector) inspectProperties(ctx context.Context, java *Java) bool {
+	ctx, cancel := context.WithTimeout(ctx, inspectTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, java.Path, "-XshowSettings:properties", "-version")
+	cmd.WaitDelay = time.Second // java启动的子进程仍持有输出管道时不再等待
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return false
+	}
+
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " = ")
+		if ok {
+			props[key] = value
+		}
+	}
+
+	java.Version = props["java.version"]
+	java.FullVersion = props["java.runtime.version"]
+	java.Vendor = props["java.vendor"]
+	java.Arch = props["os.arch"]
+	if home := props["java.home"]; home != "" {
+		java.Home = javaHome(filepath.Join(home, "bin", "java"))
+	}
+
+	if java.Version == "" {
+		java.Version = parseVersionOutput(string(output))
+	}
+	return java.Version != ""
+}
+
+// hasJavac 判断Java主目录是否包含javac，即是否为JDK
+func hasJavac(home string) bool {
+	name := "javac"
+	if runtime.GOOS == "windows" {
+		name = "javac.exe"
+	}
+	_, err := os.Stat(filepath.Join(home, "bin", name))
+	return err == nil
+}
+
+// samePath 判断两个路径解析符号链接后是否为同一文件
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	realA, errA := filepath.EvalSymlinks(a)
+	realB, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && realA == realB
+}
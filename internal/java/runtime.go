@@ -0,0 +1,260 @@
+package java
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"easilypanel/internal/archive"
+	"easilypanel/internal/audit"
+	"easilypanel/internal/download"
+	"easilypanel/internal/httpclient"
+)
+
+// DefaultRuntimeIndex 默认的JDK发行版索引（Eclipse Temurin）
+const DefaultRuntimeIndex = "https://api.adoptium.net/v3"
+
+// RuntimePackage 索引中一个可下载的Java运行时
+type RuntimePackage struct {
+	ReleaseName string // 发行版名称，如 jdk-21.0.2+13，也是安装目录名
+	Version     string
+	Major       int
+	ImageType   string // jdk 或 jre
+	FileName    string
+	URL         string
+	SHA256      string
+	Size        int64
+}
+
+// adoptiumAsset Adoptium API v3 /assets/latest 返回的条目
+type adoptiumAsset struct {
+	Binary struct {
+		ImageType string `json:"image_type"`
+		Package   struct {
+			Checksum string `json:"checksum"`
+			Link     string `json:"link"`
+			Name     string `json:"name"`
+			Size     int64  `json:"size"`
+		} `json:"package"`
+	} `json:"binary"`
+	ReleaseName string `json:"release_name"`
+	Version     struct {
+		Major  int    `json:"major"`
+		Semver string `json:"semver"`
+	} `json:"version"`
+}
+
+// RuntimeInstaller 从JDK发行版索引下载Java运行时到 data/runtimes 并登记到Java列表
+//
+// 索引使用Adoptium API v3的格式，可以指向Temurin官方API或兼容的镜像。
+type RuntimeInstaller struct {
+	manager     *Manager
+	downloads   *download.DownloadManager
+	runtimesDir string
+	indexURL    string
+	httpClient  *http.Client
+}
+
+// NewRuntimeInstaller 创建运行时安装器，indexURL为空时使用Temurin官方索引
+func NewRuntimeInstaller(manager *Manager, downloads *download.DownloadManager, runtimesDir, indexURL string) *RuntimeInstaller {
+	if indexURL == "" {
+		indexURL = DefaultRuntimeIndex
+	}
+	return &RuntimeInstaller{
+		manager:     manager,
+		downloads:   downloads,
+		runtimesDir: runtimesDir,
+		indexURL:    strings.TrimRight(indexURL, "/"),
+		httpClient:  httpclient.New(0),
+	}
+}
+
+// runtimeOS 索引中当前系统的名称
+func runtimeOS() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "mac"
+	default:
+		return runtime.GOOS
+	}
+}
+
+// runtimeArch 索引中当前架构的名称
+func runtimeArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "x32"
+	case "arm64":
+		return "aarch64"
+	default:
+		return runtime.GOARCH
+	}
+}
+
+// Resolve 查询指定主版本在当前系统和架构下的最新运行时，imageType为 jdk 或 jre
+func (r *RuntimeInstaller) Resolve(major int, imageType string) (*RuntimePackage, error) {
+	if imageType == "" {
+		imageType = "jdk"
+	}
+	url := fmt.Sprintf("%s/assets/latest/%d/hotspot?architecture=%s&image_type=%s&os=%s&vendor=eclipse",
+		r.indexURL, major, runtimeArch(), imageType, runtimeOS())
+
+	resp, err := r.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求JDK索引失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("索引中没有Java %d", major)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求JDK索引失败: HTTP %d %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	var assets []adoptiumAsset
+	if err := json.Unmarshal(body, &assets); err != nil {
+		return nil, fmt.Errorf("解析JDK索引失败: %w", err)
+	}
+
+	for _, asset := range assets {
+		pkg := asset.Binary.Package
+		if pkg.Link == "" || asset.ReleaseName == "" {
+			continue
+		}
+		return &RuntimePackage{
+			ReleaseName: asset.ReleaseName,
+			Version:     asset.Version.Semver,
+			Major:       asset.Version.Major,
+			ImageType:   asset.Binary.ImageType,
+			FileName:    filepath.Base(pkg.Name),
+			URL:         pkg.Link,
+			SHA256:      pkg.Checksum,
+			Size:        pkg.Size,
+		}, nil
+	}
+	return nil, fmt.Errorf("索引中没有适用于 %s/%s 的Java %d", runtimeOS(), runtimeArch(), major)
+}
+
+// Install 下载、校验并解压指定主版本的运行时，登记到Java列表后返回
+func (r *RuntimeInstaller) Install(major int, imageType string) (*Java, error) {
+	java, err := r.install(major, imageType)
+	params := map[string]interface{}{"major": major, "image_type": imageType}
+	if java != nil {
+		params["path"] = java.Path
+	}
+	audit.Record(audit.DefaultActor(), "java.InstallRuntime", fmt.Sprintf("java-%d", major), params, err)
+	return java, err
+}
+
+// install 执行运行时安装
+func (r *RuntimeInstaller) install(major int, imageType string) (*Java, error) {
+	pkg, err := r.Resolve(major, imageType)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.SHA256 == "" {
+		return nil, fmt.Errorf("索引未提供 %s 的SHA256，无法校验", pkg.FileName)
+	}
+
+	installDir := filepath.Join(r.runtimesDir, filepath.Base(pkg.ReleaseName))
+	if pkg.ImageType == "jre" {
+		installDir += "-jre"
+	}
+
+	// 已安装过相同的发行版
+	if javaPath, err := findJavaBinary(installDir); err == nil {
+		fmt.Printf("%s 已安装: %s\n", pkg.ReleaseName, installDir)
+		return r.register(javaPath)
+	}
+
+	if err := os.MkdirAll(r.runtimesDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建运行时目录失败: %w", err)
+	}
+
+	archivePath := filepath.Join(r.runtimesDir, pkg.FileName)
+	fmt.Printf("开始下载: %s (%s)\n", pkg.FileName, download.FormatBytes(pkg.Size))
+	fmt.Printf("下载地址: %s\n", pkg.URL)
+	if err := r.downloads.DownloadTo(pkg.URL, archivePath, "adoptium", download.Checksums{"sha256": pkg.SHA256}, true); err != nil {
+		return nil, fmt.Errorf("下载 %s 失败: %w", pkg.FileName, err)
+	}
+	defer os.Remove(archivePath)
+
+	// 先解压到临时目录，成功后再移动到安装目录
+	staging, err := os.MkdirTemp(r.runtimesDir, ".extract-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	fmt.Println("正在解压...")
+	if strings.HasSuffix(pkg.FileName, ".zip") {
+		_, err = archive.ExtractZip(archivePath, staging)
+	} else {
+		_, err = archive.ExtractTarGz(archivePath, staging)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解压 %s 失败: %w", pkg.FileName, err)
+	}
+
+	// 发行包通常只有一个顶层目录
+	root := staging
+	if entries, err := os.ReadDir(staging); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(staging, entries[0].Name())
+	}
+	if _, err := findJavaBinary(root); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(root, installDir); err != nil {
+		return nil, fmt.Errorf("移动运行时失败: %w", err)
+	}
+
+	javaPath, err := findJavaBinary(installDir)
+	if err != nil {
+		return nil, err
+	}
+	return r.register(javaPath)
+}
+
+// register 把安装的Java加入Java列表
+func (r *RuntimeInstaller) register(javaPath string) (*Java, error) {
+	if _, err := r.manager.LoadJavaList(); err != nil {
+		return nil, err
+	}
+	if existing := r.manager.FindJavaByPath(javaPath); existing != nil {
+		return existing, nil
+	}
+	return r.manager.addJava(javaPath)
+}
+
+// findJavaBinary 在运行时目录中查找java可执行文件（macOS的发行包位于 Contents/Home 下）
+func findJavaBinary(dir string) (string, error) {
+	name := "java"
+	if runtime.GOOS == "windows" {
+		name = "java.exe"
+	}
+	for _, candidate := range []string{
+		filepath.Join(dir, "bin", name),
+		filepath.Join(dir, "Contents", "Home", "bin", name),
+	} {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			abs, err := filepath.Abs(candidate)
+			if err != nil {
+				return candidate, nil
+			}
+			return abs, nil
+		}
+	}
+	return "", fmt.Errorf("运行时中没有找到 %s: %s", name, dir)
+}
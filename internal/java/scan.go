@@ -0,0 +1,315 @@
+package java
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// maxScanWorkers 同时检测Java可执行文件的最大并发数
+const maxScanWorkers = 8
+
+// SetSearchPaths 设置额外的搜索目录和排除目录
+//
+// 搜索目录总会被递归扫描，不受关键词过滤；排除目录及其子目录中的Java（包括符号链接指向其中的）都会被忽略。
+func (d *Detector) SetSearchPaths(searchPaths, excludePaths []string) {
+	d.searchPaths = cleanPaths(searchPaths)
+	d.excludePaths = cleanPaths(excludePaths)
+}
+
+// SetCache 设置检测结果缓存
+func (d *Detector) SetCache(cache *ScanCache) {
+	d.cache = cache
+}
+
+// DetectJava 检测系统中的Java安装
+func (d *Detector) DetectJava(fullSearch bool) ([]*Java, error) {
+	return d.Scan(context.Background(), fullSearch, nil)
+}
+
+// Scan 并发扫描系统中的Java安装，每找到一个就调用onFound（可为nil）
+//
+// 候选路径由一个协程遍历产生，再由有限数量的工作协程检测；ctx取消后停止遍历和检测，
+// 正在运行的java会被终止，返回已找到的Java和ctx的错误。fullSearch为true时额外遍历整个磁盘（Windows）或常见根目录。
+func (d *Detector) Scan(ctx context.Context, fullSearch bool, onFound func(*Java)) ([]*Java, error) {
+	candidates := make(chan string, 64)
+	results := make(chan *Java)
+
+	workers := runtime.NumCPU()
+	if workers > maxScanWorkers {
+		workers = maxScanWorkers
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var path string
+				var ok bool
+				select {
+				case path, ok = <-candidates:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+				if java := d.inspectCached(ctx, path); java != nil {
+					select {
+					case results <- java:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		d.collectCandidates(ctx, fullSearch, candidates)
+		close(candidates)
+		wg.Wait()
+		close(results)
+	}()
+
+	javaList := make([]*Java, 0)
+	seen := make(map[string]bool)
+	for java := range results {
+		if seen[java.Path] || d.isExcluded(java.Path) {
+			continue
+		}
+		seen[java.Path] = true
+		javaList = append(javaList, java)
+		if onFound != nil {
+			onFound(java)
+		}
+	}
+
+	if d.cache != nil {
+		if err := d.cache.Save(); err != nil {
+			fmt.Printf("保存Java检测缓存失败: %v\n", err)
+		}
+	}
+
+	d.foundJava = javaList
+	return javaList, ctx.Err()
+}
+
+// inspectCached 检测Java，可执行文件的大小和修改时间未变时直接使用缓存结果
+//
+// 只缓存成功的结果，超时或暂时失败的java在下次扫描时重新检测。
+func (d *Detector) inspectCached(ctx context.Context, path string) *Java {
+	if d.cache == nil {
+		return d.InspectContext(ctx, path)
+	}
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(realPath)
+	if err != nil {
+		return nil
+	}
+	if java, ok := d.cache.lookup(realPath, info); ok {
+		return java
+	}
+
+	java := d.InspectContext(ctx, path)
+	if java != nil {
+		d.cache.store(realPath, info, java)
+	}
+	return java
+}
+
+// collectCandidates 遍历可能存在Java的位置，把候选路径发送到candidates
+func (d *Detector) collectCandidates(ctx context.Context, fullSearch bool, candidates chan<- string) {
+	sent := make(map[string]bool)
+	emit := func(path string) {
+		if _, err := os.Stat(path); err != nil {
+			return
+		}
+		realPath, err := filepath.EvalSymlinks(path)
+		if err != nil || sent[realPath] || d.isExcluded(path) || d.isExcluded(realPath) {
+			return
+		}
+		sent[realPath] = true
+		select {
+		case candidates <- path:
+		case <-ctx.Done():
+		}
+	}
+
+	// 环境变量PATH
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir = strings.TrimSpace(dir); dir != "" && ctx.Err() == nil {
+			emit(filepath.Join(dir, javaExecutable()))
+		}
+	}
+
+	// 各系统的默认安装位置
+	for _, path := range defaultJavaPaths() {
+		if ctx.Err() != nil {
+			return
+		}
+		emit(path)
+	}
+
+	// 配置的搜索目录
+	for _, dir := range d.searchPaths {
+		d.walk(ctx, dir, false, emit)
+	}
+
+	if fullSearch {
+		for _, root := range fullSearchRoots() {
+			d.walk(ctx, root, true, emit)
+		}
+	}
+}
+
+// walk 递归遍历目录中的Java可执行文件，filter为true时只接受路径匹配关键词的
+func (d *Detector) walk(ctx context.Context, root string, filter bool, emit func(string)) {
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil // 忽略无权限等错误，继续搜索
+		}
+		if entry.IsDir() {
+			if path != root && d.isExcluded(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.isJavaExecutable(path) && (!filter || d.findStr(filepath.Dir(path))) {
+			emit(path)
+		}
+		return nil
+	})
+}
+
+// isExcluded 判断路径是否位于排除目录中
+func (d *Detector) isExcluded(path string) bool {
+	if len(d.excludePaths) == 0 {
+		return false
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	for _, exclude := range d.excludePaths {
+		if path == exclude || strings.HasPrefix(path, exclude+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanPaths 把路径转换为绝对路径，忽略空项
+func cleanPaths(paths []string) []string {
+	var cleaned []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		cleaned = append(cleaned, filepath.Clean(path))
+	}
+	return cleaned
+}
+
+// javaExecutable 当前系统的java可执行文件名
+func javaExecutable() string {
+	if runtime.GOOS == "windows" {
+		return "java.exe"
+	}
+	return "java"
+}
+
+// defaultJavaPaths 各系统默认安装位置中的java可执行文件
+func defaultJavaPaths() []string {
+	var paths []string
+
+	switch runtime.GOOS {
+	case "windows":
+		// Windows的默认位置在完整搜索中遍历磁盘时检查
+
+	case "darwin":
+		paths = append(paths,
+			"/Applications/Xcode.app/Contents/Applications/Application Loader.app/Contents/MacOS/itms/java",
+			"/Library/Internet Plug-Ins/JavaAppletPlugin.plugin/Contents/Home/bin/java",
+			"/System/Library/Frameworks/JavaVM.framework/Versions/Current/Commands/java",
+		)
+		basePath := "/Library/Java/JavaVirtualMachines/"
+		if entries, err := os.ReadDir(basePath); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					paths = append(paths, filepath.Join(basePath, entry.Name(), "Contents/Home/bin/java"))
+				}
+			}
+		}
+
+	default:
+		linuxPaths := []string{
+			"/usr",
+			"/usr/java",
+			"/usr/lib/jvm",
+			"/usr/lib64/jvm",
+			"/opt/jdk",
+			"/opt/jdks",
+		}
+		for _, basePath := range linuxPaths {
+			paths = append(paths,
+				filepath.Join(basePath, "bin/java"),
+				filepath.Join(basePath, "jre/bin/java"),
+			)
+			// 子目录中的 bin/java 和 jre/bin/java
+			if entries, err := os.ReadDir(basePath); err == nil {
+				for _, entry := range entries {
+					if entry.IsDir() {
+						subPath := filepath.Join(basePath, entry.Name())
+						paths = append(paths,
+							filepath.Join(subPath, "bin/java"),
+							filepath.Join(subPath, "jre/bin/java"),
+						)
+					}
+				}
+			}
+		}
+	}
+
+	return paths
+}
+
+// fullSearchRoots 完整搜索时遍历的根目录
+func fullSearchRoots() []string {
+	if runtime.GOOS == "windows" {
+		var roots []string
+		for drive := 'C'; drive <= 'Z'; drive++ {
+			drivePath := fmt.Sprintf("%c:\\", drive)
+			if _, err := os.Stat(drivePath); err == nil {
+				roots = append(roots, drivePath)
+			}
+		}
+		return roots
+	}
+
+	roots := []string{"/usr", "/opt"}
+	if runtime.GOOS == "darwin" {
+		roots = append(roots, "/Library/Java", "/Applications")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		roots = append(roots, home)
+	}
+	return roots
+}
@@ -0,0 +1,91 @@
+package java
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ScanCache 按可执行文件指纹（大小和修改时间）缓存的Java检测结果
+//
+// 扫描时指纹未变的java不再重新运行；只缓存识别成功的结果，失败的文件下次扫描时重新检测。
+type ScanCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*scanCacheEntry
+	dirty   bool
+}
+
+// scanCacheEntry 单个可执行文件的缓存
+type scanCacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Java    *Java     `json:"java"`
+}
+
+// LoadScanCache 加载缓存文件，文件不存在或损坏时返回空缓存
+func LoadScanCache(path string) *ScanCache {
+	cache := &ScanCache{path: path, entries: make(map[string]*scanCacheEntry)}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache.entries)
+		if cache.entries == nil {
+			cache.entries = make(map[string]*scanCacheEntry)
+		}
+	}
+	return cache
+}
+
+// lookup 查找与指纹一致的缓存结果，返回副本
+func (c *ScanCache) lookup(realPath string, info os.FileInfo) (*Java, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[realPath]
+	if !ok || entry.Java == nil || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return nil, false // 旧版本缓存的失败结果（Java为nil）同样重新检测
+	}
+	java := *entry.Java
+	return &java, true
+}
+
+// store 记录成功的检测结果
+func (c *ScanCache) store(realPath string, info os.FileInfo, java *Java) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	copied := *java
+	c.entries[realPath] = &scanCacheEntry{Size: info.Size(), ModTime: info.ModTime(), Java: &copied}
+	c.dirty = true
+}
+
+// Save 保存缓存，同时清理已不存在的文件；没有变化时不写入
+func (c *ScanCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化Java检测缓存失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,83 @@
+package java
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Selector 按主版本绑定Java，启动时再解析为具体路径，升级或移动JDK后实例无需修改
+//
+// 文本形式为 "21" 或 "21:temurin"，冒号后为偏好的供应商。
+type Selector struct {
+	Major  int    `json:"major"`
+	Vendor string `json:"vendor,omitempty"` // 偏好的供应商，不区分大小写地匹配供应商名称或路径；没有匹配时仍可使用其他供应商
+}
+
+// ParseSelector 解析Java选择器，如 "21"、"17:zulu"
+func ParseSelector(s string) (*Selector, error) {
+	majorText, vendor, _ := strings.Cut(strings.TrimSpace(s), ":")
+	major, err := strconv.Atoi(majorText)
+	if err != nil || major <= 0 {
+		return nil, fmt.Errorf("无效的Java选择器: %s (格式: 主版本[:供应商]，如 21 或 21:temurin)", s)
+	}
+	return &Selector{Major: major, Vendor: strings.TrimSpace(vendor)}, nil
+}
+
+// String 返回选择器的文本形式
+func (s *Selector) String() string {
+	if s.Vendor != "" {
+		return fmt.Sprintf("%d:%s", s.Major, s.Vendor)
+	}
+	return strconv.Itoa(s.Major)
+}
+
+// matchesVendor 判断Java是否为偏好的供应商
+func (s *Selector) matchesVendor(java *Java) bool {
+	vendor := strings.ToLower(s.Vendor)
+	return strings.Contains(strings.ToLower(java.Vendor), vendor) ||
+		strings.Contains(strings.ToLower(java.Path), vendor)
+}
+
+// Match 从列表中选择主版本一致的Java，优先偏好的供应商，其次版本最新的
+func (s *Selector) Match(javaList []*Java) *Java {
+	var best *Java
+	var bestVersion JavaVersion
+	bestVendor := false
+	for _, java := range javaList {
+		v, err := ParseJavaVersion(java.Version)
+		if err != nil || v.Major != s.Major {
+			continue
+		}
+		if _, err := os.Stat(java.Path); err != nil {
+			continue
+		}
+
+		vendor := s.Vendor != "" && s.matchesVendor(java)
+		if best == nil || (vendor && !bestVendor) || (vendor == bestVendor && v.Compare(bestVersion) > 0) {
+			best, bestVersion, bestVendor = java, v, vendor
+		}
+	}
+	return best
+}
+
+// Resolve 把选择器解析为具体的Java
+//
+// 先在已保存的Java列表中查找，没有时快速扫描系统；仍没有时返回带修复建议的错误。
+func (m *Manager) Resolve(selector *Selector) (*Java, error) {
+	if _, err := m.LoadJavaList(); err == nil {
+		if java := selector.Match(m.javaList); java != nil {
+			return java, nil
+		}
+	}
+
+	detected, _ := m.detector.Scan(context.Background(), false, nil)
+	if java := selector.Match(detected); java != nil {
+		return java, nil
+	}
+
+	return nil, fmt.Errorf("没有找到满足选择器 %s 的Java %d，可运行 'java detect' 重新检测、'java install %d' 安装，"+
+		"或使用 'instance java NAME <路径|选择器>' 修改实例的Java", selector, selector.Major, selector.Major)
+}
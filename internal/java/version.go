@@ -0,0 +1,252 @@
+package java
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JavaVersion Java版本号
+//
+// 同时支持旧格式 1.8.0_392（主版本为8，392为更新号）和新格式 21.0.7+6，
+// 比较时旧格式的更新号与新格式的补丁号等价。
+type JavaVersion struct {
+	Major  int
+	Minor  int
+	Patch  int
+	Update int // 旧格式中 _ 之后的更新号
+	Raw    string
+}
+
+// javaVersionPattern 匹配版本号中的数字部分，忽略 +build、-ea 等后缀
+var javaVersionPattern = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:[._](\d+))?`)
+
+// ParseJavaVersion 解析Java版本号，如 "1.8.0_392"、"1.8.0.392"、"17"、"21.0.7+6"
+func ParseJavaVersion(s string) (JavaVersion, error) {
+	raw := strings.TrimSpace(s)
+	matches := javaVersionPattern.FindStringSubmatch(strings.Trim(raw, `"`))
+	if matches == nil {
+		return JavaVersion{}, fmt.Errorf("无效的Java版本: %s", s)
+	}
+
+	var parts [4]int
+	for i := range parts {
+		if matches[i+1] != "" {
+			parts[i], _ = strconv.Atoi(matches[i+1])
+		}
+	}
+
+	v := JavaVersion{Raw: raw}
+	if parts[0] == 1 && matches[2] != "" {
+		// 1.x 旧格式：1.8.0_392
+		v.Major, v.Minor, v.Update = parts[1], parts[2], parts[3]
+	} else {
+		v.Major, v.Minor, v.Patch, v.Update = parts[0], parts[1], parts[2], parts[3]
+	}
+	if v.Major == 0 {
+		return JavaVersion{}, fmt.Errorf("无效的Java版本: %s", s)
+	}
+	return v, nil
+}
+
+// Compare 比较两个Java版本，返回-1、0或1
+func (v JavaVersion) Compare(other JavaVersion) int {
+	a := [...]int{v.Major, v.Minor, v.Patch, v.Update}
+	b := [...]int{other.Major, other.Minor, other.Patch, other.Update}
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// String 返回原始版本字符串
+func (v JavaVersion) String() string {
+	if v.Raw != "" {
+		return v.Raw
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// MCVersion Minecraft正式版版本号，如 1.20.4
+//
+// 快照（24w14a）和无法识别的版本Snapshot为true，按最新版本处理；
+// 预发布版（1.20.5-pre1、1.20.5-rc1）按对应的正式版处理。
+type MCVersion struct {
+	Major    int
+	Minor    int
+	Patch    int
+	Snapshot bool
+	Raw      string
+}
+
+// mcVersionPattern 匹配正式版版本号
+var mcVersionPattern = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseMCVersion 解析Minecraft版本号，无法识别时返回Snapshot为true的版本
+func ParseMCVersion(s string) MCVersion {
+	raw := strings.TrimSpace(s)
+	matches := mcVersionPattern.FindStringSubmatch(raw)
+	if matches == nil {
+		return MCVersion{Snapshot: true, Raw: raw}
+	}
+
+	v := MCVersion{Raw: raw}
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	if matches[3] != "" {
+		v.Patch, _ = strconv.Atoi(matches[3])
+	}
+	return v
+}
+
+// Compare 比较两个Minecraft版本，返回-1、0或1；快照视为比所有正式版都新
+func (v MCVersion) Compare(other MCVersion) int {
+	switch {
+	case v.Snapshot && other.Snapshot:
+		return 0
+	case v.Snapshot:
+		return 1
+	case other.Snapshot:
+		return -1
+	}
+
+	a := [...]int{v.Major, v.Minor, v.Patch}
+	b := [...]int{other.Major, other.Minor, other.Patch}
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// String 返回原始版本字符串
+func (v MCVersion) String() string {
+	if v.Raw != "" {
+		return v.Raw
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// CompatRule Minecraft版本范围对Java主版本的要求
+type CompatRule struct {
+	From    string // 最低Minecraft版本（含），空表示不限
+	To      string // 最高Minecraft版本（不含），空表示不限
+	MinJava int    // 必须满足的最低Java主版本
+	MaxJava int    // 推荐的最高Java主版本，0表示不限；更新的Java可能导致旧版服务端或模组加载器无法启动
+}
+
+// Matches 判断Minecraft版本是否在规则范围内
+func (r CompatRule) Matches(mc MCVersion) bool {
+	if r.From != "" && mc.Compare(ParseMCVersion(r.From)) < 0 {
+		return false
+	}
+	if r.To != "" && mc.Compare(ParseMCVersion(r.To)) >= 0 {
+		return false
+	}
+	return true
+}
+
+// Range 返回规则的版本范围描述
+func (r CompatRule) Range() string {
+	switch {
+	case r.From == "" && r.To == "":
+		return "所有版本"
+	case r.From == "":
+		return "低于" + r.To
+	case r.To == "":
+		return r.From + "及以上"
+	default:
+		return r.From + " ~ " + r.To + "之前"
+	}
+}
+
+// CompatibilityTable Minecraft与Java的兼容表，按版本从新到旧排列
+var CompatibilityTable = []CompatRule{
+	{From: "1.20.5", MinJava: 21},
+	{From: "1.18", To: "1.20.5", MinJava: 17},
+	{From: "1.17", To: "1.18", MinJava: 16},
+	{From: "1.12", To: "1.17", MinJava: 8, MaxJava: 17},
+	{To: "1.12", MinJava: 8, MaxJava: 8},
+}
+
+// CompatRuleFor 查找Minecraft版本对应的兼容规则
+func CompatRuleFor(mcVersion string) CompatRule {
+	mc := ParseMCVersion(mcVersion)
+	for _, rule := range CompatibilityTable {
+		if rule.Matches(mc) {
+			return rule
+		}
+	}
+	return CompatibilityTable[0]
+}
+
+// Selection Java选择结果
+type Selection struct {
+	Java   *Java      // 选中的Java，没有满足要求的Java时为nil
+	Rule   CompatRule // 适用的兼容规则
+	Reason string     // 选择理由
+}
+
+// SelectJavaForMinecraft 按兼容表从列表中为Minecraft版本选择Java
+//
+// 优先选择推荐范围内主版本最低的Java，同一主版本中选择最新的更新；
+// 范围内没有时退而选择高于推荐上限的最低版本，并在理由中说明。
+func SelectJavaForMinecraft(javaList []*Java, mcVersion string) *Selection {
+	rule := CompatRuleFor(mcVersion)
+	sel := &Selection{Rule: rule}
+
+	var best *Java
+	var bestVersion JavaVersion
+	for _, java := range javaList {
+		v, err := ParseJavaVersion(java.Version)
+		if err != nil || v.Major < rule.MinJava {
+			continue
+		}
+		if best == nil || betterMatch(v, bestVersion, rule) {
+			best, bestVersion = java, v
+		}
+	}
+
+	requirement := fmt.Sprintf("Minecraft %s (%s) 需要Java %d", mcVersion, rule.Range(), rule.MinJava)
+	switch {
+	case rule.MaxJava == rule.MinJava:
+	case rule.MaxJava > 0:
+		requirement += fmt.Sprintf(" ~ %d", rule.MaxJava)
+	default:
+		requirement += "或更高版本"
+	}
+
+	switch {
+	case best == nil:
+		sel.Reason = requirement + "，没有找到满足要求的Java"
+	case rule.MaxJava > 0 && bestVersion.Major > rule.MaxJava:
+		sel.Java = best
+		sel.Reason = fmt.Sprintf("%s，没有推荐范围内的Java，使用Java %s (高于推荐版本，可能无法启动)", requirement, best.Version)
+	default:
+		sel.Java = best
+		sel.Reason = fmt.Sprintf("%s，选择满足要求的最低主版本Java %s", requirement, best.Version)
+	}
+	return sel
+}
+
+// betterMatch 判断候选版本是否比当前选择更合适
+func betterMatch(candidate, current JavaVersion, rule CompatRule) bool {
+	inRange := func(v JavaVersion) bool { return rule.MaxJava == 0 || v.Major <= rule.MaxJava }
+	if inRange(candidate) != inRange(current) {
+		return inRange(candidate)
+	}
+	if candidate.Major != current.Major {
+		return candidate.Major < current.Major
+	}
+	return candidate.Compare(current) > 0
+}
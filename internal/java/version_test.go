@@ -0,0 +1,176 @@
+package java
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseJavaVersion(t *testing.T) {
+	tests := []struct {
+		in                          string
+		major, minor, patch, update int
+		wantErr                     bool
+	}{
+		{in: "1.8.0_392", major: 8, update: 392},
+		{in: "1.8.0_392-b08", major: 8, update: 392},
+		{in: "1.8.0.392", major: 8, update: 392},
+		{in: "1.7.0_80", major: 7, update: 80},
+		{in: "1.9.0", major: 9},
+		{in: "9", major: 9},
+		{in: "17", major: 17},
+		{in: "21.0.7", major: 21, patch: 7},
+		{in: "21.0.7+6", major: 21, patch: 7},
+		{in: "17.0.2+8-LTS", major: 17, patch: 2},
+		{in: "22-ea", major: 22},
+		{in: "23-ea+5", major: 23},
+		{in: `"11.0.21"`, major: 11, patch: 21},
+		{in: "", wantErr: true},
+		{in: "openjdk", wantErr: true},
+		{in: "0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := ParseJavaVersion(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if v.Major != tt.major || v.Minor != tt.minor || v.Patch != tt.patch || v.Update != tt.update {
+				t.Errorf("got %d.%d.%d_%d, want %d.%d.%d_%d",
+					v.Major, v.Minor, v.Patch, v.Update, tt.major, tt.minor, tt.patch, tt.update)
+			}
+		})
+	}
+}
+
+func TestJavaVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.8.0_392", "21.0.7", -1},
+		{"21.0.7", "1.8.0_392", 1},
+		{"1.9.0", "1.17.0", -1}, // 按数字比较，不是按字符串
+		{"1.8.0_392", "1.8.0_41", 1},
+		{"1.8.0_392", "1.8.0.392", 0},
+		{"11.0.2", "1.8.0_392", 1},
+		{"17.0.9", "17.0.10", -1},
+		{"21.0.7+6", "21.0.7", 0},
+		{"22-ea", "22", 0},
+		{"17", "17.0.1", -1},
+	}
+
+	for _, tt := range tests {
+		a, err := ParseJavaVersion(tt.a)
+		if err != nil {
+			t.Fatalf("ParseJavaVersion(%q): %v", tt.a, err)
+		}
+		b, err := ParseJavaVersion(tt.b)
+		if err != nil {
+			t.Fatalf("ParseJavaVersion(%q): %v", tt.b, err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMCVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.9", "1.17", -1},
+		{"1.17", "1.16.5", 1},
+		{"1.20.5", "1.20.4", 1},
+		{"1.20", "1.20.0", 0},
+		{"1.20.5-pre1", "1.20.5", 0},
+		{"24w14a", "1.21", 1},
+		{"24w14a", "23w51b", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMCVersion(tt.a).Compare(ParseMCVersion(tt.b)); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCompatRuleFor(t *testing.T) {
+	tests := []struct {
+		mc               string
+		minJava, maxJava int
+	}{
+		{"1.7.10", 8, 8},
+		{"1.11.2", 8, 8},
+		{"1.12", 8, 17},
+		{"1.16.5", 8, 17},
+		{"1.17", 16, 0},
+		{"1.17.1", 16, 0},
+		{"1.18", 17, 0},
+		{"1.20.4", 17, 0},
+		{"1.20.5", 21, 0},
+		{"1.20.5-pre1", 21, 0},
+		{"1.21.4", 21, 0},
+		{"24w14a", 21, 0},
+	}
+
+	for _, tt := range tests {
+		rule := CompatRuleFor(tt.mc)
+		if rule.MinJava != tt.minJava || rule.MaxJava != tt.maxJava {
+			t.Errorf("CompatRuleFor(%s) = Java %d~%d, want %d~%d", tt.mc, rule.MinJava, rule.MaxJava, tt.minJava, tt.maxJava)
+		}
+	}
+}
+
+func TestSelectJavaForMinecraft(t *testing.T) {
+	javas := func(versions ...string) []*Java {
+		var list []*Java
+		for _, v := range versions {
+			list = append(list, &Java{Path: "/jvm/" + v + "/bin/java", Version: v})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name     string
+		list     []*Java
+		mc       string
+		want     string // 期望选中的版本，空表示没有可用的Java
+		aboveMax bool   // 期望理由中说明高于推荐版本
+	}{
+		{name: "最低满足的主版本", list: javas("21.0.7", "1.8.0_392", "17.0.9"), mc: "1.16.5", want: "1.8.0_392"},
+		{name: "同一主版本选最新更新", list: javas("17.0.1", "17.0.9", "21.0.7"), mc: "1.20.4", want: "17.0.9"},
+		{name: "1.17需要16", list: javas("1.8.0_392", "16.0.2", "17.0.9"), mc: "1.17", want: "16.0.2"},
+		{name: "1.20.5需要21", list: javas("17.0.9", "21.0.7", "22-ea"), mc: "1.20.5", want: "21.0.7"},
+		{name: "低于最低版本", list: javas("17.0.9"), mc: "1.20.5"},
+		{name: "忽略无法解析的版本", list: javas("unknown", "21.0.7"), mc: "1.21", want: "21.0.7"},
+		{name: "推荐范围内优先", list: javas("21.0.7", "1.8.0_41", "17.0.9"), mc: "1.12.2", want: "1.8.0_41"},
+		{name: "高于MaxJava的版本只作退路", list: javas("21.0.7", "17.0.9"), mc: "1.8.9", want: "17.0.9", aboveMax: true},
+		{name: "MaxJava范围外且有范围内版本", list: javas("17.0.9", "1.8.0_392"), mc: "1.8.9", want: "1.8.0_392"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel := SelectJavaForMinecraft(tt.list, tt.mc)
+			if tt.want == "" {
+				if sel.Java != nil {
+					t.Fatalf("选中了 %s, 期望没有可用的Java", sel.Java.Version)
+				}
+				if !strings.Contains(sel.Reason, "没有找到") {
+					t.Errorf("Reason = %q", sel.Reason)
+				}
+				return
+			}
+			if sel.Java == nil || sel.Java.Version != tt.want {
+				t.Fatalf("选中 %v, 期望 %s (%s)", sel.Java, tt.want, sel.Reason)
+			}
+			if got := strings.Contains(sel.Reason, "高于推荐版本"); got != tt.aboveMax {
+				t.Errorf("Reason = %q", sel.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchMavenRange 判断版本是否满足Maven版本范围（Forge/NeoForge的versionRange）
+//
+// 支持 "[1.20.1,1.21)"、"[1.20.1]"、"(,1.20]"、"[1.20,)" 以及用逗号连接的多个区间。
+// 按Maven语义，不带括号的版本号（如 "1.20.1"）只是推荐版本，任何版本都满足；
+// 空字符串和 "*" 同样匹配任意版本。
+func MatchMavenRange(spec, ver string) (bool, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "*" || !strings.ContainsAny(spec, "[(") {
+		return true, nil
+	}
+
+	v, err := Parse(ver)
+	if err != nil {
+		return false, err
+	}
+
+	ranges, err := splitMavenRanges(spec)
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range ranges {
+		ok, err := matchMavenInterval(r, v)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// splitMavenRanges 将 "[1,2),[3,)" 拆分为单个区间
+func splitMavenRanges(spec string) ([]string, error) {
+	var ranges []string
+	depth, start := 0, -1
+
+	for i, c := range spec {
+		switch c {
+		case '[', '(':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case ']', ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("无效的版本范围: %s", spec)
+			}
+			if depth == 0 {
+				ranges = append(ranges, spec[start:i+1])
+			}
+		}
+	}
+
+	if depth != 0 || len(ranges) == 0 {
+		return nil, fmt.Errorf("无效的版本范围: %s", spec)
+	}
+	return ranges, nil
+}
+
+// matchMavenInterval 判断版本是否落在单个区间内
+func matchMavenInterval(interval string, v Version) (bool, error) {
+	lowerInclusive := interval[0] == '['
+	upperInclusive := interval[len(interval)-1] == ']'
+	body := interval[1 : len(interval)-1]
+
+	bounds := strings.Split(body, ",")
+	switch len(bounds) {
+	case 1:
+		// [1.20.1] 表示精确版本
+		exact, err := Parse(bounds[0])
+		if err != nil {
+			return false, err
+		}
+		return v.Compare(exact) == 0, nil
+	case 2:
+	default:
+		return false, fmt.Errorf("无效的版本范围: %s", interval)
+	}
+
+	if lower := strings.TrimSpace(bounds[0]); lower != "" {
+		lv, err := Parse(lower)
+		if err != nil {
+			return false, err
+		}
+		cmp := v.Compare(lv)
+		if cmp < 0 || (cmp == 0 && !lowerInclusive) {
+			return false, nil
+		}
+	}
+
+	if upper := strings.TrimSpace(bounds[1]); upper != "" {
+		uv, err := Parse(upper)
+		if err != nil {
+			return false, err
+		}
+		cmp := v.Compare(uv)
+		if cmp > 0 || (cmp == 0 && !upperInclusive) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// MatchFabricPredicate 判断版本是否满足Fabric的版本谓词（fabric.mod.json中depends的值）
+//
+// 支持 "*"、">=1.20"、"<1.21"、"=1.20.1"、"~1.20.1"、"^1.20"、"1.20.x"，
+// 空格分隔的多个条件需同时满足。
+func MatchFabricPredicate(predicate, ver string) (bool, error) {
+	predicate = strings.TrimSpace(predicate)
+	if predicate == "" || predicate == "*" {
+		return true, nil
+	}
+
+	v, err := Parse(ver)
+	if err != nil {
+		return false, err
+	}
+
+	for _, term := range strings.Fields(predicate) {
+		ok, err := matchFabricTerm(term, v)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// MatchFabricPredicates 判断版本是否满足任一谓词（depends的值为数组时表示"或"）
+func MatchFabricPredicates(predicates []string, ver string) (bool, error) {
+	if len(predicates) == 0 {
+		return true, nil
+	}
+
+	var lastErr error
+	for _, predicate := range predicates {
+		ok, err := MatchFabricPredicate(predicate, ver)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, lastErr
+}
+
+// matchFabricTerm 判断单个条件
+func matchFabricTerm(term string, v Version) (bool, error) {
+	if term == "*" {
+		return true, nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if !strings.HasPrefix(term, op) {
+			continue
+		}
+
+		target, err := Parse(term[len(op):])
+		if err != nil {
+			return false, err
+		}
+		cmp := v.Compare(target)
+
+		switch op {
+		case ">=":
+			return cmp >= 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		case "<":
+			return cmp < 0, nil
+		case "=":
+			return cmp == 0, nil
+		case "~":
+			// ~1.20.1 表示 >=1.20.1 且 <1.21
+			return cmp >= 0 && samePrefix(v, target, 2), nil
+		case "^":
+			// ^1.20 表示 >=1.20 且 <2.0
+			return cmp >= 0 && samePrefix(v, target, 1), nil
+		}
+	}
+
+	// 通配符形式：1.20.x / 1.20.*
+	if strings.HasSuffix(term, ".x") || strings.HasSuffix(term, ".X") || strings.HasSuffix(term, ".*") {
+		prefix, err := Parse(term[:len(term)-2])
+		if err != nil {
+			return false, err
+		}
+		return samePrefix(v, prefix, len(prefix.Numbers)), nil
+	}
+
+	target, err := Parse(term)
+	if err != nil {
+		return false, err
+	}
+	return v.Compare(target) == 0, nil
+}
+
+// samePrefix 判断两个版本前n个数字段是否相同
+func samePrefix(a, b Version, n int) bool {
+	for i := 0; i < n; i++ {
+		if a.part(i) != b.part(i) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package version
+
+import "testing"
+
+func TestMatchMavenRange(t *testing.T) {
+	tests := []struct {
+		spec, ver string
+		want      bool
+		wantErr   bool
+	}{
+		{spec: "[1.20,1.21)", ver: "1.20", want: true},
+		{spec: "[1.20,1.21)", ver: "1.20.0", want: true},
+		{spec: "[1.20,1.21)", ver: "1.20.6", want: true},
+		{spec: "[1.20,1.21)", ver: "1.21", want: false},
+		{spec: "[1.20,1.21)", ver: "1.19.4", want: false},
+		{spec: "[1.20,1.21]", ver: "1.21", want: true},
+		{spec: "(1.20,1.21)", ver: "1.20", want: false},
+		{spec: "[1.20.5,)", ver: "1.20.5-pre1", want: false}, // 预发布版低于正式版
+		{spec: "[1.20.5-pre1,)", ver: "1.20.5", want: true},
+		{spec: "[1.20.1]", ver: "1.20.1", want: true},
+		{spec: "[1.20.1]", ver: "1.20.2", want: false},
+		{spec: "(,1.20]", ver: "1.20", want: true},
+		{spec: "(,1.20]", ver: "1.20.1", want: false},
+		{spec: "[47.1,)", ver: "47.2.0", want: true},
+		{spec: "[1.18,1.19),[1.20,)", ver: "1.18.2", want: true},
+		{spec: "[1.18,1.19),[1.20,)", ver: "1.19.4", want: false},
+		{spec: "[1.18,1.19),[1.20,)", ver: "1.21", want: true},
+		{spec: "1.20.1", ver: "1.16.5", want: true}, // 不带括号只是推荐版本
+		{spec: "", ver: "1.20.1", want: true},
+		{spec: "*", ver: "1.20.1", want: true},
+		{spec: "[1.20", ver: "1.20", wantErr: true},
+		{spec: "[1.20,1.21,1.22]", ver: "1.20", wantErr: true},
+		{spec: "[1.20,1.21)", ver: "24w14a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := MatchMavenRange(tt.spec, tt.ver)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MatchMavenRange(%q, %q) err = %v, wantErr %v", tt.spec, tt.ver, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("MatchMavenRange(%q, %q) = %v, want %v", tt.spec, tt.ver, got, tt.want)
+		}
+	}
+}
+
+func TestMatchFabricPredicate(t *testing.T) {
+	tests := []struct {
+		predicate, ver string
+		want           bool
+		wantErr        bool
+	}{
+		{predicate: ">=1.20 <1.21", ver: "1.20", want: true},
+		{predicate: ">=1.20 <1.21", ver: "1.20.4", want: true},
+		{predicate: ">=1.20 <1.21", ver: "1.21", want: false},
+		{predicate: ">=1.20 <1.21", ver: "1.19.4", want: false},
+		{predicate: ">1.20", ver: "1.20", want: false},
+		{predicate: "<=1.20.1", ver: "1.20.1", want: true},
+		{predicate: "=1.20.1", ver: "1.20.1", want: true},
+		{predicate: "1.20.1", ver: "1.20.2", want: false},
+		{predicate: "~1.20.1", ver: "1.20.4", want: true},
+		{predicate: "~1.20.1", ver: "1.20", want: false},
+		{predicate: "~1.20.1", ver: "1.21", want: false},
+		{predicate: "^1.20", ver: "1.21.4", want: true},
+		{predicate: "^1.20", ver: "2.0", want: false},
+		{predicate: "^0.15.0", ver: "0.14.22", want: false},
+		{predicate: "1.20.x", ver: "1.20.6", want: true},
+		{predicate: "1.20.x", ver: "1.21", want: false},
+		{predicate: "1.20.*", ver: "1.20.1", want: true},
+		{predicate: "*", ver: "1.7.10", want: true},
+		{predicate: "", ver: "1.7.10", want: true},
+		{predicate: ">=1.20.5-pre1", ver: "1.20.5", want: true},
+		{predicate: ">=1.20.5-rc1", ver: "1.20.5-pre3", want: false},
+		{predicate: ">=1.20.5-pre2", ver: "1.20.5-pre10", want: true},
+		{predicate: "<1.20.5", ver: "1.20.5-rc1", want: true},
+		{predicate: ">=abc", ver: "1.20", wantErr: true},
+		{predicate: ">=1.20", ver: "24w14a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := MatchFabricPredicate(tt.predicate, tt.ver)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MatchFabricPredicate(%q, %q) err = %v, wantErr %v", tt.predicate, tt.ver, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("MatchFabricPredicate(%q, %q) = %v, want %v", tt.predicate, tt.ver, got, tt.want)
+		}
+	}
+}
+
+func TestMatchFabricPredicates(t *testing.T) {
+	tests := []struct {
+		predicates []string
+		ver        string
+		want       bool
+		wantErr    bool
+	}{
+		{predicates: nil, ver: "1.20.1", want: true},
+		{predicates: []string{"1.19.x", "1.20.x"}, ver: "1.20.2", want: true},
+		{predicates: []string{"1.19.x", "1.20.x"}, ver: "1.18.2", want: false},
+		{predicates: []string{">=bad", "~1.20"}, ver: "1.20.1", want: true}, // 任一满足即可，忽略无法解析的谓词
+		{predicates: []string{">=bad"}, ver: "1.20.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := MatchFabricPredicates(tt.predicates, tt.ver)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MatchFabricPredicates(%q, %q) err = %v, wantErr %v", tt.predicates, tt.ver, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MatchFabricPredicates(%q, %q) = %v, want %v", tt.predicates, tt.ver, got, tt.want)
+		}
+	}
+}
+
+func TestComparePreRelease(t *testing.T) {
+	ordered := []string{"1.20.5-pre1", "1.20.5-pre2", "1.20.5-pre10", "1.20.5-rc1", "1.20.5", "1.20.6"}
+	for i := 0; i < len(ordered)-1; i++ {
+		got, err := Compare(ordered[i], ordered[i+1])
+		if err != nil {
+			t.Fatalf("Compare(%s, %s): %v", ordered[i], ordered[i+1], err)
+		}
+		if got != -1 {
+			t.Errorf("Compare(%s, %s) = %d, want -1", ordered[i], ordered[i+1], got)
+		}
+	}
+
+	if got, _ := Compare("1.20", "1.20.0"); got != 0 {
+		t.Errorf("Compare(1.20, 1.20.0) = %d, want 0", got)
+	}
+	if got, _ := Compare("0.15.0+build.1", "0.15.0"); got != 0 {
+		t.Errorf("构建元数据不应参与比较, got %d", got)
+	}
+}
@@ -0,0 +1,139 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version 点分数字版本号（如Minecraft的 1.20.1、Forge的 47.2.0），可带预发布后缀
+type Version struct {
+	Numbers []int  // 数字部分
+	Pre     string // 预发布标识，如 pre1、rc1
+	Raw     string // 原始字符串
+}
+
+// Parse 解析版本号，支持 "1.20.1"、"1.21"、"1.20.5-pre1"、"1.20.5-rc1" 等格式
+//
+// 快照版本（如 24w14a）无法与正式版比较，会返回错误。
+func Parse(s string) (Version, error) {
+	raw := strings.TrimSpace(s)
+	if raw == "" {
+		return Version{}, fmt.Errorf("版本号为空")
+	}
+
+	main, pre := raw, ""
+	// 去掉构建元数据（+build）
+	if idx := strings.Index(main, "+"); idx >= 0 {
+		main = main[:idx]
+	}
+	if idx := strings.IndexAny(main, "- "); idx >= 0 {
+		main, pre = main[:idx], strings.TrimSpace(main[idx+1:])
+	}
+
+	parts := strings.Split(main, ".")
+	numbers := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("无效的版本号: %s", raw)
+		}
+		numbers = append(numbers, n)
+	}
+
+	return Version{Numbers: numbers, Pre: pre, Raw: raw}, nil
+}
+
+// MustParse 解析版本号，失败时panic，仅用于常量
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String 返回原始版本字符串
+func (v Version) String() string {
+	return v.Raw
+}
+
+// Compare 比较两个版本，返回 -1、0 或 1
+//
+// 缺失的数字段视为0（1.20 == 1.20.0），正式版高于同号的预发布版。
+func (v Version) Compare(other Version) int {
+	length := len(v.Numbers)
+	if len(other.Numbers) > length {
+		length = len(other.Numbers)
+	}
+
+	for i := 0; i < length; i++ {
+		a, b := v.part(i), other.part(i)
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case v.Pre == other.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case other.Pre == "":
+		return -1
+	default:
+		return comparePre(v.Pre, other.Pre)
+	}
+}
+
+// part 获取第i个数字段，不存在时为0
+func (v Version) part(i int) int {
+	if i < len(v.Numbers) {
+		return v.Numbers[i]
+	}
+	return 0
+}
+
+// comparePre 比较预发布标识，数字部分按数值比较（pre2 < pre10）
+func comparePre(a, b string) int {
+	prefixA, numA := splitTrailingNumber(a)
+	prefixB, numB := splitTrailingNumber(b)
+
+	if prefixA != prefixB {
+		return strings.Compare(prefixA, prefixB)
+	}
+	switch {
+	case numA < numB:
+		return -1
+	case numA > numB:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// splitTrailingNumber 拆分末尾数字，如 "rc12" -> ("rc", 12)
+func splitTrailingNumber(s string) (string, int) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	n, _ := strconv.Atoi(s[i:])
+	return strings.TrimRight(s[:i], ".-"), n
+}
+
+// Compare 比较两个版本字符串，任一无法解析时返回错误
+func Compare(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}